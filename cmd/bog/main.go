@@ -8,6 +8,12 @@ import (
 	"github.com/effective-security/x/ctl"
 	"github.com/tbilicode/bogclient/internal/cli"
 	"github.com/tbilicode/bogclient/internal/cli/account"
+	"github.com/tbilicode/bogclient/internal/cli/doctor"
+	"github.com/tbilicode/bogclient/internal/cli/payment"
+	"github.com/tbilicode/bogclient/internal/cli/query"
+	"github.com/tbilicode/bogclient/internal/cli/rates"
+	"github.com/tbilicode/bogclient/internal/cli/secret"
+	"github.com/tbilicode/bogclient/internal/cli/sync"
 	"github.com/tbilicode/bogclient/internal/version"
 )
 
@@ -15,20 +21,27 @@ type app struct {
 	cli.Cli
 
 	Account account.Cmd `cmd:"" help:"Account operations"`
+	Payment payment.Cmd `cmd:"" help:"Payment operations"`
+	Rates   rates.Cmd   `cmd:"" help:"Exchange rates"`
+	Secret  secret.Cmd  `cmd:"" help:"Encrypted secrets, referenced in the config as secret:NAME"`
+	Query   query.Cmd   `cmd:"" help:"Find records in the local store or a statement file"`
+	Sync    sync.Cmd    `cmd:"" help:"Fetch new statement records into the local store"`
+	Doctor  doctor.Cmd  `cmd:"" help:"Check the configuration, connectivity and credentials"`
 }
 
 func main() {
-	realMain(os.Args, os.Stdout, os.Stderr, os.Exit)
+	realMain(os.Args, os.Stdin, os.Stdout, os.Stderr, os.Exit)
 }
 
-func realMain(args []string, out io.Writer, errout io.Writer, exit func(int)) {
+func realMain(args []string, in io.Reader, out io.Writer, errout io.Writer, exit func(int)) {
 	cl := app{
 		Cli: cli.Cli{
 			Version: ctl.VersionFlag("0.1.1"),
 		},
 	}
-	// cl.Cli.WithErrWriter(errout).
-	// 	WithWriter(out)
+	cl.Cli.WithErrWriter(errout).
+		WithWriter(out).
+		WithReader(in)
 
 	parser, err := kong.New(&cl,
 		kong.Name("bog"),
@@ -51,7 +64,16 @@ func realMain(args []string, out io.Writer, errout io.Writer, exit func(int)) {
 	parser.FatalIfErrorf(err)
 
 	if ctx != nil {
+		if cl.AllProfiles {
+			// errors of each profile are reported with hints by RunAllProfiles
+			cmd := ctx.Selected().Target.Addr().Interface()
+			err = cl.Cli.RunAllProfiles(cmd, func() error {
+				return ctx.Run(&cl.Cli)
+			})
+			ctx.FatalIfErrorf(err)
+			return
+		}
 		err = ctx.Run(&cl.Cli)
-		ctx.FatalIfErrorf(err)
+		ctx.FatalIfErrorf(cli.WithHint(err))
 	}
 }
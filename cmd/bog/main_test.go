@@ -0,0 +1,870 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+	"github.com/tbilicode/bogclient/pkg/store"
+	"github.com/xuri/excelize/v2"
+	"gopkg.in/yaml.v3"
+)
+
+type runResult struct {
+	out  string
+	err  string
+	code int
+}
+
+func run(args ...string) runResult {
+	return runWithInput("", args...)
+}
+
+// runWithInput runs the command with the input, such as answers to confirmations
+func runWithInput(input string, args ...string) runResult {
+	var out, errout bytes.Buffer
+	res := runResult{code: -1}
+	realMain(append([]string{"bog"}, args...), strings.NewReader(input), &out, &errout, func(code int) {
+		res.code = code
+	})
+	res.out = out.String()
+	res.err = errout.String()
+	return res
+}
+
+func newTestServer(t *testing.T) (*bogtest.Server, string) {
+	srv := bogtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, srv.LoadStatements("../../pkg/bogapi/testdata/statement_feb.json"))
+	require.NoError(t, srv.LoadBalance("GE12BG0000000106360002", "USD", "../../pkg/bogapi/testdata/account_balance.json"))
+
+	dir := t.TempDir()
+	require.NoError(t, srv.WriteConfig(filepath.Join(dir, "config.yaml")))
+	return srv, dir
+}
+
+func TestAccountStatement(t *testing.T) {
+	_, dir := newTestServer(t)
+	out := filepath.Join(dir, "statement.json")
+
+	res := run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"account", "statement", "--from", "2025-02-01", "--to", "2025-02-28", "--out", out)
+	require.Equal(t, -1, res.code, res.err)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Len(t, doc.Combined, 6)
+
+	res = run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"account", "statement", "--period", "2025-02", "--out", out)
+	require.Equal(t, -1, res.code, res.err)
+
+	res = run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"account", "statement", "--from", "2025-02-28", "--to", "2025-02-01")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "invalid period: 2025-02-28 is after 2025-02-01")
+
+	res = run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"account", "statement", "--period", "2025-02", "--month", "2")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "either period, month, or start and end dates must be provided")
+}
+
+func TestAccountBalance(t *testing.T) {
+	srv, dir := newTestServer(t)
+
+	res := run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"--o", "json", "account", "balance")
+	// GEL and EUR balances are not seeded
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "failed to get balance")
+	assert.Contains(t, res.err, "account GE12BG0000000106360001 EUR was not found")
+
+	res = run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"--o", "json", "account", "balance", "--continue-on-error", "--workers", "1")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.err, "WARNING: GE12BG0000000106360001 EUR")
+	assert.Contains(t, res.out, "GE12BG0000000106360002 USD")
+
+	for _, acc := range srv.Config().Accounts {
+		for _, currency := range acc.Currency {
+			srv.SetBalance(acc.ID, currency, &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(10), CurrentBalance: bogapi.MoneyFromFloat(10)})
+		}
+	}
+
+	res = run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"),
+		"--o", "json", "account", "balance")
+	require.Equal(t, -1, res.code, res.err)
+
+	var balances map[string]*bogapi.AccountBalance
+	require.NoError(t, json.Unmarshal([]byte(res.out), &balances))
+	assert.Len(t, balances, 6)
+}
+
+func TestAccountToday(t *testing.T) {
+	srv, dir := newTestServer(t)
+	args := []string{"--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"), "account", "today"}
+
+	res := run(args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "No operations today")
+
+	srv.AddRecords("GE12BG0000000106360002", "USD", bogapi.Record{
+		EntryDate:           bogapi.Time(time.Now().UTC()),
+		EntryDocumentNumber: "INCOMING1",
+		EntryAmountCredit:   bogapi.MoneyFromFloat(1500),
+		SenderDetails:       bogapi.SenderDetails{Name: "ACME Corp"},
+		DocumentNomination:  "Invoice 42",
+	})
+
+	res = run(args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "INCOMING1")
+	assert.Contains(t, res.out, "1500.00")
+	assert.Contains(t, res.out, "ACME Corp")
+}
+
+func TestPayment(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SeedPaymentAccount()
+	cfg := filepath.Join(dir, "config.yaml")
+	args := []string{"--storage", dir, "--cfg", cfg, "--o", "json", "payment", "create",
+		"--from", bogtest.PaymentAccount,
+		"--to", "GE29NB0000000101904917",
+		"--name", "Revenue Service",
+		"--inn", "204469032",
+		"--amount", "100.5",
+		"--nomination", "Income tax",
+	}
+
+	res := run(append(args, "--dry-run")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Dry run")
+	assert.Contains(t, res.out, `"BeneficiaryAccountNumber": "GE29NB0000000101904917"`)
+	assert.Equal(t, 0, srv.Payments())
+
+	// the printed document has the ID, which would be sent
+	res = run(append(args, "--dry-run", "--id", "tax-2025-02")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, `"UniqueId": "tax-2025-02"`)
+	res = run(append(args, "--dry-run")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Regexp(t, `"UniqueId": "[0-9a-f-]{36}"`, res.out)
+
+	res = run(append(args, "--currency", "USD", "--dry-run")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "domestic transfers must be in GEL")
+
+	res = run(append(args, "--amount", "1,200.50", "--dry-run")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "invalid amount: 1,200.50, use a dot for decimals and no grouping, like 1200.50")
+
+	res = run(args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, 1, srv.Payments())
+
+	var created bogapi.PaymentResponse
+	require.NoError(t, json.Unmarshal([]byte(res.out), &created))
+	key := strconv.FormatInt(created.UniqueKey, 10)
+
+	res = run("--storage", dir, "--cfg", cfg, "--o", "json", "payment", "status", key)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, bogapi.PaymentStatusInProgress)
+
+	res = run("--storage", dir, "--cfg", cfg, "payment", "cancel", key)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Payment "+key+" canceled")
+}
+
+func TestAccountExchange(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SetRate("USD", 2.70, 2.75)
+	srv.SetRate("EUR", 2.90, 3.00)
+	args := []string{"--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"), "--o", "json",
+		"account", "exchange", "--from-currency", "usd", "--to-currency", "eur", "--amount", "100",
+	}
+
+	// both accounts have USD and EUR sub-accounts
+	res := run(args...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "--account is required")
+
+	res = run(append(args, "--amount", "1 000")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "invalid amount: 1 000, use a dot for decimals")
+
+	args = append(args, "--account", "GE12BG0000000106360002")
+	res = runWithInput("n\n", args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Exchange 100.00 USD to 90.00 EUR at 0.9 on GE12BG0000000106360002")
+	assert.Contains(t, res.out, "Exchange canceled")
+	assert.Equal(t, 0, srv.Payments())
+
+	res = runWithInput("y\n", args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, 1, srv.Payments())
+	assert.Contains(t, res.out, `"UniqueKey"`)
+
+	srv.SetRate("CHF", 3.00, 3.10)
+	res = run(append(args, "--to-currency", "CHF", "--yes")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "account not found: GE12BG0000000106360002 CHF")
+}
+
+func TestPaymentBatch(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SeedPaymentAccount()
+	cfg := filepath.Join(dir, "config.yaml")
+	out := filepath.Join(dir, "results.csv")
+	args := []string{"--storage", dir, "--cfg", cfg, "payment", "batch", "../../pkg/bogapi/testdata/payment_batch.csv",
+		"--from", bogtest.PaymentAccount, "--out", out}
+
+	res := run(append(args, "--dry-run")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Dry run, 3 payments are valid")
+	assert.Equal(t, 0, srv.Payments())
+
+	res = run(append(args, "--no-wait")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, 3, srv.Payments())
+	assert.Contains(t, res.out, "Results are written to "+out)
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, 3, strings.Count(string(data), bogapi.PaymentStatusInProgress))
+
+	// running the same file again does not duplicate the payments
+	res = run(append(args, "--no-wait")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, 3, srv.Payments())
+	again, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, string(data), string(again))
+
+	// the results are written next to the input file by default
+	in := filepath.Join(dir, "batch.xlsx")
+	f := excelize.NewFile()
+	require.NoError(t, f.SetSheetRow(f.GetSheetName(0), "A1", &[]any{"IBAN", "Name", "Amount", "Nomination"}))
+	require.NoError(t, f.SetSheetRow(f.GetSheetName(0), "A2", &[]any{"GE29NB0000000101904917", "Revenue Service", 15, "Income tax"}))
+	require.NoError(t, f.SaveAs(in))
+	res = run("--storage", dir, "--cfg", cfg, "payment", "batch", in, "--from", bogtest.PaymentAccount, "--no-wait")
+	require.Equal(t, -1, res.code, res.err)
+	assert.FileExists(t, filepath.Join(dir, "batch.results.csv"))
+	assert.Equal(t, 4, srv.Payments())
+}
+
+func TestRates(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SetOfficialRate("2025-02-19", "USD", 1, 2.8123)
+	srv.SetOfficialRate("2025-02-19", "EUR", 1, 2.9361)
+	args := []string{"--storage", dir, "--cfg", filepath.Join(dir, "config.yaml")}
+
+	res := run(append(args, "rates", "usd", "--date", "2025-02-19")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "2.8123")
+	assert.NotContains(t, res.out, "2.9361")
+	assert.FileExists(t, filepath.Join(dir, "rates", "nbg", "2025-02-19.json"))
+
+	res = run(append(args, "rates", "check", "../../pkg/bogapi/testdata/statement_feb.json")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "-1.47%")
+
+	res = run(append(args, "rates", "check", "../../pkg/bogapi/testdata/statement_feb.json", "--tolerance", "2")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "All document rates are within 2% of the official rates")
+	assert.Equal(t, 1, srv.Requests(bogtest.NBGPath))
+}
+
+func TestProfiles(t *testing.T) {
+	acme, dir := newTestServer(t)
+	beta := bogtest.NewServer()
+	t.Cleanup(beta.Close)
+	beta.SetBalance("GE12BG0000000106360002", "EUR", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(42), CurrentBalance: bogapi.MoneyFromFloat(42)})
+
+	data, err := yaml.Marshal(&bogapi.Config{
+		Profiles: map[string]*bogapi.Config{
+			"acme": acme.Config(),
+			"beta": beta.Config(),
+		},
+	})
+	require.NoError(t, err)
+	cfg := filepath.Join(dir, "profiles.yaml")
+	require.NoError(t, os.WriteFile(cfg, data, 0600))
+	args := []string{"--storage", dir, "--cfg", cfg, "--o", "json"}
+
+	res := run(append(args, "--profile", "beta", "account", "balance")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "GE12BG0000000106360002 EUR")
+
+	res = run(append(args, "--profile", "gamma", "account", "balance")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "profile not found: gamma, available: acme, beta")
+
+	t.Setenv("BOG_PROFILE", "acme")
+	res = run(append(args, "account", "balance", "--account", "GE12BG0000000106360002", "--currency", "USD")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "GE12BG0000000106360002 USD")
+
+	// only USD balance is seeded for acme
+	res = run(append(args, "--all-profiles", "account", "balance")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "ERROR: profile acme: failed to fetch 5 accounts")
+	assert.Contains(t, res.err, "failed for 1 of 2 profiles: acme")
+
+	var combined map[string]map[string]*bogapi.AccountBalance
+	require.NoError(t, json.Unmarshal([]byte(res.out), &combined))
+	assert.Len(t, combined, 1)
+	assert.Equal(t, "42", combined["beta"]["GE12BG0000000106360002 EUR"].AvailableBalance.String())
+
+	res = run(append(args, "--all-profiles", "account", "balance", "--continue-on-error")...)
+	require.Equal(t, -1, res.code, res.err)
+	require.NoError(t, json.Unmarshal([]byte(res.out), &combined))
+	assert.Len(t, combined, 2)
+	assert.Equal(t, "23083.33", combined["acme"]["GE12BG0000000106360002 USD"].AvailableBalance.String())
+
+	res = run("--storage", dir, "--cfg", cfg, "--all-profiles", "account", "today", "--continue-on-error")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Profile: acme\nNo operations today\nProfile: beta\nNo operations today\n")
+
+	// documents are never sent for every profile at once
+	for _, cmd := range [][]string{
+		{"payment", "create", "--from", bogtest.PaymentAccount, "--to", "GE29NB0000000101904917",
+			"--name", "Revenue Service", "--amount", "10", "--nomination", "tax"},
+		{"payment", "batch", "../../pkg/bogapi/testdata/payment_batch.csv", "--from", bogtest.PaymentAccount},
+		{"payment", "cancel", "1"},
+		{"account", "exchange", "--from-currency", "USD", "--to-currency", "EUR", "--amount", "10", "-y"},
+	} {
+		res = runWithInput("y\n", append(append(args, "--all-profiles"), cmd...)...)
+		assert.Equal(t, 1, res.code, cmd)
+		assert.Contains(t, res.err, "can not run with --all-profiles, use --profile")
+	}
+	assert.Equal(t, 0, acme.Payments()+beta.Payments())
+
+	res = run(append(args, "--all-profiles", "payment", "create", "--from", bogtest.PaymentAccount,
+		"--to", "GE29NB0000000101904917", "--name", "Revenue Service", "--amount", "10", "--nomination", "tax", "--dry-run")...)
+	require.Equal(t, -1, res.code, res.err)
+	var docs map[string]*bogapi.PaymentRequest
+	require.NoError(t, json.Unmarshal([]byte(res.out), &docs))
+	assert.Len(t, docs, 2)
+	assert.Equal(t, "10", docs["beta"].Amount.String())
+}
+
+func TestSecrets(t *testing.T) {
+	srv, dir := newTestServer(t)
+	args := []string{"--storage", dir}
+
+	res := runWithInput("pass\n"+bogtest.ClientSecret+"\n", append(args, "secret", "set", "bog")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, "Saved secret: bog\n", res.out)
+	assert.FileExists(t, filepath.Join(dir, "secrets.enc"))
+
+	res = runWithInput("wrong\n", append(args, "secret", "list")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "invalid passphrase or corrupted secrets file")
+
+	t.Setenv("BOG_PASSPHRASE", "pass")
+	res = run(append(args, "--o", "json", "secret", "list")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.JSONEq(t, `["bog"]`, res.out)
+
+	cfg := srv.Config()
+	cfg.ClientSecret = "secret:bog"
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(dir, "secret.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+
+	res = run(append(args, "--cfg", cfgFile, "account", "balance", "--account", "GE12BG0000000106360002", "--currency", "USD")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "GE12BG0000000106360002 USD")
+
+	res = run(append(args, "secret", "delete", "bog")...)
+	require.Equal(t, -1, res.code, res.err)
+
+	res = run(append(args, "--cfg", cfgFile, "account", "balance")...)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "client_secret: failed to resolve secret secret: secret not found: bog")
+}
+
+func TestAccountDiscover(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SetBalance("GE12BG0000000106360001", "GEL", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(10)})
+	cfg := filepath.Join(dir, "config.yaml")
+	before, err := bogapi.LoadConfig(cfg)
+	require.NoError(t, err)
+
+	// only the probed currency is removed, other configured currencies are kept
+	res := run("--storage", dir, "--cfg", cfg, "account", "discover", "--currency", "eur", "--write")
+	require.Equal(t, -1, res.code, res.err)
+	loaded, err := bogapi.LoadConfig(cfg)
+	require.NoError(t, err)
+	require.Len(t, loaded.Accounts, len(before.Accounts))
+	for i, acc := range before.Accounts {
+		assert.Equal(t, slices.DeleteFunc(acc.Currency, func(c string) bool { return c == "EUR" }), loaded.Accounts[i].Currency)
+	}
+
+	args := []string{"--storage", dir, "--cfg", cfg, "account", "discover", "--currency", "gel,usd,eur"}
+	res = run(args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "│ - │ GE12BG0000000106360001 │      │ USD      │")
+	assert.NotContains(t, res.out, "Updated accounts")
+
+	res = run(append(args, "--write")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Updated accounts in "+cfg)
+
+	loaded, err = bogapi.LoadConfig(cfg)
+	require.NoError(t, err)
+	require.Len(t, loaded.Accounts, 2)
+	assert.Equal(t, []string{"GEL"}, loaded.Accounts[0].Currency)
+	assert.Equal(t, []string{"USD"}, loaded.Accounts[1].Currency)
+
+	res = run(args...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, "Configuration is up to date\n", res.out)
+}
+
+func TestDoctor(t *testing.T) {
+	srv, dir := newTestServer(t)
+	srv.SetBalance("GE35BG0000000106360001", "GEL", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(10)})
+
+	// fixture accounts have invalid checksums, and only USD balance is seeded
+	res := run("--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"), "doctor")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.out, "invalid IBAN checksum: GE12BG0000000106360001")
+	assert.Contains(t, res.out, "│ authenticate")
+	assert.Contains(t, res.err, "checks failed")
+
+	cfg := srv.Config()
+	cfg.Accounts = []bogapi.Account{{ID: "GE35BG0000000106360001", Currency: []string{"GEL", "XYZ"}}}
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(dir, "doctor.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json", "doctor")
+	assert.Equal(t, 1, res.code)
+	var report struct {
+		OK     bool `json:"ok"`
+		Checks []struct {
+			Name   string `json:"name"`
+			Status string `json:"status"`
+			Detail string `json:"detail"`
+		} `json:"checks"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.out), &report))
+	assert.False(t, report.OK)
+	statuses := make(map[string]string)
+	for _, c := range report.Checks {
+		statuses[c.Name] = c.Status
+	}
+	assert.Equal(t, map[string]string{
+		"storage":                            "pass",
+		"config":                             "pass",
+		"required fields":                    "pass",
+		"secrets":                            "pass",
+		"account GE35BG0000000106360001":     "fail",
+		"auth_url":                           "pass",
+		"api_host":                           "pass",
+		"authenticate":                       "pass",
+		"balance GE35BG0000000106360001 GEL": "pass",
+		"balance GE35BG0000000106360001 XYZ": "fail",
+	}, statuses)
+
+	cfg.Accounts[0].Currency = []string{"GEL"}
+	data, err = yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+	res = run("--storage", dir, "--cfg", cfgFile, "doctor")
+	require.Equal(t, -1, res.code, res.err)
+	assert.NotContains(t, res.out, "FAIL")
+
+	cfg.ClientSecret = "wrong"
+	data, err = yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+	res = run("--storage", dir, "--cfg", cfgFile, "doctor")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.out, "check client_id and client_secret")
+}
+
+func TestSync(t *testing.T) {
+	srv, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+
+	res := run("--storage", dir, "--cfg", cfgFile, "sync", "--status")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "No synced accounts")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json",
+		"sync", "--account", "GE12BG0000000106360002", "--since", "2025-02")
+	require.Equal(t, -1, res.code, res.err)
+	var results []*store.SyncResult
+	require.NoError(t, json.Unmarshal([]byte(res.out), &results))
+	require.Len(t, results, 3)
+	added := 0
+	for _, r := range results {
+		assert.Equal(t, "2025-02-01", r.From)
+		added += r.Added
+	}
+	assert.Equal(t, 6, added)
+
+	// the next sync starts from the last synced date
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json",
+		"sync", "--account", "GE12BG0000000106360002", "--currency", "GEL", "--overlap", "0")
+	require.Equal(t, -1, res.code, res.err)
+	require.NoError(t, json.Unmarshal([]byte(res.out), &results))
+	require.Len(t, results, 1)
+	assert.Equal(t, results[0].To, results[0].From)
+	assert.Equal(t, 0, results[0].Added)
+
+	res = run("--storage", dir, "--cfg", cfgFile, "sync", "--status")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "GE12BG0000000106360002")
+
+	out := filepath.Join(dir, "local.json")
+	res = run("--storage", dir, "--cfg", cfgFile,
+		"account", "statement", "--period", "2025-02", "--local", "--out", out)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.err, "WARNING: GE12BG0000000106360001 GEL is not synced, run bog sync")
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Len(t, doc.Combined, 6)
+	records := 0
+	for _, st := range doc.Combined {
+		records += len(st.Records)
+	}
+	assert.Equal(t, 6, records)
+
+	// summaries and conversions are built from the store too
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json",
+		"account", "statement", "--period", "2025-02", "--local", "--summary", "--account", "GE12BG0000000106360002")
+	require.Equal(t, -1, res.code, res.err)
+	doc = bogapi.AccountStatements{}
+	require.NoError(t, json.Unmarshal([]byte(res.out), &doc))
+	require.NotEmpty(t, doc.Combined)
+	records = 0
+	for _, st := range doc.Combined {
+		assert.NotNil(t, st.Summary, st.Currency)
+		records += len(st.Records)
+	}
+
+	csvFile := filepath.Join(dir, "local.csv")
+	res = run("--storage", dir, "--cfg", cfgFile,
+		"account", "convert", "--local", "--period", "2025-02", "--account", "GE12BG0000000106360002", csvFile)
+	require.Equal(t, -1, res.code, res.err)
+	data, err = os.ReadFile(csvFile)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	assert.Len(t, rows, records+1)
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "convert", "--local", out, csvFile)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "only the output file is expected with --local")
+
+	// failed accounts are reported, and the others are stored
+	srv.Fail("/api/statement/GE12BG0000000106360001/EUR/", http.StatusInternalServerError, 1)
+	res = run("--storage", dir, "--cfg", cfgFile,
+		"sync", "--account", "GE12BG0000000106360001", "--since", "2025-02")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "failed to sync 1 of 3 accounts")
+}
+
+func TestQuery(t *testing.T) {
+	_, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+	in := "../../pkg/bogapi/testdata/statement_feb.json"
+
+	res := run("--storage", dir, "--cfg", cfgFile, "query")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "the local store is empty, run bog sync or specify --in")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "query", "--in", in, "--product", "fee", "--debit")
+	require.Equal(t, -1, res.code, res.err)
+	// amounts in different currencies are not added up
+	assert.Contains(t, res.out, "Found: 2\nEUR debit: 17.39, credit: 0.00\nGEL debit: 50.00, credit: 0.00\n")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "csv",
+		"query", "--in", in, "--counterparty", "avaleris", "--min", "1000")
+	require.Equal(t, -1, res.code, res.err)
+	lines := strings.Split(strings.TrimSpace(res.out), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "Date,Account,Currency,Debit,Credit,Counterparty,Product,Comment", lines[0])
+	assert.Contains(t, lines[1], ",USD,0.00,23583.33,")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json", "query", "--in", in, "--text", "missing")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, "[]\n", res.out)
+
+	res = run("--storage", dir, "--cfg", cfgFile, "query", "--in", in, "--debit", "--credit")
+	assert.NotEqual(t, -1, res.code)
+	assert.Contains(t, res.err, "--debit and --credit can't be used together")
+
+	// records synced to the local store
+	res = run("--storage", dir, "--cfg", cfgFile, "sync", "--account", "GE12BG0000000106360002", "--since", "2025-02")
+	require.Equal(t, -1, res.code, res.err)
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json", "query", "--period", "2025-02", "--currency", "EUR")
+	require.Equal(t, -1, res.code, res.err)
+	var transactions bogapi.TransactionSlice
+	require.NoError(t, json.Unmarshal([]byte(res.out), &transactions))
+	assert.Len(t, transactions, 3)
+}
+
+func TestOffline(t *testing.T) {
+	srv, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+
+	res := run("--storage", dir, "--cfg", cfgFile, "--offline", "account", "balance",
+		"--account", "GE12BG0000000106360002", "--currency", "USD")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "not cached")
+	assert.Contains(t, res.err, "run the command without --offline to fetch it")
+
+	out := filepath.Join(dir, "statement.json")
+	res = run("--storage", dir, "--cfg", cfgFile,
+		"account", "statement", "--account", "GE12BG0000000106360002", "--period", "2025-02", "--out", out)
+	require.Equal(t, -1, res.code, res.err)
+	statements := srv.Requests("/api/statement/")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--offline",
+		"account", "statement", "--account", "GE12BG0000000106360002", "--period", "2025-02", "--out", out)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Equal(t, statements, srv.Requests("/api/statement/"))
+
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+	assert.Len(t, doc.Combined, 3)
+}
+
+func TestAccountConvert(t *testing.T) {
+	srv, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+	srv.SetOpeningBalance("GE12BG0000000106360002", "EUR", bogapi.MoneyFromFloat(1000))
+
+	in := filepath.Join(dir, "statement.json")
+	res := run("--storage", dir, "--cfg", cfgFile, "account", "statement",
+		"--account", "GE12BG0000000106360002", "--currency", "EUR", "--period", "2025-02", "--summary", "--out", in)
+	require.Equal(t, -1, res.code, res.err)
+
+	out := filepath.Join(dir, "statement.csv")
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "convert", in, out)
+	require.Equal(t, -1, res.code, res.err)
+
+	f, err := os.Open(out)
+	require.NoError(t, err)
+	defer f.Close()
+	rows, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	header := rows[0]
+	col := func(row []string, name string) string {
+		for i, h := range header {
+			if h == name {
+				return row[i]
+			}
+		}
+		t.Fatalf("column not found: %s", name)
+		return ""
+	}
+	var balances, eod []string
+	for _, row := range rows[1:] {
+		balances = append(balances, col(row, "Balance"))
+		eod = append(eod, col(row, "Balance at end of day"))
+		assert.Empty(t, col(row, "Balance Mismatch"))
+	}
+	assert.ElementsMatch(t, []string{"1500.00", "1482.61", "1282.61"}, balances)
+	assert.ElementsMatch(t, []string{"1482.61", "1482.61", "1282.61"}, eod)
+
+	// without the summary, the balances are unknown, unless the summary is fetched
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "statement",
+		"--account", "GE12BG0000000106360002", "--currency", "EUR", "--period", "2025-02", "--out", in)
+	require.Equal(t, -1, res.code, res.err)
+	read := func() [][]string {
+		data, err := os.ReadFile(out)
+		require.NoError(t, err)
+		rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, rows, 4)
+		return rows
+	}
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "convert", in, out)
+	require.Equal(t, -1, res.code, res.err)
+	for _, row := range read()[1:] {
+		assert.Empty(t, col(row, "Balance"))
+		assert.Empty(t, col(row, "Balance at end of day"))
+	}
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "convert", "--balances", in, out)
+	require.Equal(t, -1, res.code, res.err)
+	balances = nil
+	for _, row := range read()[1:] {
+		balances = append(balances, col(row, "Balance"))
+	}
+	assert.ElementsMatch(t, []string{"1500.00", "1482.61", "1282.61"}, balances)
+}
+
+func TestAccountConvert_CurrentBalance(t *testing.T) {
+	srv, dir := newTestServer(t)
+	args := []string{"--storage", dir, "--cfg", filepath.Join(dir, "config.yaml"), "--cache-ttl", "3600"}
+
+	// the balance is cached before the last transaction of today
+	res := run(append(args, "account", "balance", "--account", "GE12BG0000000106360002", "--currency", "USD")...)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "23083.33")
+	srv.SetBalance("GE12BG0000000106360002", "USD", &bogapi.AccountBalance{
+		AvailableBalance: bogapi.MoneyFromFloat(23183.33),
+		CurrentBalance:   bogapi.MoneyFromFloat(23183.33),
+	})
+
+	today := time.Now().In(bogapi.Location)
+	doc := &bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "USD",
+		StartDate: today.Format(bogapi.DateFormat),
+		EndDate:   today.Format(bogapi.DateFormat),
+		Records: []bogapi.Record{{
+			EntryId:           1,
+			EntryDate:         bogapi.Time(today),
+			EntryAmountCredit: bogapi.MoneyFromFloat(100),
+			EntryAmount:       bogapi.MoneyFromFloat(100),
+		}},
+	}}}
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	in, out := filepath.Join(dir, "today.json"), filepath.Join(dir, "today.csv")
+	require.NoError(t, os.WriteFile(in, data, 0600))
+
+	res = run(append(args, "account", "convert", "--balances", in, out)...)
+	require.Equal(t, -1, res.code, res.err)
+	data, err = os.ReadFile(out)
+	require.NoError(t, err)
+	rows, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "Balance", rows[0][34])
+	assert.Equal(t, "23183.33", rows[1][34])
+}
+
+func TestAccountVerify(t *testing.T) {
+	srv, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+	srv.SetOpeningBalance("GE12BG0000000106360002", "EUR", bogapi.MoneyFromFloat(1000))
+
+	files := make([]string, 2)
+	for i, period := range []string{"2025-01", "2025-02"} {
+		files[i] = filepath.Join(dir, period+".json")
+		res := run("--storage", dir, "--cfg", cfgFile, "account", "statement",
+			"--account", "GE12BG0000000106360002", "--currency", "EUR", "--period", period, "--summary", "--out", files[i])
+		require.Equal(t, -1, res.code, res.err)
+	}
+
+	res := run("--storage", dir, "--cfg", cfgFile, "account", "verify", files[1], files[0])
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "Verified 2 statements, no discrepancies")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "verify",
+		"--account", "GE12BG0000000106360002", "--currency", "EUR", "--period", "2025-02")
+	require.Equal(t, -1, res.code, res.err)
+
+	// a record removed from the saved statement
+	data, err := os.ReadFile(files[1])
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+	doc.Combined[0].Records = doc.Combined[0].Records[1:]
+	data, err = json.Marshal(&doc)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(files[1], data, 0600))
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json", "account", "verify", files[0], files[1])
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "discrepancies in 2 statements")
+	var list []*bogapi.Discrepancy
+	require.NoError(t, json.Unmarshal([]byte(res.out), &list))
+	require.NotEmpty(t, list)
+	for _, d := range list {
+		assert.Equal(t, "2025-02-01..2025-02-28", d.Period)
+	}
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "verify")
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "either statement files or period must be provided")
+}
+
+func TestAccountCategorize(t *testing.T) {
+	_, dir := newTestServer(t)
+	cfgFile := filepath.Join(dir, "config.yaml")
+	in := "../../pkg/bogapi/testdata/statement_feb.json"
+
+	res := run("--storage", dir, "--cfg", cfgFile, "account", "categorize", in)
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "no categorization rules, create "+filepath.Join(dir, "rules.yaml"))
+
+	rules := "rules:\n" +
+		"  - name: telecom\n    category: utilities\n    tags: [phone]\n    mcc: [4814]\n" +
+		"  - name: fees\n    category: bank fees\n    product_group: FEE\n    side: debit\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "rules.yaml"), []byte(rules), 0600))
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "categorize", in)
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "(uncategorized)")
+	assert.Contains(t, res.out, "Uncategorized: 5, run with --explain to list them")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "categorize", in, "--explain")
+	require.Equal(t, -1, res.code, res.err)
+	assert.Contains(t, res.out, "telecom")
+	assert.Contains(t, res.out, "Uncategorized: 5")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "--o", "json", "account", "categorize", in)
+	require.Equal(t, -1, res.code, res.err)
+	var totals []struct {
+		Category string       `json:"category"`
+		Currency string       `json:"currency"`
+		Records  int          `json:"records"`
+		Debit    bogapi.Money `json:"debit"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(res.out), &totals))
+	require.Len(t, totals, 6)
+	assert.Equal(t, "bank fees", totals[0].Category)
+	assert.Equal(t, "EUR", totals[0].Currency)
+	assert.Equal(t, "17.39", totals[0].Debit.String())
+	assert.Equal(t, "utilities", totals[2].Category)
+	assert.Equal(t, "", totals[5].Category)
+
+	// convert adds the categories from rules.yaml in the storage folder
+	out := filepath.Join(dir, "statement.csv")
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "convert", in, out)
+	require.Equal(t, -1, res.code, res.err)
+	data, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ",Category,Tags,Original Amount,")
+	assert.Contains(t, string(data), ",utilities,phone,135.00,GEL,4814,salerequest.silknet.com,2025-02-19T16:06:42+04:00,42222*******0002,442775\n")
+
+	res = run("--storage", dir, "--cfg", cfgFile, "account", "categorize", in, "--rules", filepath.Join(dir, "missing.yaml"))
+	assert.Equal(t, 1, res.code)
+	assert.Contains(t, res.err, "failed to read rules")
+}
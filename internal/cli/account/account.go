@@ -4,24 +4,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 
 	"github.com/mitchellh/go-homedir"
 	"github.com/tbilicode/bogclient/internal/cli"
 	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
 	"github.com/tbilicode/bogclient/pkg/translate"
 )
 
 type Cmd struct {
-	Statement StatementCmd `cmd:"" help:"create statement"`
-	Balance   BalanceCmd   `cmd:"" help:"prints account balance"`
-	Translate TranslateCmd `cmd:"" help:"translate statement to English, requires GOOGLE API KEY"`
-	Convert   ConvertCmd   `cmd:"" help:"convert statement to CSV or Excel"`
+	Statement  StatementCmd  `cmd:"" help:"create statement"`
+	Balance    BalanceCmd    `cmd:"" help:"prints account balance"`
+	Today      TodayCmd      `cmd:"" help:"prints today's operations, which are not in statements yet"`
+	Translate  TranslateCmd  `cmd:"" help:"translate statement to English, requires GOOGLE API KEY"`
+	Convert    ConvertCmd    `cmd:"" help:"convert statement to CSV or Excel"`
+	Exchange   ExchangeCmd   `cmd:"" help:"exchange currency between sub-accounts of the same account"`
+	Discover   DiscoverCmd   `cmd:"" help:"find currency sub-accounts visible to the credentials, and compare with the configuration"`
+	Verify     VerifyCmd     `cmd:"" help:"reconcile statements with the bank summaries, and report discrepancies"`
+	Categorize CategorizeCmd `cmd:"" help:"assign categories to statement records by rules"`
 }
 
 // BalanceCmd prints account balance
 type BalanceCmd struct {
+	Account         string `help:"Filter by account, empty for all"`
+	Currency        string `help:"Filter by currency, empty for all"`
+	Workers         int    `help:"number of accounts fetched concurrently" default:"4"`
+	ContinueOnError bool   `help:"print warnings and continue if some accounts fail"`
 }
 
 func (cmd *BalanceCmd) Run(ctx *cli.Cli) error {
@@ -30,55 +41,127 @@ func (cmd *BalanceCmd) Run(ctx *cli.Cli) error {
 		return err
 	}
 
-	res, err := client.AllBalances(ctx.Context())
-	if err != nil {
+	res, err := client.AllBalances(ctx.Context(), &bogapi.BalanceRequest{
+		Account:  cmd.Account,
+		Currency: cmd.Currency,
+		Workers:  cmd.Workers,
+	})
+	if err = checkFetchError(ctx, err, cmd.ContinueOnError); err != nil {
 		return err
 	}
 
 	return ctx.Print(res)
 }
 
-// StatementCmd create statement
-type StatementCmd struct {
-	Account  string `help:"Filter by account, empty for all"`
-	Currency string `help:"Filter by currency, empty for all"`
-	Month    int    `help:"month to summarize, in 1-12 format"`
-	From     string `help:"start date"`
-	To       string `help:"end date"`
-	Summary  bool   `help:"add summary"`
-	Out      string `help:"output file, if not provided prints to stdout"`
+// TodayCmd prints today's operations
+type TodayCmd struct {
+	Account         string `help:"Filter by account, empty for all"`
+	Currency        string `help:"Filter by currency, empty for all"`
+	Workers         int    `help:"number of accounts fetched concurrently" default:"4"`
+	ContinueOnError bool   `help:"print warnings and continue if some accounts fail"`
 }
 
-func (cmd *StatementCmd) Run(ctx *cli.Cli) error {
-	if cmd.Month == 0 && (cmd.From == "" || cmd.To == "") {
-		return errors.New("either month or start and end dates must be provided")
+func (cmd *TodayCmd) Run(ctx *cli.Cli) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
 	}
-	if cmd.Month != 0 && (cmd.From != "" || cmd.To != "") {
-		return errors.New("either month or start and end dates must be provided")
+
+	res, err := client.AllTodayActivities(ctx.Context(), &bogapi.BalanceRequest{
+		Account:  cmd.Account,
+		Currency: cmd.Currency,
+		Workers:  cmd.Workers,
+	})
+	if err = checkFetchError(ctx, err, cmd.ContinueOnError); err != nil {
+		return err
 	}
 
-	req := &bogapi.StatementRequest{
-		StartDate: cmd.From,
-		EndDate:   cmd.To,
-		Account:   cmd.Account,
-		Currency:  cmd.Currency,
-		Summary:   cmd.Summary,
+	if ctx.O != "table" {
+		return ctx.Print(res)
 	}
 
-	if cmd.Month != 0 {
-		req.StartDate, req.EndDate = bogapi.MonthRange(cmd.Month)
+	var rows [][]string
+	for _, st := range res.Combined {
+		for _, r := range st.Records {
+			// incoming operations show the sender, outgoing the beneficiary
+			counterparty := r.BeneficiaryDetails.Name
+			if r.EntryAmountCredit.Sign() > 0 {
+				counterparty = r.SenderDetails.Name
+			}
+			rows = append(rows, []string{
+				st.Account, st.Currency, r.EntryDocumentNumber,
+				formatAmount(r.EntryAmountDebit.In(st.Currency)), formatAmount(r.EntryAmountCredit.In(st.Currency)),
+				counterparty, r.DocumentNomination,
+			})
+		}
+	}
+	if len(rows) == 0 {
+		fmt.Fprintln(ctx.Writer(), "No operations today")
+		return nil
 	}
+	print.Table(ctx.Writer(), []string{"Account", "Currency", "Document", "Debit", "Credit", "Counterparty", "Nomination"}, rows)
+	return nil
+}
 
-	client, err := ctx.Client()
+func formatAmount(amount bogapi.Money) string {
+	if amount.IsZero() {
+		return ""
+	}
+	return amount.Format()
+}
+
+// StatementCmd create statement
+type StatementCmd struct {
+	Account         string `help:"Filter by account, empty for all"`
+	Currency        string `help:"Filter by currency, empty for all"`
+	Period          string `help:"statement period: 2025-02, 2025-Q1, 2025, ytd, last-month, last-30d, or a range like 2025-01-15..2025-02-10"`
+	Month           int    `help:"month to summarize, in 1-12 format, the latest one not in the future"`
+	From            string `help:"start date"`
+	To              string `help:"end date"`
+	Summary         bool   `help:"add summary"`
+	Out             string `help:"output file, if not provided prints to stdout"`
+	Workers         int    `help:"number of accounts fetched concurrently" default:"4"`
+	ChunkWorkers    int    `help:"number of monthly chunks of one account fetched concurrently" default:"1"`
+	NoSplit         bool   `help:"fetch periods longer than a month in one request, instead of monthly chunks"`
+	ContinueOnError bool   `help:"print warnings and continue if some accounts fail"`
+	Local           bool   `help:"read records from the local store, updated by bog sync"`
+}
+
+func (cmd *StatementCmd) Run(ctx *cli.Cli) error {
+	period, err := statementPeriod(cmd.Period, cmd.Month, cmd.From, cmd.To)
 	if err != nil {
 		return err
 	}
 
-	res, err := client.AllStatements(ctx.Context(), req)
+	req := &bogapi.StatementRequest{
+		StartDate:    period.StartDate(),
+		EndDate:      period.EndDate(),
+		Account:      cmd.Account,
+		Currency:     cmd.Currency,
+		Summary:      cmd.Summary,
+		Workers:      cmd.Workers,
+		ChunkWorkers: cmd.ChunkWorkers,
+		NoSplit:      cmd.NoSplit,
+	}
+
+	client, err := ctx.Client()
 	if err != nil {
 		return err
 	}
 
+	var res *bogapi.AccountStatements
+	if cmd.Local {
+		res, err = localStatements(ctx, client.Accounts(), req)
+		if err != nil {
+			return err
+		}
+	} else {
+		res, err = client.AllStatements(ctx.Context(), req)
+		if err = checkFetchError(ctx, err, cmd.ContinueOnError); err != nil {
+			return err
+		}
+	}
+
 	if cmd.Out != "" {
 		return ctx.WriteFile(cmd.Out, res)
 	}
@@ -86,6 +169,68 @@ func (cmd *StatementCmd) Run(ctx *cli.Cli) error {
 	return ctx.Print(res)
 }
 
+// statementPeriod returns the period specified by one of the flags
+func statementPeriod(period string, month int, from, to string) (*bogapi.Period, error) {
+	switch {
+	case period != "" && month == 0 && from == "" && to == "":
+		return bogapi.ParsePeriod(period)
+	case month != 0 && period == "" && from == "" && to == "":
+		return bogapi.RecentMonth(month)
+	case from != "" && to != "" && period == "" && month == 0:
+		return bogapi.ParsePeriod(from + ".." + to)
+	}
+	return nil, errors.New("either period, month, or start and end dates must be provided")
+}
+
+// localStatements returns the statements from the local store, and warns about accounts
+// not synced to the end of the period
+func localStatements(ctx *cli.Cli, accounts []bogapi.Account, req *bogapi.StatementRequest) (*bogapi.AccountStatements, error) {
+	db, err := ctx.OpenStore()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	res, err := db.Statements(accounts, req)
+	if err != nil {
+		return nil, err
+	}
+	for _, st := range res.Combined {
+		synced, err := db.Synced(st.Account, st.Currency)
+		if err != nil {
+			return nil, err
+		}
+		if synced.IsZero() {
+			fmt.Fprintf(ctx.ErrWriter(), "WARNING: %s %s is not synced, run bog sync\n", st.Account, st.Currency)
+			continue
+		}
+		if synced.Format(bogapi.DateFormat) < req.EndDate {
+			fmt.Fprintf(ctx.ErrWriter(), "WARNING: %s %s is synced to %s only\n",
+				st.Account, st.Currency, synced.Format(bogapi.DateFormat))
+		}
+		if req.Summary && st.Summary == nil {
+			fmt.Fprintf(ctx.ErrWriter(), "WARNING: %s %s has no stored balances, run bog sync --full\n", st.Account, st.Currency)
+		}
+	}
+	return res, nil
+}
+
+// checkFetchError returns err, unless it is a partial failure and continueOnError is set,
+// in which case a warning is printed for each failed account
+func checkFetchError(ctx *cli.Cli, err error, continueOnError bool) error {
+	var ferr *bogapi.FetchError
+	if err == nil || !continueOnError || !errors.As(err, &ferr) {
+		return err
+	}
+	for _, e := range ferr.Errors {
+		fmt.Fprintf(ctx.ErrWriter(), "WARNING: %s\n", e.Error())
+		if hint := cli.ErrorHint(e.Err); hint != "" {
+			fmt.Fprintf(ctx.ErrWriter(), "  %s\n", hint)
+		}
+	}
+	return nil
+}
+
 type TranslateCmd struct {
 	In       string `kong:"arg" help:"input file" required:""`
 	Out      string `kong:"arg" help:"output file" required:""`
@@ -152,25 +297,77 @@ func (cmd *TranslateCmd) Run(ctx *cli.Cli) error {
 }
 
 type ConvertCmd struct {
-	In     string `kong:"arg" help:"input file" required:""`
-	Out    string `kong:"arg" help:"output file" required:""`
-	Format string `help:"output format" enum:"csv,excel" default:"csv"`
-	Dedup  bool   `help:"deduplicate transactions"`
+	In       string `kong:"arg" help:"input file, or the output file with --local" required:""`
+	Out      string `kong:"arg,optional" help:"output file"`
+	Format   string `help:"output format" enum:"csv,excel" default:"csv"`
+	Dedup    bool   `help:"deduplicate transactions"`
+	Balances bool   `help:"fetch summaries, or current balances of statements which end today, to compute running balances of statements without summary"`
+	Rules    string `help:"categorization rules file, rules.yaml in the storage folder is used if exists"`
+	Local    bool   `help:"convert records of the local store, updated by bog sync, instead of the input file"`
+	Period   string `help:"statement period with --local, all stored records if not set"`
+	Account  string `help:"Filter by account with --local, empty for all"`
+	Currency string `help:"Filter by currency with --local, empty for all"`
 }
 
 func (cmd *ConvertCmd) Run(ctx *cli.Cli) error {
-	data, err := os.ReadFile(cmd.In)
-	if err != nil {
-		return err
+	var doc *bogapi.AccountStatements
+	if cmd.Local {
+		if cmd.Out != "" {
+			return errors.New("only the output file is expected with --local")
+		}
+		cmd.Out = cmd.In
+
+		req := &bogapi.StatementRequest{
+			Account:  cmd.Account,
+			Currency: cmd.Currency,
+			Summary:  true,
+		}
+		if cmd.Period != "" {
+			period, err := bogapi.ParsePeriod(cmd.Period)
+			if err != nil {
+				return err
+			}
+			req.StartDate, req.EndDate = period.StartDate(), period.EndDate()
+		}
+		client, err := ctx.Client()
+		if err != nil {
+			return err
+		}
+		if doc, err = localStatements(ctx, client.Accounts(), req); err != nil {
+			return err
+		}
+	} else {
+		if cmd.Out == "" {
+			return errors.New("output file is required")
+		}
+		data, err := os.ReadFile(cmd.In)
+		if err != nil {
+			return err
+		}
+		doc = new(bogapi.AccountStatements)
+		if err = json.Unmarshal(data, doc); err != nil {
+			return err
+		}
 	}
 
-	doc := new(bogapi.AccountStatements)
-	err = json.Unmarshal(data, doc)
+	var balances map[string]*bogapi.AccountBalance
+	if cmd.Balances {
+		client, err := ctx.Client()
+		if err != nil {
+			return err
+		}
+		balances = statementBalances(ctx, client, doc)
+	}
+
+	rules, err := ctx.Rules(cmd.Rules)
 	if err != nil {
 		return err
 	}
 
-	transactions := bogapi.Report(doc)
+	transactions := bogapi.ReportWithBalances(doc, balances)
+	if rules != nil {
+		rules.Apply(transactions)
+	}
 	if cmd.Dedup {
 		transactions = transactions.Dedup()
 	}
@@ -190,3 +387,56 @@ func (cmd *ConvertCmd) Run(ctx *cli.Cli) error {
 		return errors.New("unsupported format")
 	}
 }
+
+// statementBalances fetches summaries of the statements without summary, which end before today,
+// and the current balances of the statements without summary, which end today
+func statementBalances(ctx *cli.Cli, client bogapi.Client, doc *bogapi.AccountStatements) map[string]*bogapi.AccountBalance {
+	today := bogapi.NowFunc().In(bogapi.Location).Format(bogapi.DateFormat)
+	balances := make(map[string]*bogapi.AccountBalance)
+	for _, st := range doc.Combined {
+		if st.Summary != nil {
+			continue
+		}
+		if st.EndDate == today {
+			// a cached balance may miss the latest transactions of the statement
+			res, err := client.AllBalances(ctx.Context(), &bogapi.BalanceRequest{
+				Account:  st.Account,
+				Currency: st.Currency,
+				NoCache:  true,
+			})
+			if err != nil {
+				fmt.Fprintf(ctx.ErrWriter(), "WARNING: balances of %s %s are unknown: %s\n", st.Account, st.Currency, err.Error())
+			}
+			maps.Copy(balances, res)
+			continue
+		}
+		summary, err := statementSummary(ctx, client, st)
+		if err != nil {
+			// the statement may be expired at the bank, its balances are left unknown
+			fmt.Fprintf(ctx.ErrWriter(), "WARNING: balances of %s %s are unknown: %s\n", st.Account, st.Currency, err.Error())
+			continue
+		}
+		st.Summary = summary
+	}
+	return balances
+}
+
+// statementSummary fetches the summary of the statement, or of each of its chunks
+func statementSummary(ctx *cli.Cli, client bogapi.Client, st *bogapi.AccountStatement) (*bogapi.StatementSummary, error) {
+	if len(st.Chunks) == 0 {
+		if st.StatementID == 0 {
+			return nil, errors.New("statement ID is not known")
+		}
+		return client.StatementSummary(ctx.Context(), st.Account, st.Currency, st.StatementID)
+	}
+
+	var list []*bogapi.StatementSummary
+	for _, ch := range st.Chunks {
+		summary, err := client.StatementSummary(ctx.Context(), st.Account, st.Currency, ch.StatementID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, summary)
+	}
+	return bogapi.MergeSummaries(list), nil
+}
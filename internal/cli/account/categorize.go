@@ -0,0 +1,181 @@
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/effective-security/x/slices"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/categorize"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+// CategorizeCmd assigns categories to the statement records
+type CategorizeCmd struct {
+	In      string `kong:"arg" help:"input file" required:""`
+	Rules   string `help:"rules file, rules.yaml in the storage folder by default"`
+	Explain bool   `help:"show the rule matched by each record, and list uncategorized records"`
+}
+
+// categorized describes the category of the record
+type categorized struct {
+	Date         string       `json:"date" yaml:"date"`
+	Account      string       `json:"account" yaml:"account"`
+	Currency     string       `json:"currency" yaml:"currency"`
+	Document     string       `json:"document" yaml:"document"`
+	Debit        bogapi.Money `json:"debit" yaml:"debit"`
+	Credit       bogapi.Money `json:"credit" yaml:"credit"`
+	Counterparty string       `json:"counterparty" yaml:"counterparty"`
+	Product      string       `json:"product" yaml:"product"`
+	Comment      string       `json:"comment" yaml:"comment"`
+	Category     string       `json:"category,omitempty" yaml:"category,omitempty"`
+	Tags         []string     `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Rule         string       `json:"rule,omitempty" yaml:"rule,omitempty"`
+}
+
+// categoryTotal describes the records of the category in one currency
+type categoryTotal struct {
+	Category string       `json:"category" yaml:"category"`
+	Currency string       `json:"currency" yaml:"currency"`
+	Records  int          `json:"records" yaml:"records"`
+	Debit    bogapi.Money `json:"debit" yaml:"debit"`
+	Credit   bogapi.Money `json:"credit" yaml:"credit"`
+}
+
+func (cmd *CategorizeCmd) Run(ctx *cli.Cli) error {
+	rules, err := ctx.Rules(cmd.Rules)
+	if err != nil {
+		return err
+	}
+	if rules == nil {
+		return errors.Errorf("no categorization rules, create %s", filepath.Join(ctx.Storage, categorize.DefaultFile))
+	}
+
+	data, err := os.ReadFile(cmd.In)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read statement")
+	}
+	doc := new(bogapi.AccountStatements)
+	if err = json.Unmarshal(data, doc); err != nil {
+		return errors.WithMessage(err, "failed to parse statement")
+	}
+
+	transactions := bogapi.Report(doc)
+	matched := rules.Apply(transactions)
+
+	list := make([]*categorized, len(transactions))
+	var uncategorized []*categorized
+	for i, t := range transactions {
+		c := &categorized{
+			Date:         t.Date,
+			Account:      t.Account,
+			Currency:     t.Currency,
+			Document:     t.DocumentNumber,
+			Debit:        t.Debit,
+			Credit:       t.Credit,
+			Counterparty: counterparty(&t),
+			Product:      t.OperationType,
+			Comment:      t.EntryComment,
+			Category:     t.Category,
+			Tags:         t.Tags,
+		}
+		if matched[i] != nil {
+			c.Rule = matched[i].Name
+		} else {
+			uncategorized = append(uncategorized, c)
+		}
+		list[i] = c
+	}
+
+	if cmd.Explain {
+		if ctx.O != "table" {
+			return ctx.Print(list)
+		}
+		rows := make([][]string, 0, len(list))
+		for _, c := range list {
+			if c.Rule == "" {
+				continue
+			}
+			rows = append(rows, []string{c.Date, c.Account, c.Currency, c.Debit.Format(), c.Credit.Format(),
+				slices.StringUpto(c.Counterparty, 32), c.Category, strings.Join(c.Tags, ", "), c.Rule})
+		}
+		print.Table(ctx.Writer(), []string{"Date", "Account", "Currency", "Debit", "Credit", "Counterparty", "Category", "Tags", "Rule"}, rows)
+
+		if len(uncategorized) > 0 {
+			fmt.Fprintf(ctx.Writer(), "\nUncategorized: %d\n", len(uncategorized))
+			rows = make([][]string, len(uncategorized))
+			for i, c := range uncategorized {
+				rows[i] = []string{c.Date, c.Account, c.Currency, c.Debit.Format(), c.Credit.Format(),
+					slices.StringUpto(c.Counterparty, 32), c.Product, slices.StringUpto(c.Comment, 48)}
+			}
+			print.Table(ctx.Writer(), []string{"Date", "Account", "Currency", "Debit", "Credit", "Counterparty", "Product", "Comment"}, rows)
+		}
+		return nil
+	}
+
+	totals := categoryTotals(list)
+	if ctx.O != "table" {
+		return ctx.Print(totals)
+	}
+	rows := make([][]string, len(totals))
+	for i, total := range totals {
+		category := total.Category
+		if category == "" {
+			category = "(uncategorized)"
+		}
+		rows[i] = []string{category, total.Currency, strconv.Itoa(total.Records), total.Debit.Format(), total.Credit.Format()}
+	}
+	print.Table(ctx.Writer(), []string{"Category", "Currency", "Records", "Debit", "Credit"}, rows)
+	if len(uncategorized) > 0 {
+		fmt.Fprintf(ctx.Writer(), "Uncategorized: %d, run with --explain to list them\n", len(uncategorized))
+	}
+	return nil
+}
+
+// counterparty returns the beneficiary of outgoing, or the sender of incoming transactions
+func counterparty(t *bogapi.Transaction) string {
+	if !t.Debit.IsZero() {
+		return t.RecipientName
+	}
+	return t.SenderName
+}
+
+// categoryTotals returns totals by category and currency, sorted by category,
+// with uncategorized records last
+func categoryTotals(list []*categorized) []*categoryTotal {
+	index := make(map[string]*categoryTotal)
+	var totals []*categoryTotal
+	for _, c := range list {
+		key := c.Category + " " + c.Currency
+		total := index[key]
+		if total == nil {
+			total = &categoryTotal{Category: c.Category, Currency: c.Currency}
+			index[key] = total
+			totals = append(totals, total)
+		}
+		total.Records++
+		total.Debit = total.Debit.Add(c.Debit)
+		total.Credit = total.Credit.Add(c.Credit)
+	}
+	sort.SliceStable(totals, func(i, j int) bool {
+		a, b := totals[i], totals[j]
+		if (a.Category == "") != (b.Category == "") {
+			return b.Category == ""
+		}
+		if a.Category != b.Category {
+			return a.Category < b.Category
+		}
+		return a.Currency < b.Currency
+	})
+	if totals == nil {
+		totals = []*categoryTotal{}
+	}
+	return totals
+}
@@ -0,0 +1,82 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+// DiscoverCmd finds currency sub-accounts visible to the credentials
+type DiscoverCmd struct {
+	Account         []string `help:"account IBAN to probe in addition to the configured ones"`
+	Currency        []string `help:"currencies to probe, common currencies by default"`
+	Workers         int      `help:"number of probes run concurrently" default:"4"`
+	ContinueOnError bool     `help:"print warnings and continue if some probes fail"`
+	Write           bool     `help:"write discovered accounts to the configuration file"`
+}
+
+func (cmd *DiscoverCmd) Run(ctx *cli.Cli) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	currencies := make([]string, len(cmd.Currency))
+	for i, c := range cmd.Currency {
+		currencies[i] = strings.ToUpper(c)
+	}
+
+	discovered, fetchErr := client.DiscoverAccounts(ctx.Context(), &bogapi.DiscoverRequest{
+		Accounts:   cmd.Account,
+		Currencies: currencies,
+		Workers:    cmd.Workers,
+	})
+	if err = checkFetchError(ctx, fetchErr, cmd.ContinueOnError); err != nil {
+		return err
+	}
+
+	probed := currencies
+	if len(probed) == 0 {
+		probed = bogapi.DiscoverCurrencies
+	}
+	// currencies, which were not probed, are kept as configured
+	accounts := bogapi.MergeAccounts(client.Accounts(), discovered, probed)
+	changes := bogapi.DiffAccounts(client.Accounts(), accounts)
+	if ctx.O != "table" {
+		if err = ctx.Print(changes); err != nil {
+			return err
+		}
+	} else if len(changes) == 0 {
+		fmt.Fprintln(ctx.Writer(), "Configuration is up to date")
+	} else {
+		var rows [][]string
+		for _, ch := range changes {
+			for _, c := range ch.Added {
+				rows = append(rows, []string{"+", ch.Account, ch.Name, c})
+			}
+			for _, c := range ch.Removed {
+				rows = append(rows, []string{"-", ch.Account, ch.Name, c})
+			}
+		}
+		print.Table(ctx.Writer(), []string{"", "Account", "Name", "Currency"}, rows)
+	}
+
+	if !cmd.Write || len(changes) == 0 {
+		return nil
+	}
+	if fetchErr != nil {
+		// currencies of the failed probes would be removed from the configuration
+		return errors.New("configuration is not updated, as some probes failed")
+	}
+
+	cfgFile := ctx.ConfigFile()
+	if err = bogapi.UpdateConfigAccounts(cfgFile, ctx.Profile, accounts); err != nil {
+		return errors.WithMessage(err, "failed to update config")
+	}
+	fmt.Fprintf(ctx.Writer(), "Updated accounts in %s\n", cfgFile)
+	return nil
+}
@@ -0,0 +1,103 @@
+package account
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+// ExchangeCmd converts between currency sub-accounts of the same account
+type ExchangeCmd struct {
+	Account      string `help:"account IBAN, can be omitted if only one account has both currencies"`
+	FromCurrency string `help:"currency to sell" required:""`
+	ToCurrency   string `help:"currency to buy" required:""`
+	Amount       string `help:"amount to sell, in the source currency, with a dot for decimals" required:""`
+	Nomination   string `help:"document nomination" default:"Conversion"`
+	ID           string `name:"id" help:"unique ID of the document, generated if not provided"`
+	Yes          bool   `short:"y" help:"do not ask for confirmation"`
+}
+
+// Mutating returns true, the command sends documents to the bank
+func (cmd *ExchangeCmd) Mutating() bool {
+	return true
+}
+
+func (cmd *ExchangeCmd) Run(ctx *cli.Cli) error {
+	amount, err := bogapi.ParseAmount(cmd.Amount)
+	if err != nil {
+		return err
+	}
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	req := &bogapi.ExchangeRequest{
+		UniqueID:      cmd.ID,
+		AccountNumber: cmd.Account,
+		FromCurrency:  strings.ToUpper(cmd.FromCurrency),
+		ToCurrency:    strings.ToUpper(cmd.ToCurrency),
+		Amount:        amount,
+		Nomination:    cmd.Nomination,
+	}
+	if req.AccountNumber == "" {
+		req.AccountNumber, err = exchangeAccount(client.Accounts(), req.FromCurrency, req.ToCurrency)
+		if err != nil {
+			return err
+		}
+	}
+
+	quote, err := client.QuoteExchange(ctx.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Writer(), "Exchange %s %s to %s %s at %s on %s\n",
+		quote.Amount.Format(), quote.FromCurrency,
+		quote.Result.Format(), quote.ToCurrency,
+		quote.Rate, quote.AccountNumber)
+	if !cmd.Yes && !ctx.Confirm("Proceed?") {
+		fmt.Fprintln(ctx.Writer(), "Exchange canceled")
+		return nil
+	}
+
+	// the bank rejects the document, if the rate has changed since the quote,
+	// the quoted rate is rounded to the precision of the bank
+	req.Rate = quote.Rate
+	res, err := client.Exchange(ctx.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Print(res)
+}
+
+// exchangeAccount returns the only configured account with both currencies
+func exchangeAccount(accounts []bogapi.Account, from, to string) (string, error) {
+	var found []string
+	for _, acc := range accounts {
+		if hasCurrency(acc, from) && hasCurrency(acc, to) {
+			found = append(found, acc.ID)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", errors.Errorf("no account with %s and %s currencies in the configuration file", from, to)
+	case 1:
+		return found[0], nil
+	default:
+		return "", errors.Errorf("--account is required, %s and %s are available in: %s", from, to, strings.Join(found, ", "))
+	}
+}
+
+func hasCurrency(acc bogapi.Account, currency string) bool {
+	for _, c := range acc.Currency {
+		if strings.EqualFold(c, currency) {
+			return true
+		}
+	}
+	return false
+}
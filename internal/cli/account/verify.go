@@ -0,0 +1,113 @@
+package account
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+// VerifyCmd reconciles statements with the bank summaries
+type VerifyCmd struct {
+	Files           []string `kong:"arg,optional" help:"statement files saved with --summary, in any order; fetched from the API for --period if not provided"`
+	Account         string   `help:"Filter by account, empty for all"`
+	Currency        string   `help:"Filter by currency, empty for all"`
+	Period          string   `help:"statement period to fetch: 2025-02, 2025-Q1, 2025, ytd, last-month, or a range like 2025-01-15..2025-02-10"`
+	Workers         int      `help:"number of accounts fetched concurrently" default:"4"`
+	ContinueOnError bool     `help:"print warnings and continue if some accounts fail"`
+}
+
+func (cmd *VerifyCmd) Run(ctx *cli.Cli) error {
+	statements, err := cmd.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	var filtered []*bogapi.AccountStatement
+	for _, st := range statements {
+		if (cmd.Account == "" || cmd.Account == st.Account) &&
+			(cmd.Currency == "" || cmd.Currency == st.Currency) {
+			filtered = append(filtered, st)
+		}
+	}
+	if len(filtered) == 0 {
+		return errors.New("no statements to verify")
+	}
+
+	list := bogapi.Reconcile(filtered...)
+	if ctx.O != "table" {
+		if list == nil {
+			list = []*bogapi.Discrepancy{}
+		}
+		if err = ctx.Print(list); err != nil {
+			return err
+		}
+	} else if len(list) == 0 {
+		fmt.Fprintf(ctx.Writer(), "Verified %d statements, no discrepancies\n", len(filtered))
+	} else {
+		rows := make([][]string, len(list))
+		for i, d := range list {
+			rows[i] = []string{d.Account, d.Currency, d.Period, d.Date, d.Check,
+				d.Bank.In(d.Currency).Format(),
+				d.Computed.In(d.Currency).Format(),
+				d.Computed.Sub(d.Bank).In(d.Currency).Format(),
+				d.Message}
+		}
+		print.Table(ctx.Writer(), []string{"Account", "Currency", "Period", "Date", "Check", "Bank", "Computed", "Difference", "Message"}, rows)
+	}
+
+	if len(list) > 0 {
+		return errors.Errorf("found %d discrepancies in %d statements", len(list), len(filtered))
+	}
+	return nil
+}
+
+// load returns statements of the files, or fetches them with summaries for the period
+func (cmd *VerifyCmd) load(ctx *cli.Cli) ([]*bogapi.AccountStatement, error) {
+	if len(cmd.Files) > 0 {
+		if cmd.Period != "" {
+			return nil, errors.New("either statement files or period must be provided")
+		}
+		var list []*bogapi.AccountStatement
+		for _, file := range cmd.Files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to read statement")
+			}
+			doc := new(bogapi.AccountStatements)
+			if err = json.Unmarshal(data, doc); err != nil {
+				return nil, errors.WithMessagef(err, "failed to parse statement: %s", file)
+			}
+			list = append(list, doc.Combined...)
+		}
+		return list, nil
+	}
+
+	if cmd.Period == "" {
+		return nil, errors.New("either statement files or period must be provided")
+	}
+	period, err := bogapi.ParsePeriod(cmd.Period)
+	if err != nil {
+		return nil, err
+	}
+	client, err := ctx.Client()
+	if err != nil {
+		return nil, err
+	}
+	res, err := client.AllStatements(ctx.Context(), &bogapi.StatementRequest{
+		Account:   cmd.Account,
+		Currency:  cmd.Currency,
+		StartDate: period.StartDate(),
+		EndDate:   period.EndDate(),
+		Summary:   true,
+		Workers:   cmd.Workers,
+	})
+	if err = checkFetchError(ctx, err, cmd.ContinueOnError); err != nil {
+		return nil, err
+	}
+	return res.Combined, nil
+}
@@ -1,21 +1,33 @@
 package cli
 
 import (
+	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
+	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/alecthomas/kong"
 	"github.com/effective-security/porto/xhttp/correlation"
 	"github.com/effective-security/x/ctl"
+	"github.com/effective-security/x/fileutil"
 	"github.com/effective-security/x/values"
 	"github.com/effective-security/xlog"
 	"github.com/mitchellh/go-homedir"
 	"github.com/pkg/errors"
 	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/categorize"
 	"github.com/tbilicode/bogclient/pkg/print"
+	"github.com/tbilicode/bogclient/pkg/rates"
+	"github.com/tbilicode/bogclient/pkg/secrets"
+	"github.com/tbilicode/bogclient/pkg/store"
+	"golang.org/x/term"
 )
 
 var logger = xlog.NewPackageLogger("github.com/tbilicode/bogclient/internal", "cli")
@@ -29,21 +41,43 @@ var (
 type Cli struct {
 	Version ctl.VersionFlag `name:"version" help:"Print version information and quit" hidden:""`
 	Debug   bool            `short:"D" help:"Enable debug mode"`
-	O       string          `help:"Print output format: json|yaml|table" default:"table"`
+	O       string          `help:"Print output format: json|yaml|table, and csv for query" default:"table"`
 	Cfg     string          `help:"Configuration file" default:"~/.config/bogclient/config.yaml"`
 	Storage string          `help:"flag specifies to override default location: ~/.config/bogclient. Use BOG_STORAGE environment to override" default:"~/.config/bogclient"`
 	Timeout int             `help:"Connection timeout"  default:"6"`
+	Profile string          `help:"Configuration profile, the default profile if not set" env:"BOG_PROFILE"`
+
+	AllProfiles bool `help:"Run the command for every configuration profile, and label the output by profile"`
+
+	Offline  bool `help:"Read statements, balances and rates only from the cache in the storage folder"`
+	CacheTTL int  `name:"cache-ttl" help:"Seconds the statements of the current period and balances are served from the cache" default:"0"`
 
 	TimeFormat string `name:"time" help:"Print time format: utc|local|ago" hidden:"" default:"utc"`
 
+	// input is the source of user confirmations, typically set to os.Stdin
+	input io.Reader
+	// lines reads input by lines, shared by all prompts to keep buffered input
+	lines *bufio.Reader
 	// Output is the destination for all output from the command, typically set to os.Stdout
 	output io.Writer
 	// ErrOutput is the destination for errors.
 	// If not set, errors will be written to os.StdError
 	errOutput io.Writer
 
-	client bogapi.Client
-	ctx    context.Context
+	// printed collects the values of Print, when RunAllProfiles combines JSON or YAML output
+	printed *[]any
+
+	client  bogapi.Client
+	rates   *rates.Service
+	secrets *secrets.Store
+	ctx     context.Context
+}
+
+// Mutating is implemented by commands, which may send documents to the bank.
+// Such commands run only for one profile, and are refused by RunAllProfiles.
+type Mutating interface {
+	// Mutating returns true if the command changes data in the bank with the given flags
+	Mutating() bool
 }
 
 // Context for requests
@@ -60,6 +94,58 @@ func (c *Cli) IsJSON() bool {
 	return c.O == "json"
 }
 
+// Reader returns a reader for user input
+func (c *Cli) Reader() io.Reader {
+	if c.input != nil {
+		return c.input
+	}
+	return os.Stdin
+}
+
+// WithReader allows to specify a custom reader
+func (c *Cli) WithReader(in io.Reader) *Cli {
+	c.input = in
+	c.lines = nil
+	return c
+}
+
+// ReadLine returns the next line of user input, without the line break
+func (c *Cli) ReadLine() (string, error) {
+	if c.lines == nil {
+		c.lines = bufio.NewReader(c.Reader())
+	}
+	line, err := c.lines.ReadString('\n')
+	if err != nil && (err != io.EOF || line == "") {
+		return "", errors.WithMessage(err, "failed to read input")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// ReadPassword returns the next line of user input, which is not echoed if the input is a terminal
+func (c *Cli) ReadPassword() (string, error) {
+	if f, ok := c.Reader().(*os.File); ok && term.IsTerminal(int(f.Fd())) {
+		b, err := term.ReadPassword(int(f.Fd()))
+		// the line break typed by the user is not echoed either
+		fmt.Fprintln(c.ErrWriter())
+		if err != nil {
+			return "", errors.WithMessage(err, "failed to read input")
+		}
+		return string(b), nil
+	}
+	return c.ReadLine()
+}
+
+// Confirm prints the prompt and returns true if the user answers yes
+func (c *Cli) Confirm(prompt string) bool {
+	fmt.Fprintf(c.Writer(), "%s [y/N]: ", prompt)
+	answer, _ := c.ReadLine()
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
+
 // Writer returns a writer for control output
 func (c *Cli) Writer() io.Writer {
 	if c.output != nil {
@@ -101,26 +187,95 @@ func (c *Cli) AfterApply(app *kong.Kong, vars kong.Vars) error {
 	return nil
 }
 
-// Client returns client
-func (c *Cli) Client() (bogapi.Client, error) {
-	if c.client == nil {
-		// expand Storage in order of priorities: flag, Env, config, default
-		storage := values.StringsCoalesce(
-			c.Storage,
-			os.Getenv("BOG_STORAGE"),
-			DefaultStoragePath,
-		)
+// ConfigFile expands Storage and returns the path of the configuration file
+func (c *Cli) ConfigFile() string {
+	// expand Storage in order of priorities: flag, Env, config, default
+	storage := values.StringsCoalesce(
+		c.Storage,
+		os.Getenv("BOG_STORAGE"),
+		DefaultStoragePath,
+	)
+
+	c.Storage, _ = homedir.Expand(storage)
+
+	cfgpath := values.StringsCoalesce(
+		c.Cfg,
+		filepath.Join(c.Storage, "config.yaml"),
+	)
+
+	cfg, _ := homedir.Expand(cfgpath)
+	return cfg
+}
+
+// SecretStore returns the encrypted secrets file in the storage folder.
+// The passphrase is taken from BOG_PASSPHRASE environment, or prompted.
+func (c *Cli) SecretStore() (*secrets.Store, error) {
+	if c.secrets == nil {
+		c.ConfigFile()
+		passphrase := os.Getenv("BOG_PASSPHRASE")
+		if passphrase == "" {
+			fmt.Fprint(c.ErrWriter(), "Passphrase: ")
+			line, err := c.ReadPassword()
+			if err != nil {
+				return nil, err
+			}
+			passphrase = line
+		}
+
+		store, err := secrets.OpenStore(filepath.Join(c.Storage, secrets.StoreFile), passphrase)
+		if err != nil {
+			return nil, err
+		}
+		c.secrets = store
+	}
+	return c.secrets, nil
+}
 
-		c.Storage, _ = homedir.Expand(storage)
+// OpenStore opens the local database of statement records in the storage folder.
+// The caller must close the store, as the database file is locked while open.
+func (c *Cli) OpenStore() (*store.Store, error) {
+	c.ConfigFile()
+	if err := os.MkdirAll(c.Storage, 0700); err != nil {
+		return nil, errors.WithMessage(err, "failed to create storage folder")
+	}
+	return store.Open(filepath.Join(c.Storage, store.DefaultFile))
+}
 
-		cfgpath := values.StringsCoalesce(
-			c.Cfg,
-			filepath.Join(c.Storage, "config.yaml"),
-		)
+// Rules loads the categorization rules of the file, or of rules.yaml in the storage folder
+// if the file is not provided. Rules are nil if the default file does not exist.
+func (c *Cli) Rules(file string) (*categorize.Rules, error) {
+	if file == "" {
+		c.ConfigFile()
+		file = filepath.Join(c.Storage, categorize.DefaultFile)
+		if fileutil.FileExists(file) != nil {
+			return nil, nil
+		}
+	}
+	return categorize.Load(file)
+}
 
-		cfg, _ := homedir.Expand(cfgpath)
+// resolveStoreSecret resolves secret:NAME references from the secrets file
+func (c *Cli) resolveStoreSecret(name string) (string, error) {
+	store, err := c.SecretStore()
+	if err != nil {
+		return "", err
+	}
+	value, ok := store.Get(name)
+	if !ok {
+		return "", errors.Errorf("secret not found: %s", name)
+	}
+	return value, nil
+}
 
-		client, err := bogapi.CreateClient(cfg, c.Timeout)
+// Client returns client
+func (c *Cli) Client() (bogapi.Client, error) {
+	if c.client == nil {
+		secrets.Register("secret", c.resolveStoreSecret)
+		cfgFile := c.ConfigFile()
+		cache := bogapi.NewCache(filepath.Join(c.Storage, "cache")).
+			WithTTL(time.Second * time.Duration(c.CacheTTL)).
+			WithOffline(c.Offline)
+		client, err := bogapi.CreateProfileClient(cfgFile, c.Profile, c.Timeout, bogapi.WithCache(cache))
 		if err != nil {
 			return nil, err
 		}
@@ -129,8 +284,135 @@ func (c *Cli) Client() (bogapi.Client, error) {
 	return c.client, nil
 }
 
-// Print response to out
+// Rates returns the rates service, which caches official rates in the storage folder.
+// Commercial rates are available if BOG credentials are configured.
+func (c *Cli) Rates() (*rates.Service, error) {
+	if c.rates == nil {
+		cfgFile := c.ConfigFile()
+		svc := rates.New().
+			WithCacheDir(filepath.Join(c.Storage, "rates")).
+			WithOffline(c.Offline)
+		httpClient := &http.Client{Timeout: time.Second * time.Duration(c.Timeout)}
+
+		if fileutil.FileExists(cfgFile) == nil {
+			cfg, err := bogapi.LoadConfig(cfgFile)
+			if err != nil {
+				return nil, errors.WithMessage(err, "failed to load config")
+			}
+			if cfg, err = cfg.Profile(c.Profile); err != nil {
+				return nil, err
+			}
+			svc.WithNBGURL(cfg.NBGRatesURL)
+
+			// NBG is reached through the same proxy as the BOG API
+			if cfg.Transport != nil {
+				if httpClient.Transport, err = cfg.Transport.RoundTripper(); err != nil {
+					return nil, errors.WithMessage(err, "invalid transport")
+				}
+				if cfg.Transport.Timeout > 0 {
+					httpClient.Timeout = time.Second * time.Duration(cfg.Transport.Timeout)
+				}
+			}
+
+			if cfg.ClientID != "" {
+				client, err := c.Client()
+				if err != nil {
+					return nil, err
+				}
+				svc.WithCommercial(client)
+			}
+		}
+		if httpClient.Timeout > 0 || httpClient.Transport != nil {
+			svc.WithHTTPClient(httpClient)
+		}
+		c.rates = svc
+	}
+	return c.rates, nil
+}
+
+// RunAllProfiles calls run of the cmd for every profile of the configuration.
+// The output of each profile is labelled by the profile name, or, for JSON and YAML formats,
+// the printed values are combined into one object keyed by the profile name.
+// Failed profiles are reported to ErrWriter, and the other profiles continue.
+// Mutating commands are refused, so documents are never sent for every entity at once.
+func (c *Cli) RunAllProfiles(cmd any, run func() error) error {
+	if m, ok := cmd.(Mutating); ok && m.Mutating() {
+		return errors.New("the command sends documents to the bank, and can not run with --all-profiles, use --profile")
+	}
+
+	cfg, err := bogapi.LoadConfig(c.ConfigFile())
+	if err != nil {
+		return errors.WithMessage(err, "failed to load config")
+	}
+	names := cfg.ProfileNames()
+	if len(names) == 0 {
+		return errors.New("no profiles in the configuration file")
+	}
+
+	out := c.output
+	defer func() {
+		c.output = out
+		c.printed = nil
+		c.client = nil
+		c.rates = nil
+	}()
+
+	combined := make(map[string]any)
+	var failed []string
+	for _, name := range names {
+		var buf bytes.Buffer
+		var printed []any
+		c.Profile = name
+		c.output = &buf
+		c.client = nil
+		c.rates = nil
+		if c.O != "table" {
+			c.printed = &printed
+		}
+
+		err := run()
+		c.output = out
+		c.printed = nil
+		if err != nil {
+			failed = append(failed, name)
+			fmt.Fprintf(c.ErrWriter(), "ERROR: profile %s: %s\n", name, WithHint(err).Error())
+			continue
+		}
+
+		if c.O == "table" {
+			fmt.Fprintf(c.Writer(), "Profile: %s\n", name)
+			_, _ = buf.WriteTo(c.Writer())
+			continue
+		}
+
+		switch len(printed) {
+		case 0:
+			// the command printed only a message
+			combined[name] = buf.String()
+		case 1:
+			combined[name] = printed[0]
+		default:
+			combined[name] = printed
+		}
+	}
+
+	if c.O != "table" && len(combined) > 0 {
+		if err = c.Print(combined); err != nil {
+			return err
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed for %d of %d profiles: %s", len(failed), len(names), strings.Join(failed, ", "))
+	}
+	return nil
+}
+
+// Print response to out, or collects it for RunAllProfiles
 func (c *Cli) Print(value any) error {
+	if c.printed != nil {
+		*c.printed = append(*c.printed, value)
+		return nil
+	}
 	return print.Object(c.Writer(), c.O, value)
 }
 
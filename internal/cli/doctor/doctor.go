@@ -0,0 +1,303 @@
+package doctor
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/effective-security/x/fileutil"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+// Check status values
+const (
+	StatusPass = "pass"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+	StatusSkip = "skip"
+)
+
+// Check is the result of a single diagnostic
+type Check struct {
+	Name   string `json:"name" yaml:"name"`
+	Status string `json:"status" yaml:"status"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+	Hint   string `json:"hint,omitempty" yaml:"hint,omitempty"`
+}
+
+// Report is the result of all diagnostics
+type Report struct {
+	OK     bool     `json:"ok" yaml:"ok"`
+	Checks []*Check `json:"checks" yaml:"checks"`
+}
+
+func (r *Report) add(name, status, detail, hint string) {
+	r.Checks = append(r.Checks, &Check{Name: name, Status: status, Detail: detail, Hint: hint})
+}
+
+func (r *Report) failed() int {
+	n := 0
+	for _, c := range r.Checks {
+		if c.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// Cmd checks the configuration and connectivity
+type Cmd struct {
+	Workers int `help:"number of accounts probed concurrently" default:"4"`
+}
+
+func (cmd *Cmd) Run(ctx *cli.Cli) error {
+	report := new(Report)
+	cmd.run(ctx, report)
+	report.OK = report.failed() == 0
+
+	if ctx.O != "table" {
+		if err := ctx.Print(report); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, len(report.Checks))
+		for i, c := range report.Checks {
+			rows[i] = []string{c.Name, strings.ToUpper(c.Status), c.Detail, c.Hint}
+		}
+		print.Table(ctx.Writer(), []string{"Check", "Status", "Detail", "Hint"}, rows)
+	}
+
+	if n := report.failed(); n > 0 {
+		return errors.Errorf("%d of %d checks failed", n, len(report.Checks))
+	}
+	return nil
+}
+
+// run adds the checks to the report, and stops at the first check
+// the following ones depend on
+func (cmd *Cmd) run(ctx *cli.Cli, report *Report) {
+	cfgFile := ctx.ConfigFile()
+	checkStorage(ctx.Storage, report)
+
+	if fileutil.FileExists(cfgFile) != nil {
+		report.add("config", StatusFail, cfgFile+" not found",
+			"create the configuration file, or specify it with --cfg")
+		return
+	}
+	cfg, err := bogapi.LoadConfig(cfgFile)
+	if err != nil {
+		report.add("config", StatusFail, err.Error(), "fix the YAML syntax of the configuration file")
+		return
+	}
+	if cfg, err = cfg.Profile(ctx.Profile); err != nil {
+		report.add("config", StatusFail, err.Error(), "select an existing profile with --profile or BOG_PROFILE")
+		return
+	}
+	report.add("config", StatusPass, cfgFile, "")
+
+	if !checkFields(cfg, report) {
+		return
+	}
+	checkAccounts(cfg, report)
+
+	rt := http.DefaultTransport
+	if cfg.Transport != nil {
+		if rt, err = cfg.Transport.RoundTripper(); err != nil {
+			report.add("transport", StatusFail, err.Error(), "fix the transport section of the configuration file")
+			return
+		}
+		report.add("transport", StatusPass, "", "")
+	}
+
+	reachable := checkEndpoint(ctx.Context(), "auth_url", cfg.AuthURL, cfg.Transport, rt, report)
+	reachable = checkEndpoint(ctx.Context(), "api_host", cfg.ApiHost, cfg.Transport, rt, report) && reachable
+	if !reachable {
+		report.add("authenticate", StatusSkip, "endpoints are not reachable", "")
+		return
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		report.add("authenticate", StatusFail, err.Error(), cli.ErrorHint(err))
+		return
+	}
+	if err = client.Authenticate(ctx.Context()); err != nil {
+		report.add("authenticate", StatusFail, err.Error(), cli.ErrorHint(err))
+		return
+	}
+	report.add("authenticate", StatusPass, cfg.ClientID, "")
+
+	checkBalances(ctx.Context(), client, cmd.Workers, report)
+}
+
+func checkStorage(dir string, report *Report) {
+	info, err := os.Stat(dir)
+	switch {
+	case os.IsNotExist(err):
+		report.add("storage", StatusWarn, dir+" does not exist",
+			"create the folder, or specify it with --storage or BOG_STORAGE")
+	case err != nil:
+		report.add("storage", StatusFail, err.Error(), "")
+	case !info.IsDir():
+		report.add("storage", StatusFail, dir+" is not a folder",
+			"specify the storage folder with --storage or BOG_STORAGE")
+	default:
+		f, err := os.CreateTemp(dir, ".doctor")
+		if err != nil {
+			report.add("storage", StatusFail, err.Error(), "check permissions of the storage folder")
+			return
+		}
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		report.add("storage", StatusPass, dir, "")
+	}
+}
+
+// checkFields returns false if the credentials or endpoints are missing
+func checkFields(cfg *bogapi.Config, report *Report) bool {
+	var missing []string
+	for _, f := range []struct{ name, value string }{
+		{"client_id", cfg.ClientID},
+		{"client_secret", cfg.ClientSecret},
+		{"auth_url", cfg.AuthURL},
+		{"api_host", cfg.ApiHost},
+	} {
+		if f.value == "" {
+			missing = append(missing, f.name)
+		}
+	}
+	if len(missing) > 0 {
+		report.add("required fields", StatusFail, "missing "+strings.Join(missing, ", "),
+			"add the missing fields to the configuration file")
+		return false
+	}
+	report.add("required fields", StatusPass, "", "")
+
+	// the credentials are resolved on a copy, as the client resolves them on its own
+	resolved := *cfg
+	if err := resolved.ResolveSecrets(); err != nil {
+		report.add("secrets", StatusFail, err.Error(), "check the secret references of the credentials")
+		return false
+	}
+	report.add("secrets", StatusPass, "", "")
+	return true
+}
+
+func checkAccounts(cfg *bogapi.Config, report *Report) {
+	if len(cfg.Accounts) == 0 {
+		report.add("accounts", StatusWarn, "no accounts configured",
+			"run bog account discover --account IBAN --write")
+		return
+	}
+	for _, acc := range cfg.Accounts {
+		name := "account " + acc.ID
+		if err := bogapi.ValidateIBAN(acc.ID); err != nil {
+			report.add(name, StatusFail, err.Error(), "check the account number in the configuration file")
+			continue
+		}
+		if len(acc.Currency) == 0 {
+			report.add(name, StatusWarn, "no currencies configured", "run bog account discover --write")
+			continue
+		}
+		var invalid []string
+		for _, cur := range acc.Currency {
+			if bogapi.ValidateCurrency(cur) != nil {
+				invalid = append(invalid, cur)
+			}
+		}
+		if len(invalid) > 0 {
+			report.add(name, StatusFail, "unsupported currency: "+strings.Join(invalid, ", "),
+				"use ISO 4217 codes, supported: "+strings.Join(bogapi.SupportedCurrencies, ", "))
+			continue
+		}
+		report.add(name, StatusPass, strings.Join(acc.Currency, ", "), "")
+	}
+}
+
+// checkEndpoint resolves the host and sends a request through the transport,
+// any HTTP response means the endpoint is reachable
+func checkEndpoint(ctx context.Context, name, endpoint string, tr *bogapi.TransportConfig, rt http.RoundTripper, report *Report) bool {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		report.add(name, StatusFail, "invalid URL: "+endpoint, "specify an absolute URL in the configuration file")
+		return false
+	}
+
+	// with a proxy, the host may be resolved by the proxy only
+	if tr == nil || tr.Proxy == "" {
+		rctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		if _, err = net.DefaultResolver.LookupHost(rctx, u.Hostname()); err != nil {
+			report.add(name, StatusFail, "DNS: "+err.Error(), "check the host name and the DNS settings, or configure transport.proxy")
+			return false
+		}
+	}
+
+	rctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(rctx, http.MethodHead, u.Scheme+"://"+u.Host, nil)
+	if err != nil {
+		report.add(name, StatusFail, err.Error(), "")
+		return false
+	}
+	resp, err := rt.RoundTrip(req)
+	if err != nil {
+		hint := "check the firewall, or configure transport.proxy"
+		var certErr *tls.CertificateVerificationError
+		if errors.As(err, &certErr) {
+			hint = "the server certificate is not trusted: configure transport.ca_file with the corporate root CA"
+		}
+		report.add(name, StatusFail, err.Error(), hint)
+		return false
+	}
+	_ = resp.Body.Close()
+
+	detail := u.Host + ", no TLS"
+	if resp.TLS != nil {
+		detail = fmt.Sprintf("%s, %s", u.Host, tls.VersionName(resp.TLS.Version))
+	}
+	report.add(name, StatusPass, detail, "")
+	return true
+}
+
+func checkBalances(ctx context.Context, client bogapi.Client, workers int, report *Report) {
+	balances, err := client.AllBalances(ctx, &bogapi.BalanceRequest{Workers: workers})
+
+	failed := make(map[string]error)
+	var ferr *bogapi.FetchError
+	if errors.As(err, &ferr) {
+		for _, e := range ferr.Errors {
+			failed[e.Account+" "+e.Currency] = e.Err
+		}
+	} else if err != nil {
+		report.add("balance", StatusFail, err.Error(), cli.ErrorHint(err))
+		return
+	}
+
+	var keys []string
+	for key := range balances {
+		keys = append(keys, key)
+	}
+	for key := range failed {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if err := failed[key]; err != nil {
+			report.add("balance "+key, StatusFail, err.Error(), cli.ErrorHint(err))
+			continue
+		}
+		report.add("balance "+key, StatusPass, "", "")
+	}
+}
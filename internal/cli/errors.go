@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/effective-security/x/values"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+// ErrorHint returns a human explanation of the BOG API error,
+// or empty string if err is not an API error
+func ErrorHint(err error) string {
+	var ferr *bogapi.FetchError
+	if errors.As(err, &ferr) {
+		var hints []string
+		for _, e := range ferr.Errors {
+			if hint := ErrorHint(e.Err); hint != "" {
+				hints = append(hints, e.Account+" "+e.Currency+": "+hint)
+			}
+		}
+		return strings.Join(hints, "\n")
+	}
+
+	if bogapi.IsNotCached(err) {
+		return "the data is not in the cache: run the command without --offline to fetch it"
+	}
+
+	var apiErr *bogapi.APIError
+	if !errors.As(err, &apiErr) {
+		return ""
+	}
+
+	account := strings.TrimSpace(apiErr.Account + " " + apiErr.Currency)
+	switch {
+	case bogapi.IsUnauthorized(err) && apiErr.Account == "":
+		return "the credentials were rejected: check client_id and client_secret in the configuration file"
+	case bogapi.IsUnauthorized(err):
+		return "the access token was rejected: the credentials may have been revoked or rotated"
+	case bogapi.IsForbidden(err):
+		return fmt.Sprintf("the credentials are not permitted to access %s: check the API permissions in Business Online", values.StringsCoalesce(account, "the resource"))
+	case bogapi.IsNotFound(err) && account != "":
+		return fmt.Sprintf("account %s was not found: check the accounts and currencies in the configuration file", account)
+	case bogapi.IsNotFound(err):
+		return "the requested resource was not found"
+	case bogapi.IsBadRequest(err):
+		return "the request was rejected as invalid: check the account number, currency and dates"
+	case bogapi.IsConflict(err):
+		return "the document was rejected in its current state: check the status, or request a new quote if the rate has changed"
+	case bogapi.IsRateLimited(err) && apiErr.RetryAfter > 0:
+		return fmt.Sprintf("too many requests to BOG: retry after %s, or reduce --workers", apiErr.RetryAfter)
+	case bogapi.IsRateLimited(err):
+		return "too many requests to BOG: retry later, or reduce --workers"
+	case bogapi.IsUnavailable(err):
+		return "BOG service is unavailable: retry later"
+	}
+	return ""
+}
+
+// WithHint appends the explanation returned by ErrorHint to the error message
+func WithHint(err error) error {
+	hint := ErrorHint(err)
+	if hint == "" {
+		return err
+	}
+	return &hintError{err: err, hint: hint}
+}
+
+type hintError struct {
+	err  error
+	hint string
+}
+
+func (e *hintError) Error() string {
+	return e.err.Error() + "\n" + e.hint
+}
+
+func (e *hintError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,135 @@
+package payment
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+// BatchCmd submits payments from CSV or Excel file
+type BatchCmd struct {
+	In       string        `kong:"arg" help:"input CSV or Excel file with columns: IBAN, Name, INN, Amount, Currency, Nomination" required:""`
+	From     string        `help:"source account" required:""`
+	Out      string        `help:"output CSV file with statuses, by default <input>.results.csv, rows accepted in a previous run are not sent again"`
+	DryRun   bool          `help:"validate the file without sending"`
+	NoWait   bool          `help:"do not wait for the final statuses"`
+	Interval time.Duration `help:"status polling interval" default:"5s"`
+	Wait     time.Duration `help:"maximum time to wait for the final statuses" default:"30m"`
+}
+
+// Mutating returns true, unless the file is only validated
+func (cmd *BatchCmd) Mutating() bool {
+	return !cmd.DryRun
+}
+
+func (cmd *BatchCmd) Run(ctx *cli.Cli) error {
+	batch, err := bogapi.LoadPaymentBatch(cmd.In, cmd.From)
+	if err != nil {
+		return err
+	}
+
+	out := cmd.Out
+	if out == "" {
+		out = strings.TrimSuffix(cmd.In, filepath.Ext(cmd.In)) + ".results.csv"
+	}
+
+	// the results of the previous run keep the generated IDs and the bank keys,
+	// so running the same file again does not duplicate payments
+	if _, err = os.Stat(out); err == nil {
+		prev, err := bogapi.LoadPaymentBatch(out, cmd.From)
+		if err != nil {
+			return errors.WithMessagef(err, "failed to load previous results: %s", out)
+		}
+		batch.Resume(prev)
+	}
+
+	if err = batch.Validate(); err != nil {
+		if werr := writeResults(out, batch); werr != nil {
+			return werr
+		}
+		return errors.WithMessagef(err, "see %s", out)
+	}
+
+	if cmd.DryRun {
+		fmt.Fprintf(ctx.Writer(), "Dry run, %d payments are valid and not sent\n", len(batch))
+		return ctx.Print(summary(batch))
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	// the IDs are written before sending, so they are reused if the submission is interrupted
+	if err = writeResults(out, batch); err != nil {
+		return err
+	}
+	err = bogapi.SubmitBatch(ctx.Context(), client, batch)
+	if werr := writeResults(out, batch); werr != nil {
+		return werr
+	}
+	if err != nil {
+		return err
+	}
+
+	if !cmd.NoWait {
+		wctx, cancel := context.WithTimeout(ctx.Context(), cmd.Wait)
+		defer cancel()
+
+		err = bogapi.TrackBatch(wctx, client, batch, cmd.Interval, func(pending int) {
+			if pending > 0 {
+				fmt.Fprintf(ctx.ErrWriter(), "waiting for %d payments\n", pending)
+			}
+		})
+		if werr := writeResults(out, batch); werr != nil {
+			return werr
+		}
+		if err != nil {
+			return errors.WithMessage(err, "failed to wait for the final statuses")
+		}
+	}
+
+	fmt.Fprintf(ctx.Writer(), "Results are written to %s\n", out)
+	return ctx.Print(summary(batch))
+}
+
+func writeResults(file string, batch bogapi.PaymentBatch) error {
+	f, err := os.Create(file)
+	if err != nil {
+		return errors.WithMessage(err, "failed to create results file")
+	}
+	defer f.Close()
+	return batch.ToCSV(f)
+}
+
+// summary returns the number of payments, totals per currency and counts per status
+func summary(batch bogapi.PaymentBatch) map[string]string {
+	res := map[string]string{
+		"Payments": strconv.Itoa(len(batch)),
+	}
+	for currency, total := range batch.Total() {
+		res["Total "+currency] = total.Format()
+	}
+
+	counts := make(map[string]int)
+	for _, p := range batch {
+		switch {
+		case p.Error != "":
+			counts["Failed"]++
+		case p.Status != "":
+			counts[p.Status]++
+		}
+	}
+	for status, count := range counts {
+		res["Status "+status] = strconv.Itoa(count)
+	}
+	return res
+}
@@ -0,0 +1,120 @@
+package payment
+
+import (
+	"fmt"
+
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+type Cmd struct {
+	Create CreateCmd `cmd:"" help:"create domestic or intra-bank payment"`
+	Status StatusCmd `cmd:"" help:"prints payment status"`
+	Cancel CancelCmd `cmd:"" help:"cancel payment"`
+	Batch  BatchCmd  `cmd:"" help:"submit payments from CSV or Excel file, and track their statuses"`
+}
+
+// CreateCmd creates payment
+type CreateCmd struct {
+	Type       string `help:"payment type" enum:"domestic,intrabank" default:"domestic"`
+	From       string `help:"source account" required:""`
+	To         string `help:"beneficiary account IBAN" required:""`
+	Name       string `help:"beneficiary name" required:""`
+	Inn        string `help:"beneficiary tax ID or personal number"`
+	Amount     string `help:"amount to transfer, with a dot for decimals" required:""`
+	Currency   string `help:"currency" default:"GEL"`
+	Nomination string `help:"payment nomination" required:""`
+	Info       string `help:"additional information"`
+	ID         string `name:"id" help:"unique ID of the document, generated if not provided"`
+	DryRun     bool   `help:"print the document without sending"`
+}
+
+// Mutating returns true, unless the document is only printed
+func (cmd *CreateCmd) Mutating() bool {
+	return !cmd.DryRun
+}
+
+func (cmd *CreateCmd) Run(ctx *cli.Cli) error {
+	amount, err := bogapi.ParseAmount(cmd.Amount)
+	if err != nil {
+		return err
+	}
+	req := &bogapi.PaymentRequest{
+		Type:                     bogapi.PaymentType(cmd.Type),
+		UniqueID:                 cmd.ID,
+		SourceAccountNumber:      cmd.From,
+		BeneficiaryAccountNumber: cmd.To,
+		BeneficiaryName:          cmd.Name,
+		BeneficiaryInn:           cmd.Inn,
+		Nomination:               cmd.Nomination,
+		AdditionalInformation:    cmd.Info,
+		Amount:                   amount,
+		Currency:                 cmd.Currency,
+	}
+	// the printed document is the same as sent by CreatePayment
+	req.SetDefaults()
+
+	if cmd.DryRun {
+		if err := req.Validate(); err != nil {
+			return err
+		}
+		fmt.Fprintf(ctx.Writer(), "Dry run, the %s payment document is not sent:\n", req.Type)
+		return ctx.Print(req)
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	res, err := client.CreatePayment(ctx.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Print(res)
+}
+
+// StatusCmd prints payment status
+type StatusCmd struct {
+	Key int64 `kong:"arg" help:"unique key of the document" required:""`
+}
+
+func (cmd *StatusCmd) Run(ctx *cli.Cli) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	res, err := client.GetPaymentStatus(ctx.Context(), cmd.Key)
+	if err != nil {
+		return err
+	}
+
+	return ctx.Print(res)
+}
+
+// CancelCmd cancels payment
+type CancelCmd struct {
+	Key int64 `kong:"arg" help:"unique key of the document" required:""`
+}
+
+// Mutating returns true, the command cancels the document in the bank
+func (cmd *CancelCmd) Mutating() bool {
+	return true
+}
+
+func (cmd *CancelCmd) Run(ctx *cli.Cli) error {
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	err = client.CancelPayment(ctx.Context(), cmd.Key)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(ctx.Writer(), "Payment %d canceled\n", cmd.Key)
+	return nil
+}
@@ -0,0 +1,173 @@
+package query
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/effective-security/x/slices"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+// Cmd finds records in the local store, or in a statement file
+type Cmd struct {
+	In           string  `help:"statement JSON file to search, the local store if not provided"`
+	Period       string  `help:"entry dates: 2025-02, 2025-Q1, 2025, ytd, last-30d, or a range like 2025-01-15..2025-02-10"`
+	Account      string  `help:"Filter by account, empty for all"`
+	Currency     string  `help:"Filter by currency, empty for all"`
+	Debit        bool    `help:"outgoing records only" xor:"side"`
+	Credit       bool    `help:"incoming records only" xor:"side"`
+	Min          float64 `help:"minimum debit or credit amount"`
+	Max          float64 `help:"maximum debit or credit amount"`
+	Counterparty string  `help:"part of the sender or beneficiary name"`
+	INN          string  `name:"inn" help:"taxpayer number of the sender or beneficiary"`
+	IBAN         string  `name:"iban" help:"account number of the sender or beneficiary"`
+	Product      string  `help:"product group, such as CCO, PMI, TRN or FEE"`
+	Text         string  `help:"part of the entry comment or nomination"`
+}
+
+func (cmd *Cmd) Run(ctx *cli.Cli) error {
+	q := &bogapi.Query{
+		Account:      cmd.Account,
+		Currency:     cmd.Currency,
+		MinAmount:    bogapi.MoneyFromFloat(cmd.Min),
+		MaxAmount:    bogapi.MoneyFromFloat(cmd.Max),
+		Counterparty: cmd.Counterparty,
+		INN:          cmd.INN,
+		IBAN:         cmd.IBAN,
+		ProductGroup: cmd.Product,
+		Text:         cmd.Text,
+	}
+	if cmd.Debit {
+		q.Side = "debit"
+	} else if cmd.Credit {
+		q.Side = "credit"
+	}
+	if cmd.Period != "" {
+		period, err := bogapi.ParsePeriod(cmd.Period)
+		if err != nil {
+			return err
+		}
+		q.Start, q.End = period.Start, period.End
+	}
+	if err := q.Validate(); err != nil {
+		return err
+	}
+
+	doc, err := cmd.load(ctx)
+	if err != nil {
+		return err
+	}
+	transactions := bogapi.Report(q.Filter(doc))
+
+	switch ctx.O {
+	case "json", "yaml":
+		if transactions == nil {
+			transactions = bogapi.TransactionSlice{}
+		}
+		return ctx.Print(transactions)
+	case "csv":
+		return print.CSV(ctx.Writer(), header, rows(transactions, false))
+	}
+
+	print.Table(ctx.Writer(), header, rows(transactions, true))
+	fmt.Fprintf(ctx.Writer(), "Found: %d\n", len(transactions))
+	for _, total := range totals(transactions) {
+		fmt.Fprintf(ctx.Writer(), "%s debit: %s, credit: %s\n", total.currency, total.debit.Format(), total.credit.Format())
+	}
+	return nil
+}
+
+type total struct {
+	currency      string
+	debit, credit bogapi.Money
+}
+
+// totals returns debit and credit sums per currency, sorted by currency
+func totals(transactions bogapi.TransactionSlice) []*total {
+	index := make(map[string]*total)
+	var list []*total
+	for _, t := range transactions {
+		sum := index[t.Currency]
+		if sum == nil {
+			sum = &total{currency: t.Currency}
+			index[t.Currency] = sum
+			list = append(list, sum)
+		}
+		sum.debit = sum.debit.Add(t.Debit)
+		sum.credit = sum.credit.Add(t.Credit)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].currency < list[j].currency })
+	return list
+}
+
+// load returns statements of the input file, or all records of the local store
+func (cmd *Cmd) load(ctx *cli.Cli) (*bogapi.AccountStatements, error) {
+	if cmd.In != "" {
+		data, err := os.ReadFile(cmd.In)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read statement")
+		}
+		doc := new(bogapi.AccountStatements)
+		if err = json.Unmarshal(data, doc); err != nil {
+			return nil, errors.WithMessage(err, "failed to parse statement")
+		}
+		return doc, nil
+	}
+
+	db, err := ctx.OpenStore()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	list, err := db.Status()
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, errors.New("the local store is empty, run bog sync or specify --in")
+	}
+	var accounts []bogapi.Account
+	for _, s := range list {
+		if n := len(accounts); n > 0 && accounts[n-1].ID == s.Account {
+			accounts[n-1].Currency = append(accounts[n-1].Currency, s.Currency)
+			continue
+		}
+		accounts = append(accounts, bogapi.Account{ID: s.Account, Currency: []string{s.Currency}})
+	}
+	return db.Statements(accounts, &bogapi.StatementRequest{})
+}
+
+var header = []string{"Date", "Account", "Currency", "Debit", "Credit", "Counterparty", "Product", "Comment"}
+
+// rows returns the columns of the header, truncating long texts for the table
+func rows(transactions bogapi.TransactionSlice, truncate bool) [][]string {
+	res := make([][]string, len(transactions))
+	for i, t := range transactions {
+		counterparty := t.SenderName
+		if !t.Debit.IsZero() {
+			counterparty = t.RecipientName
+		}
+		comment := t.EntryComment
+		if truncate {
+			counterparty = slices.StringUpto(counterparty, 32)
+			comment = slices.StringUpto(comment, 48)
+		}
+		res[i] = []string{
+			t.Date,
+			t.Account,
+			t.Currency,
+			t.Debit.Format(),
+			t.Credit.Format(),
+			counterparty,
+			t.OperationType,
+			comment,
+		}
+	}
+	return res
+}
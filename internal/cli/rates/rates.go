@@ -0,0 +1,108 @@
+package rates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+	"github.com/tbilicode/bogclient/pkg/rates"
+)
+
+type Cmd struct {
+	Show  ShowCmd  `cmd:"" default:"withargs" help:"print NBG official and BOG commercial rates"`
+	Check CheckCmd `cmd:"" help:"compare document rates of the statement with NBG official rates"`
+}
+
+// ShowCmd prints rates
+type ShowCmd struct {
+	Currency []string `kong:"arg" optional:"" help:"currencies, all by default"`
+	Date     string   `help:"date in 2006-01-02 format, today by default"`
+}
+
+func (cmd *ShowCmd) Run(ctx *cli.Cli) error {
+	date, err := rates.ParseDate(cmd.Date)
+	if err != nil {
+		return err
+	}
+
+	svc, err := ctx.Rates()
+	if err != nil {
+		return err
+	}
+
+	res, err := svc.Rates(ctx.Context(), date, cmd.Currency...)
+	if err != nil {
+		return err
+	}
+
+	if ctx.O != "table" {
+		return ctx.Print(res)
+	}
+
+	rows := make([][]string, len(res))
+	for i, r := range res {
+		rows[i] = []string{r.Currency, r.Date, formatRate(r.Official), formatRate(r.Buy), formatRate(r.Sell)}
+	}
+	print.Table(ctx.Writer(), []string{"Currency", "Date", "NBG", "BOG Buy", "BOG Sell"}, rows)
+	return nil
+}
+
+// CheckCmd compares document rates of the statement with official rates
+type CheckCmd struct {
+	In        string  `kong:"arg" help:"statement file, as produced by the account statement command" required:""`
+	Tolerance float64 `help:"allowed deviation from the official rate, in percent" default:"1"`
+}
+
+func (cmd *CheckCmd) Run(ctx *cli.Cli) error {
+	data, err := os.ReadFile(cmd.In)
+	if err != nil {
+		return err
+	}
+
+	doc := new(bogapi.AccountStatements)
+	if err = json.Unmarshal(data, doc); err != nil {
+		return errors.WithMessage(err, "failed to unmarshal statement")
+	}
+
+	svc, err := ctx.Rates()
+	if err != nil {
+		return err
+	}
+
+	res, err := svc.CheckStatements(ctx.Context(), doc, cmd.Tolerance)
+	if err != nil {
+		return err
+	}
+
+	if len(res) == 0 {
+		fmt.Fprintf(ctx.Writer(), "All document rates are within %v%% of the official rates\n", cmd.Tolerance)
+		return nil
+	}
+
+	if ctx.O != "table" {
+		return ctx.Print(res)
+	}
+
+	rows := make([][]string, len(res))
+	for i, d := range res {
+		rows[i] = []string{
+			d.Date, d.Account, d.Currency, d.Document,
+			formatRate(d.DocumentRate), formatRate(d.OfficialRate),
+			strconv.FormatFloat(d.Percent, 'f', 2, 64) + "%",
+		}
+	}
+	print.Table(ctx.Writer(), []string{"Date", "Account", "Currency", "Document", "Rate", "NBG", "Deviation"}, rows)
+	return nil
+}
+
+func formatRate(rate float64) string {
+	if rate == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
@@ -0,0 +1,86 @@
+package secret
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/print"
+)
+
+type Cmd struct {
+	Set    SetCmd    `cmd:"" help:"add or replace a secret, which can be referenced in the config as secret:NAME"`
+	List   ListCmd   `cmd:"" help:"list names of the secrets"`
+	Delete DeleteCmd `cmd:"" help:"delete a secret"`
+}
+
+// SetCmd adds or replaces a secret
+type SetCmd struct {
+	Name string `kong:"arg" required:"" help:"name of the secret"`
+}
+
+func (cmd *SetCmd) Run(ctx *cli.Cli) error {
+	store, err := ctx.SecretStore()
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(ctx.ErrWriter(), "Secret: ")
+	value, err := ctx.ReadPassword()
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return errors.New("secret value is empty")
+	}
+
+	store.Set(cmd.Name, value)
+	if err = store.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Writer(), "Saved secret: %s\n", cmd.Name)
+	return nil
+}
+
+// ListCmd prints names of the secrets
+type ListCmd struct{}
+
+func (cmd *ListCmd) Run(ctx *cli.Cli) error {
+	store, err := ctx.SecretStore()
+	if err != nil {
+		return err
+	}
+
+	names := store.Names()
+	if ctx.O != "table" {
+		return ctx.Print(names)
+	}
+
+	rows := make([][]string, len(names))
+	for i, name := range names {
+		rows[i] = []string{name, "secret:" + name}
+	}
+	print.Table(ctx.Writer(), []string{"Name", "Reference"}, rows)
+	return nil
+}
+
+// DeleteCmd deletes a secret
+type DeleteCmd struct {
+	Name string `kong:"arg" required:"" help:"name of the secret"`
+}
+
+func (cmd *DeleteCmd) Run(ctx *cli.Cli) error {
+	store, err := ctx.SecretStore()
+	if err != nil {
+		return err
+	}
+
+	if !store.Delete(cmd.Name) {
+		return errors.Errorf("secret not found: %s", cmd.Name)
+	}
+	if err = store.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(ctx.Writer(), "Deleted secret: %s\n", cmd.Name)
+	return nil
+}
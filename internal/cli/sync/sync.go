@@ -0,0 +1,105 @@
+package sync
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/internal/cli"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/print"
+	"github.com/tbilicode/bogclient/pkg/store"
+)
+
+// Cmd fetches new statement records into the local store
+type Cmd struct {
+	Account      string `help:"Filter by account, empty for all"`
+	Currency     string `help:"Filter by currency, empty for all"`
+	Since        string `help:"start of the first sync of an account: 2025-01-01, 2025-01, 2025, ytd, last-90d" default:"ytd"`
+	Overlap      int    `help:"days before the last synced date fetched again, to pick up late bookings" default:"3"`
+	Full         bool   `help:"ignore the last synced date, and fetch from --since"`
+	ChunkWorkers int    `help:"number of monthly chunks of one account fetched concurrently" default:"1"`
+	Status       bool   `help:"print the stored accounts without fetching"`
+}
+
+func (cmd *Cmd) Run(ctx *cli.Cli) error {
+	db, err := ctx.OpenStore()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if cmd.Status {
+		return printStatus(ctx, db)
+	}
+
+	since, err := bogapi.ParsePeriod(cmd.Since)
+	if err != nil {
+		return errors.WithMessage(err, "invalid --since")
+	}
+	if cmd.Overlap < 0 {
+		return errors.New("overlap must not be negative")
+	}
+
+	client, err := ctx.Client()
+	if err != nil {
+		return err
+	}
+
+	results, err := db.Sync(ctx.Context(), client, &store.SyncRequest{
+		Account:      cmd.Account,
+		Currency:     cmd.Currency,
+		Since:        since.Start,
+		Overlap:      cmd.Overlap,
+		Full:         cmd.Full,
+		ChunkWorkers: cmd.ChunkWorkers,
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.O != "table" {
+		if err = ctx.Print(results); err != nil {
+			return err
+		}
+	} else {
+		rows := make([][]string, len(results))
+		for i, r := range results {
+			rows[i] = []string{r.Account, r.Currency, r.From, r.To,
+				strconv.Itoa(r.Fetched), strconv.Itoa(r.Added), r.Error}
+		}
+		print.Table(ctx.Writer(), []string{"Account", "Currency", "From", "To", "Fetched", "Added", "Error"}, rows)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to sync %d of %d accounts", failed, len(results))
+	}
+	return nil
+}
+
+func printStatus(ctx *cli.Cli, db *store.Store) error {
+	list, err := db.Status()
+	if err != nil {
+		return err
+	}
+	if ctx.O != "table" {
+		return ctx.Print(list)
+	}
+	if len(list) == 0 {
+		fmt.Fprintln(ctx.Writer(), "No synced accounts, run bog sync")
+		return nil
+	}
+
+	rows := make([][]string, len(list))
+	for i, s := range list {
+		rows[i] = []string{s.Account, s.Currency, strconv.Itoa(s.Records), s.Synced}
+	}
+	print.Table(ctx.Writer(), []string{"Account", "Currency", "Records", "Synced"}, rows)
+	return nil
+}
@@ -0,0 +1,443 @@
+package bogapi
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/effective-security/x/values"
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"github.com/xuri/excelize/v2"
+)
+
+// BatchPayment is a row of the payment batch
+type BatchPayment struct {
+	// Row is the line number in the source file, starting from 1 for the header
+	Row     int             `json:"Row"`
+	Request *PaymentRequest `json:"Request"`
+
+	UniqueKey    int64  `json:"UniqueKey,omitempty"`
+	Status       string `json:"Status,omitempty"`
+	RejectReason string `json:"RejectReason,omitempty"`
+	// Error is the validation or submission error of the row
+	Error string `json:"Error,omitempty"`
+
+	// idGenerated is true if the file has no ID for the row
+	idGenerated bool
+}
+
+// IsFinal returns true if the row failed, or its status will not change
+func (p *BatchPayment) IsFinal() bool {
+	return p.Error != "" || (&PaymentStatus{Status: p.Status}).IsFinal()
+}
+
+// PaymentBatch is a list of payments submitted together, such as payroll or supplier runs
+type PaymentBatch []*BatchPayment
+
+// batchColumns maps accepted header names to the fields
+var batchColumns = map[string]string{
+	"iban":                        "iban",
+	"account":                     "iban",
+	"beneficiary account":         "iban",
+	"beneficiary iban":            "iban",
+	"name":                        "name",
+	"beneficiary name":            "name",
+	"inn":                         "inn",
+	"tax id":                      "inn",
+	"beneficiary inn":             "inn",
+	"amount":                      "amount",
+	"currency":                    "currency",
+	"nomination":                  "nomination",
+	"info":                        "info",
+	"additional info":             "info",
+	"additional information":      "info",
+	"id":                          "id",
+	"unique id":                   "id",
+	"type":                        "type",
+	"beneficiary bank code":       "bank",
+	"beneficiary bank swift code": "bank",
+	// columns of the results file, so it can be loaded again
+	"row":        "row",
+	"unique key": "key",
+	"status":     "status",
+}
+
+var batchRequired = []string{"iban", "name", "amount", "nomination"}
+
+// LoadPaymentBatch reads the batch from CSV or Excel file, depending on the extension.
+// The source account is used for all rows.
+func LoadPaymentBatch(file, source string) (PaymentBatch, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to open file")
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(file)) {
+	case ".xlsx":
+		return ReadPaymentBatchExcel(f, source)
+	default:
+		return ReadPaymentBatchCSV(f, source)
+	}
+}
+
+// ReadPaymentBatchCSV reads the batch from CSV with a header row,
+// the columns are: IBAN, Name, INN, Amount, Currency, Nomination, and optional Info, ID, Type.
+// Amounts must use a dot as the decimal separator, without grouping, like 1200.50.
+// The results file written by ToCSV can be read as well, with IDs, keys and statuses of the rows.
+func ReadPaymentBatchCSV(r io.Reader, source string) (PaymentBatch, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	rows, err := reader.ReadAll()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read CSV")
+	}
+	return parsePaymentBatch(rows, source)
+}
+
+// ReadPaymentBatchExcel reads the batch from the first sheet of Excel file,
+// with the same columns as ReadPaymentBatchCSV
+func ReadPaymentBatchExcel(r io.Reader, source string) (PaymentBatch, error) {
+	f, err := excelize.OpenReader(r)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read Excel")
+	}
+	defer f.Close()
+
+	// raw values, so the amounts are not formatted with grouping
+	rows, err := f.GetRows(f.GetSheetName(0), excelize.Options{RawCellValue: true})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read Excel")
+	}
+	return parsePaymentBatch(rows, source)
+}
+
+func parsePaymentBatch(rows [][]string, source string) (PaymentBatch, error) {
+	if len(rows) == 0 {
+		return nil, errors.New("missing header")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range rows[0] {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		if field, ok := batchColumns[name]; ok {
+			columns[field] = i
+		}
+	}
+	for _, field := range batchRequired {
+		if _, ok := columns[field]; !ok {
+			return nil, errors.Errorf("missing column: %s", field)
+		}
+	}
+
+	value := func(row []string, field string) string {
+		i, ok := columns[field]
+		if !ok || i >= len(row) {
+			return ""
+		}
+		return strings.TrimSpace(row[i])
+	}
+
+	var batch PaymentBatch
+	for i, row := range rows[1:] {
+		if strings.TrimSpace(strings.Join(row, "")) == "" {
+			continue
+		}
+
+		p := &BatchPayment{
+			Row: i + 2,
+			Request: &PaymentRequest{
+				UniqueID:                 value(row, "id"),
+				SourceAccountNumber:      source,
+				BeneficiaryAccountNumber: strings.ToUpper(strings.ReplaceAll(value(row, "iban"), " ", "")),
+				BeneficiaryBankCode:      value(row, "bank"),
+				BeneficiaryName:          value(row, "name"),
+				BeneficiaryInn:           value(row, "inn"),
+				Nomination:               value(row, "nomination"),
+				AdditionalInformation:    value(row, "info"),
+				Currency:                 strings.ToUpper(value(row, "currency")),
+				Type:                     PaymentType(strings.ToLower(value(row, "type"))),
+			},
+		}
+		if p.Request.UniqueID == "" {
+			// the ID is written to the results file, so it can be resubmitted without duplicates
+			p.Request.UniqueID = newUniqueID()
+			p.idGenerated = true
+		}
+		if p.Request.Currency == "" {
+			p.Request.Currency = "GEL"
+		}
+		if p.Request.Type == "" {
+			// GEL can be sent to any bank, other currencies only within BOG
+			p.Request.Type = PaymentDomestic
+			if p.Request.Currency != "GEL" {
+				p.Request.Type = PaymentIntraBank
+			}
+		}
+		if p.Request.Type == PaymentIntraBank && p.Request.BeneficiaryBankCode == "" {
+			p.Request.BeneficiaryBankCode = BOGSwiftCode
+		}
+
+		if n, err := strconv.Atoi(value(row, "row")); err == nil && n > 1 {
+			p.Row = n
+		}
+		if key := value(row, "key"); key != "" {
+			p.UniqueKey, _ = strconv.ParseInt(key, 10, 64)
+			p.Status = value(row, "status")
+		}
+
+		if m, err := ParseAmount(value(row, "amount")); err != nil {
+			p.Error = err.Error()
+		} else {
+			p.Request.Amount = m
+		}
+		batch = append(batch, p)
+	}
+
+	if len(batch) == 0 {
+		return nil, errors.New("no payments found")
+	}
+	return batch, nil
+}
+
+// Resume copies IDs, bank keys and statuses of the previous run to the same rows of the batch,
+// so the rows accepted by the bank are not submitted again, and others are resubmitted
+// with the same ID. A row is the same, if its line, beneficiary, amount and nomination
+// did not change, and its ID is the same or not provided.
+func (b PaymentBatch) Resume(prev PaymentBatch) {
+	rows := make(map[int]*BatchPayment, len(prev))
+	for _, p := range prev {
+		rows[p.Row] = p
+	}
+	for _, p := range b {
+		old := rows[p.Row]
+		if old == nil || !p.sameAs(old) {
+			continue
+		}
+		p.Request.UniqueID = old.Request.UniqueID
+		p.UniqueKey = old.UniqueKey
+		p.Status = old.Status
+		p.RejectReason = old.RejectReason
+	}
+}
+
+// sameAs returns true if the row describes the same payment as the row of the previous run,
+// the ID of the row is generated if not provided in the file
+func (p *BatchPayment) sameAs(old *BatchPayment) bool {
+	r, o := p.Request, old.Request
+	return o.UniqueID != "" &&
+		(p.idGenerated || r.UniqueID == o.UniqueID) &&
+		r.Type == o.Type &&
+		r.BeneficiaryAccountNumber == o.BeneficiaryAccountNumber &&
+		r.BeneficiaryName == o.BeneficiaryName &&
+		r.Amount.Equal(o.Amount) &&
+		r.Currency == o.Currency &&
+		r.Nomination == o.Nomination
+}
+
+// Validate checks every row and sets its Error, and returns an error listing all invalid rows
+func (b PaymentBatch) Validate() error {
+	ids := make(map[string]int)
+	var msgs []string
+	for _, p := range b {
+		if p.Error == "" {
+			if err := p.Request.Validate(); err != nil {
+				p.Error = err.Error()
+			} else if row, ok := ids[p.Request.UniqueID]; ok {
+				p.Error = fmt.Sprintf("duplicate ID %s, see row %d", p.Request.UniqueID, row)
+			}
+		}
+		if p.Error != "" {
+			msgs = append(msgs, fmt.Sprintf("row %d: %s", p.Row, p.Error))
+		}
+		ids[p.Request.UniqueID] = p.Row
+	}
+	if len(msgs) > 0 {
+		return errors.Errorf("invalid payments:\n%s", strings.Join(msgs, "\n"))
+	}
+	return nil
+}
+
+// Total returns the sum of amounts per currency
+func (b PaymentBatch) Total() map[string]Money {
+	total := make(map[string]Money)
+	for _, p := range b {
+		currency := p.Request.Currency
+		total[currency] = total[currency].In(currency).Add(p.Request.Amount)
+	}
+	return total
+}
+
+// CreateBatchPayment submits the documents of the same type as a bulk payment,
+// the response contains a result for every document in the same order
+func (c *client) CreateBatchPayment(ctx context.Context, typ PaymentType, docs []*PaymentRequest) ([]*PaymentResponse, error) {
+	for _, doc := range docs {
+		if doc.UniqueID == "" {
+			doc.UniqueID = newUniqueID()
+		}
+		if doc.Type == "" {
+			doc.Type = typ
+		}
+		if doc.Type != typ {
+			return nil, errors.Errorf("all documents must be of %s type: %s", typ, doc.UniqueID)
+		}
+	}
+
+	path := "/api/documents/bulk/" + string(typ)
+	var res []*PaymentResponse
+	hdr, status, err := c.call(ctx, http.MethodPost, path, docs, &res)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"type", typ,
+			"count", len(docs),
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(err, "failed to create batch payment: %d documents", len(docs))
+	}
+	if len(res) != len(docs) {
+		return nil, errors.Errorf("unexpected batch response: %d results for %d documents", len(res), len(docs))
+	}
+	return res, nil
+}
+
+// SubmitBatch submits valid rows of the batch, grouped by the payment type,
+// and sets UniqueKey or Error for each row.
+// Rows already accepted by the bank are skipped, so a failed batch can be resubmitted.
+func SubmitBatch(ctx context.Context, client Client, b PaymentBatch) error {
+	groups := make(map[PaymentType]PaymentBatch)
+	var types []PaymentType
+	for _, p := range b {
+		// rows with errors, or already accepted by the bank, are not sent
+		if p.Error != "" || p.UniqueKey != 0 {
+			continue
+		}
+		if groups[p.Request.Type] == nil {
+			types = append(types, p.Request.Type)
+		}
+		groups[p.Request.Type] = append(groups[p.Request.Type], p)
+	}
+
+	for _, typ := range types {
+		group := groups[typ]
+		docs := make([]*PaymentRequest, len(group))
+		for i, p := range group {
+			docs[i] = p.Request
+		}
+
+		res, err := client.CreateBatchPayment(ctx, typ, docs)
+		if err != nil {
+			for _, p := range group {
+				p.Error = err.Error()
+			}
+			return err
+		}
+
+		for i, p := range group {
+			if res[i].ResultCode != 0 {
+				p.Error = values.StringsCoalesce(res[i].Message, fmt.Sprintf("rejected with code %d", res[i].ResultCode))
+				continue
+			}
+			p.UniqueKey = res[i].UniqueKey
+			p.Status = PaymentStatusInProgress
+		}
+	}
+	return nil
+}
+
+// TrackBatch polls statuses of submitted rows until all are final, or the context is done.
+// The progress function, if provided, is called after each poll.
+func TrackBatch(ctx context.Context, client Client, b PaymentBatch, interval time.Duration, progress func(pending int)) error {
+	for {
+		pending := 0
+		for _, p := range b {
+			if p.IsFinal() || p.UniqueKey == 0 {
+				continue
+			}
+			status, err := client.GetPaymentStatus(ctx, p.UniqueKey)
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				// keep polling, the status may be temporarily unavailable
+				logger.ContextKV(ctx, xlog.WARNING,
+					"row", p.Row,
+					"unique_key", p.UniqueKey,
+					"err", err.Error(),
+				)
+				pending++
+				continue
+			}
+			p.Status = status.Status
+			p.RejectReason = status.RejectReason
+			if !p.IsFinal() {
+				pending++
+			}
+		}
+
+		if progress != nil {
+			progress(pending)
+		}
+		if pending == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// ToCSV writes the batch with statuses, for reconciliation
+func (b PaymentBatch) ToCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	header := []string{
+		"Row", "ID", "Type", "IBAN", "Name", "INN", "Amount", "Currency", "Nomination", "Info",
+		"Unique Key", "Status", "Reject Reason", "Error",
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, p := range b {
+		key := ""
+		if p.UniqueKey != 0 {
+			key = strconv.FormatInt(p.UniqueKey, 10)
+		}
+		row := []string{
+			strconv.Itoa(p.Row),
+			p.Request.UniqueID,
+			string(p.Request.Type),
+			p.Request.BeneficiaryAccountNumber,
+			p.Request.BeneficiaryName,
+			p.Request.BeneficiaryInn,
+			p.Request.Amount.In(p.Request.Currency).Format(),
+			p.Request.Currency,
+			p.Request.Nomination,
+			p.Request.AdditionalInformation,
+			key,
+			p.Status,
+			p.RejectReason,
+			p.Error,
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package bogapi_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+	"github.com/xuri/excelize/v2"
+)
+
+func TestLoadPaymentBatch(t *testing.T) {
+	t.Parallel()
+
+	batch, err := bogapi.LoadPaymentBatch("testdata/payment_batch.csv", bogtest.PaymentAccount)
+	require.NoError(t, err)
+	require.Len(t, batch, 3)
+	require.NoError(t, batch.Validate())
+
+	p := batch[0]
+	assert.Equal(t, 2, p.Row)
+	assert.Equal(t, bogapi.PaymentDomestic, p.Request.Type)
+	assert.Equal(t, "1200.5", p.Request.Amount.String())
+	assert.Equal(t, "GEL", p.Request.Currency)
+	assert.Equal(t, bogtest.PaymentAccount, p.Request.SourceAccountNumber)
+	assert.NotEmpty(t, p.Request.UniqueID)
+
+	p = batch[1]
+	assert.Equal(t, bogapi.PaymentIntraBank, p.Request.Type)
+	assert.Equal(t, bogapi.BOGSwiftCode, p.Request.BeneficiaryBankCode)
+	assert.Equal(t, "01001012345", p.Request.BeneficiaryInn)
+
+	assert.Equal(t, "GEL", batch[2].Request.Currency)
+	total := batch.Total()
+	assert.Len(t, total, 2)
+	assert.Equal(t, "1500.50", total["GEL"].Format())
+	assert.Equal(t, "USD", total["USD"].Currency())
+	assert.Equal(t, "500.00", total["USD"].Format())
+
+	// the results file can be loaded again
+	var buf bytes.Buffer
+	require.NoError(t, batch.ToCSV(&buf))
+	again, err := bogapi.ReadPaymentBatchCSV(&buf, bogtest.PaymentAccount)
+	require.NoError(t, err)
+	require.Len(t, again, 3)
+	assert.Equal(t, batch[1].Request, again[1].Request)
+}
+
+func TestPaymentBatch_Validate(t *testing.T) {
+	t.Parallel()
+
+	_, err := bogapi.ReadPaymentBatchCSV(strings.NewReader("IBAN,Name,Amount\n"), "")
+	assert.EqualError(t, err, "missing column: nomination")
+
+	_, err = bogapi.ReadPaymentBatchCSV(strings.NewReader("IBAN,Name,Amount,Nomination\n,,,\n"), "")
+	assert.EqualError(t, err, "no payments found")
+
+	csv := `ID,IBAN,Name,Amount,Nomination
+1,GE29NB0000000101904917,Revenue Service,10,tax
+2,GE29NB0000000101904910,Revenue Service,10,tax
+3,GE29NB0000000101904917,,10,tax
+4,GE29NB0000000101904917,Revenue Service,ten,tax
+1,GE29NB0000000101904917,Revenue Service,10,tax
+5,GE29NB0000000101904917,Revenue Service,"135,50",tax
+6,GE29NB0000000101904917,Revenue Service,"1,200.50",tax
+7,GE29NB0000000101904917,Revenue Service,-10,tax
+`
+	batch, err := bogapi.ReadPaymentBatchCSV(strings.NewReader(csv), bogtest.PaymentAccount)
+	require.NoError(t, err)
+	err = batch.Validate()
+	assert.EqualError(t, err, `invalid payments:
+row 3: invalid beneficiary account: invalid IBAN checksum: GE29NB0000000101904910
+row 4: beneficiary name is required
+row 5: invalid amount: ten
+row 6: duplicate ID 1, see row 2
+row 7: invalid amount: 135,50, use a dot for decimals and no grouping, like 1200.50
+row 8: invalid amount: 1,200.50, use a dot for decimals and no grouping, like 1200.50
+row 9: invalid amount: -10`)
+	assert.Empty(t, batch[0].Error)
+}
+
+func TestReadPaymentBatchExcel(t *testing.T) {
+	t.Parallel()
+
+	f := excelize.NewFile()
+	sheet := f.GetSheetName(0)
+	rows := [][]any{
+		{"Beneficiary IBAN", "Beneficiary Name", "Tax ID", "Amount", "Currency", "Nomination"},
+		{"GE29 NB00 0000 0101 9049 17", "Revenue Service", "204469032", 1200.25, "gel", "Income tax"},
+	}
+	for i, row := range rows {
+		cell, err := excelize.CoordinatesToCellName(1, i+1)
+		require.NoError(t, err)
+		require.NoError(t, f.SetSheetRow(sheet, cell, &row))
+	}
+	// the amount is displayed with grouping, like 1,200.25
+	style, err := f.NewStyle(&excelize.Style{NumFmt: 4})
+	require.NoError(t, err)
+	require.NoError(t, f.SetCellStyle(sheet, "D2", "D2", style))
+	var buf bytes.Buffer
+	require.NoError(t, f.Write(&buf))
+
+	batch, err := bogapi.ReadPaymentBatchExcel(&buf, bogtest.PaymentAccount)
+	require.NoError(t, err)
+	require.Len(t, batch, 1)
+	require.NoError(t, batch.Validate())
+	assert.Equal(t, "GE29NB0000000101904917", batch[0].Request.BeneficiaryAccountNumber)
+	assert.Equal(t, "1200.25", batch[0].Request.Amount.String())
+	assert.Equal(t, "GEL", batch[0].Request.Currency)
+}
+
+func TestSubmitBatch(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SeedPaymentAccount()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	batch, err := bogapi.LoadPaymentBatch("testdata/payment_batch.csv", bogtest.PaymentAccount)
+	require.NoError(t, err)
+	require.NoError(t, batch.Validate())
+
+	// the account has no CHF sub-account
+	batch[1].Request.Currency = "CHF"
+
+	require.NoError(t, bogapi.SubmitBatch(ctx, client, batch))
+	assert.NotZero(t, batch[0].UniqueKey)
+	assert.Equal(t, bogapi.PaymentStatusInProgress, batch[0].Status)
+	assert.Contains(t, batch[1].Error, "account not found")
+	assert.NotZero(t, batch[2].UniqueKey)
+	assert.Equal(t, 2, srv.Payments())
+
+	srv.SetPaymentStatus(batch[0].UniqueKey, bogapi.PaymentStatusCompleted, "")
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		srv.SetPaymentStatus(batch[2].UniqueKey, bogapi.PaymentStatusRejected, "insufficient funds")
+	}()
+
+	polls := 0
+	err = bogapi.TrackBatch(ctx, client, batch, 10*time.Millisecond, func(pending int) {
+		polls++
+	})
+	require.NoError(t, err)
+	assert.Greater(t, polls, 1)
+	assert.Equal(t, bogapi.PaymentStatusCompleted, batch[0].Status)
+	assert.Equal(t, bogapi.PaymentStatusRejected, batch[2].Status)
+	assert.Equal(t, "insufficient funds", batch[2].RejectReason)
+
+	// resubmitting the same batch does not create new documents
+	batch[1].Error = ""
+	batch[1].Request.Currency = "USD"
+	require.NoError(t, bogapi.SubmitBatch(ctx, client, batch))
+	assert.Equal(t, 3, srv.Payments())
+
+	var buf bytes.Buffer
+	require.NoError(t, batch.ToCSV(&buf))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 4)
+	assert.Equal(t, "Row,ID,Type,IBAN,Name,INN,Amount,Currency,Nomination,Info,Unique Key,Status,Reject Reason,Error", lines[0])
+	assert.Contains(t, lines[3], "Rejected,insufficient funds,")
+
+	// the results file restores the IDs, keys and statuses
+	again, err := bogapi.ReadPaymentBatchCSV(&buf, bogtest.PaymentAccount)
+	require.NoError(t, err)
+	require.Len(t, again, 3)
+	for i, p := range again {
+		assert.Equal(t, batch[i].Row, p.Row)
+		assert.Equal(t, batch[i].Request.UniqueID, p.Request.UniqueID)
+		assert.Equal(t, batch[i].UniqueKey, p.UniqueKey)
+		assert.Equal(t, batch[i].Status, p.Status)
+	}
+	require.NoError(t, bogapi.SubmitBatch(ctx, client, again))
+	assert.Equal(t, 3, srv.Payments())
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	err = bogapi.TrackBatch(ctx, client, batch, 10*time.Millisecond, nil)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPaymentBatch_Resume(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SeedPaymentAccount()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	load := func() bogapi.PaymentBatch {
+		batch, err := bogapi.LoadPaymentBatch("testdata/payment_batch.csv", bogtest.PaymentAccount)
+		require.NoError(t, err)
+		require.NoError(t, batch.Validate())
+		return batch
+	}
+
+	first := load()
+	srv.Fail("/api/documents/bulk/intrabank", http.StatusServiceUnavailable, 1)
+	require.Error(t, bogapi.SubmitBatch(ctx, client, first))
+	assert.Equal(t, 2, srv.Payments())
+	assert.NotEmpty(t, first[1].Error)
+	var results bytes.Buffer
+	require.NoError(t, first.ToCSV(&results))
+	prev, err := bogapi.ReadPaymentBatchCSV(&results, bogtest.PaymentAccount)
+	require.NoError(t, err)
+
+	// the same file without IDs gets new IDs, which are replaced by the previous ones
+	second := load()
+	assert.NotEqual(t, first[0].Request.UniqueID, second[0].Request.UniqueID)
+	second.Resume(prev)
+	for i := range second {
+		assert.Equal(t, first[i].Request.UniqueID, second[i].Request.UniqueID)
+		assert.Equal(t, first[i].UniqueKey, second[i].UniqueKey)
+	}
+	// only the failed row is sent again
+	require.NoError(t, bogapi.SubmitBatch(ctx, client, second))
+	assert.Equal(t, 3, srv.Payments())
+	assert.NotZero(t, second[1].UniqueKey)
+
+	// changed rows are new payments
+	third := load()
+	third[2].Request.Amount = bogapi.MoneyFromInt(301)
+	third.Resume(prev)
+	assert.Equal(t, first[0].Request.UniqueID, third[0].Request.UniqueID)
+	assert.NotEqual(t, first[2].Request.UniqueID, third[2].Request.UniqueID)
+	assert.Zero(t, third[2].UniqueKey)
+}
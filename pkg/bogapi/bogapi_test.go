@@ -28,6 +28,66 @@ func Test_LoadConfig(t *testing.T) {
 	assert.Equal(t, []string{"USD", "EUR", "GEL"}, cfg.Accounts[1].Currency)
 }
 
+func Test_ConfigProfiles(t *testing.T) {
+	cfg, err := bogapi.LoadConfig("testdata/config_profiles.yaml")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme", "beta", "default"}, cfg.ProfileNames())
+
+	def, err := cfg.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, "123456", def.ClientID)
+	assert.Equal(t, "GE12BG0000000106360001", def.Accounts[0].ID)
+	assert.Nil(t, def.Profiles)
+
+	def2, err := cfg.Profile(bogapi.DefaultProfile)
+	require.NoError(t, err)
+	assert.Equal(t, def, def2)
+
+	acme, err := cfg.Profile("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "acme-id", acme.ClientID)
+	assert.Equal(t, "acme-secret", acme.ClientSecret)
+	// inherited from the top level
+	assert.Equal(t, "https://sandbox.businessonline.ge", acme.ApiHost)
+	assert.Equal(t, "https://sandbox.bog.ge/auth/realms/bog/protocol/openid-connect/token", acme.AuthURL)
+	require.Len(t, acme.Accounts, 1)
+	assert.Equal(t, []string{"GEL", "USD"}, acme.Accounts[0].Currency)
+
+	beta, err := cfg.Profile("beta")
+	require.NoError(t, err)
+	assert.Equal(t, "https://beta.businessonline.ge", beta.ApiHost)
+	assert.Equal(t, 30, beta.TokenRefreshSkew)
+
+	cfg.DefaultProfile = "beta"
+	def, err = cfg.Profile("")
+	require.NoError(t, err)
+	assert.Equal(t, "beta-id", def.ClientID)
+
+	_, err = cfg.Profile("gamma")
+	assert.EqualError(t, err, "profile not found: gamma, available: acme, beta, default")
+
+	_, err = bogapi.CreateProfileClient("testdata/config_profiles.yaml", "gamma", 6)
+	assert.EqualError(t, err, "profile not found: gamma, available: acme, beta, default")
+
+	client, err := bogapi.CreateProfileClient("testdata/config_profiles.yaml", "acme", 6)
+	require.NoError(t, err)
+	assert.Equal(t, "GE35BG0000000106360001", client.Accounts()[0].ID)
+}
+
+func Test_ConfigResolveSecrets(t *testing.T) {
+	t.Setenv("BOG_TEST_SECRET", "env-secret")
+	cfg := &bogapi.Config{
+		ClientID:     "plain-id",
+		ClientSecret: "env:BOG_TEST_SECRET",
+	}
+	require.NoError(t, cfg.ResolveSecrets())
+	assert.Equal(t, "plain-id", cfg.ClientID)
+	assert.Equal(t, "env-secret", cfg.ClientSecret)
+
+	cfg.ClientSecret = "env:BOG_TEST_MISSING"
+	assert.EqualError(t, cfg.ResolveSecrets(), "client_secret: failed to resolve env secret: environment variable is not set: BOG_TEST_MISSING")
+}
+
 func Test_RealAuth(t *testing.T) {
 	// To test your connection, populate the config file with real credentials
 	// and uncomment the following line
@@ -0,0 +1,989 @@
+// Package bogtest provides an in-process fake of the BOG Business Online API
+// for tests and offline development.
+package bogtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/rates"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ClientID is the default client ID accepted by the server
+	ClientID = "bogtest"
+	// ClientSecret is the default client secret accepted by the server
+	ClientSecret = "bogtest-secret"
+	// AuthPath is the path of the OAuth token endpoint
+	AuthPath = "/auth/realms/bog/protocol/openid-connect/token"
+	// DefaultPageSize is the default number of records in a statement page
+	DefaultPageSize = 1000
+	// NBGPath is the path of the National Bank of Georgia official rates stand-in,
+	// which does not require authorization
+	NBGPath = "/gw/api/ct/monetarypolicy/currencies/en/json"
+)
+
+// Server emulates the BOG OAuth token endpoint, statements, statement summaries,
+// account balances, payments and currency exchange, and NBG official rates.
+// It is safe for concurrent use.
+type Server struct {
+	*httptest.Server
+
+	// ClientID and ClientSecret are the credentials accepted by the token endpoint
+	ClientID     string
+	ClientSecret string
+
+	lock       sync.Mutex
+	tokenTTL   time.Duration
+	latency    time.Duration
+	pageSize   int
+	tokens     map[string]time.Time
+	records    map[string][]bogapi.Record
+	opening    map[string]bogapi.Money
+	balances   map[string]*bogapi.AccountBalance
+	statements map[int]*issuedStatement
+	payments   map[int64]*payment
+	rates      map[string]*bogapi.CommercialRate
+	official   map[string]map[string]*rates.OfficialRate
+	faults     []*fault
+	requests   map[string]int
+	nextID     int
+	nextToken  int
+	nextKey    int64
+}
+
+type issuedStatement struct {
+	account  string
+	currency string
+	// opening is the balance before the start of the statement
+	opening bogapi.Money
+	records []bogapi.Record
+}
+
+type payment struct {
+	request  *bogapi.PaymentRequest
+	exchange *bogapi.ExchangeRequest
+	status   *bogapi.PaymentStatus
+}
+
+type fault struct {
+	prefix string
+	status int
+	count  int
+}
+
+// NewServer starts and returns a new fake server,
+// the caller should call Close when finished
+func NewServer() *Server {
+	s := &Server{
+		ClientID:     ClientID,
+		ClientSecret: ClientSecret,
+		tokenTTL:     time.Hour,
+		pageSize:     DefaultPageSize,
+		tokens:       make(map[string]time.Time),
+		records:      make(map[string][]bogapi.Record),
+		opening:      make(map[string]bogapi.Money),
+		balances:     make(map[string]*bogapi.AccountBalance),
+		statements:   make(map[int]*issuedStatement),
+		payments:     make(map[int64]*payment),
+		rates:        make(map[string]*bogapi.CommercialRate),
+		official:     make(map[string]map[string]*rates.OfficialRate),
+		requests:     make(map[string]int),
+		nextID:       1000,
+		nextKey:      5000,
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST "+AuthPath, s.handleToken)
+	mux.HandleFunc("GET /api/statement/summary/{account}/{currency}/{id}", s.handleSummary)
+	// both /{from}/{to} and /{id}/{page} are served by the same pattern
+	mux.HandleFunc("GET /api/statement/{account}/{currency}/{from}/{to}", s.handleStatement)
+	mux.HandleFunc("GET /api/accounts/{account}/{currency}", s.handleBalance)
+	mux.HandleFunc("GET /api/documents/todayactivities/{account}/{currency}", s.handleTodayActivities)
+	mux.HandleFunc("GET /api/rates/commercial/{currency}", s.handleCommercialRate)
+	mux.HandleFunc("GET "+NBGPath, s.handleOfficialRates)
+	mux.HandleFunc("POST /api/documents/{type}", s.handleCreatePayment)
+	mux.HandleFunc("POST /api/documents/currency-exchange", s.handleExchange)
+	mux.HandleFunc("POST /api/documents/bulk/{type}", s.handleCreateBulkPayment)
+	mux.HandleFunc("GET /api/documents/statuses/{key}", s.handlePaymentStatus)
+	mux.HandleFunc("DELETE /api/documents/{key}", s.handleCancelPayment)
+
+	s.Server = httptest.NewServer(s.middleware(mux))
+	return s
+}
+
+// Config returns client configuration pointing to the server,
+// with accounts and currencies that have been seeded
+func (s *Server) Config() *bogapi.Config {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	currencies := make(map[string][]string)
+	add := func(key string) {
+		account, currency, _ := strings.Cut(key, "/")
+		for _, c := range currencies[account] {
+			if c == currency {
+				return
+			}
+		}
+		currencies[account] = append(currencies[account], currency)
+	}
+	for key := range s.records {
+		add(key)
+	}
+	for key := range s.balances {
+		add(key)
+	}
+
+	cfg := &bogapi.Config{
+		ClientID:     s.ClientID,
+		ClientSecret: s.ClientSecret,
+		AuthURL:      s.URL + AuthPath,
+		ApiHost:      s.URL,
+		NBGRatesURL:  s.URL + NBGPath,
+	}
+	for account, list := range currencies {
+		sort.Strings(list)
+		cfg.Accounts = append(cfg.Accounts, bogapi.Account{
+			ID:       account,
+			Currency: list,
+		})
+	}
+	sort.Slice(cfg.Accounts, func(i, j int) bool {
+		return cfg.Accounts[i].ID < cfg.Accounts[j].ID
+	})
+	return cfg
+}
+
+// WriteConfig writes configuration returned by Config to file,
+// to be used with bogapi.CreateClient or the CLI
+func (s *Server) WriteConfig(file string) error {
+	data, err := yaml.Marshal(s.Config())
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal config")
+	}
+	err = os.WriteFile(file, data, 0600)
+	if err != nil {
+		return errors.WithMessage(err, "failed to write file")
+	}
+	return nil
+}
+
+// AddRecords adds statement records for the account and currency
+func (s *Server) AddRecords(account, currency string, records ...bogapi.Record) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := account + "/" + currency
+	s.records[key] = append(s.records[key], records...)
+}
+
+// LoadStatements seeds records from a file with AccountStatements,
+// as produced by the `bog account statement` command
+func (s *Server) LoadStatements(file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read file")
+	}
+
+	var doc bogapi.AccountStatements
+	if err = json.Unmarshal(data, &doc); err != nil {
+		return errors.WithMessage(err, "failed to unmarshal statements")
+	}
+
+	for _, st := range doc.Combined {
+		s.AddRecords(st.Account, st.Currency, st.Records...)
+	}
+	return nil
+}
+
+// SetOpeningBalance sets the balance of the account before the first record,
+// used to build statement summaries
+func (s *Server) SetOpeningBalance(account, currency string, amount bogapi.Money) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.opening[account+"/"+currency] = amount
+}
+
+// PaymentAccount is an account with a valid IBAN to send test payments from,
+// see SeedPaymentAccount
+const PaymentAccount = "GE24BG0000000106360005"
+
+// SeedPaymentAccount adds GEL and USD sub-accounts of PaymentAccount
+func (s *Server) SeedPaymentAccount() {
+	s.SetBalance(PaymentAccount, "GEL", &bogapi.AccountBalance{
+		AvailableBalance: bogapi.MoneyFromInt(10000),
+		CurrentBalance:   bogapi.MoneyFromInt(10000),
+	})
+	s.SetBalance(PaymentAccount, "USD", &bogapi.AccountBalance{
+		AvailableBalance: bogapi.MoneyFromInt(5000),
+		CurrentBalance:   bogapi.MoneyFromInt(5000),
+	})
+}
+
+// SetBalance sets the balance returned for the account and currency
+func (s *Server) SetBalance(account, currency string, balance *bogapi.AccountBalance) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.balances[account+"/"+currency] = balance
+}
+
+// LoadBalance seeds the balance of the account and currency from a JSON file
+func (s *Server) LoadBalance(account, currency, file string) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read file")
+	}
+
+	balance := new(bogapi.AccountBalance)
+	if err = json.Unmarshal(data, balance); err != nil {
+		return errors.WithMessage(err, "failed to unmarshal balance")
+	}
+	s.SetBalance(account, currency, balance)
+	return nil
+}
+
+// Payment returns the submitted payment document and its status,
+// the document is nil for currency exchanges
+func (s *Server) Payment(uniqueKey int64) (*bogapi.PaymentRequest, *bogapi.PaymentStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p := s.payments[uniqueKey]
+	if p == nil {
+		return nil, nil
+	}
+	status := *p.status
+	return p.request, &status
+}
+
+// Payments returns the number of submitted payment documents
+func (s *Server) Payments() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.payments)
+}
+
+// Exchange returns the submitted currency exchange document and its status
+func (s *Server) Exchange(uniqueKey int64) (*bogapi.ExchangeRequest, *bogapi.PaymentStatus) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p := s.payments[uniqueKey]
+	if p == nil || p.exchange == nil {
+		return nil, nil
+	}
+	status := *p.status
+	return p.exchange, &status
+}
+
+// SetRate sets the commercial rate of the currency against GEL
+func (s *Server) SetRate(currency string, buy, sell float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rates[currency] = &bogapi.CommercialRate{
+		Currency: currency,
+		Buy:      buy,
+		Sell:     sell,
+	}
+}
+
+// SetPaymentStatus changes the status of the payment document,
+// new documents are created in bogapi.PaymentStatusInProgress status
+func (s *Server) SetPaymentStatus(uniqueKey int64, status, rejectReason string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if p := s.payments[uniqueKey]; p != nil {
+		p.status.Status = status
+		p.status.RejectReason = rejectReason
+	}
+}
+
+// SetOfficialRate sets the NBG rate of the currency for the date in 2006-01-02 format,
+// as GEL for quantity units. Requests for days without rates return the latest earlier rates.
+func (s *Server) SetOfficialRate(date, currency string, quantity int, rate float64) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.official[date] == nil {
+		s.official[date] = make(map[string]*rates.OfficialRate)
+	}
+	s.official[date][currency] = &rates.OfficialRate{
+		Currency:      currency,
+		Quantity:      quantity,
+		Rate:          rate,
+		ValidFromDate: date + "T00:00:00.000Z",
+	}
+}
+
+// SetLatency delays every response by d
+func (s *Server) SetLatency(d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.latency = d
+}
+
+// SetPageSize sets the number of records returned in a statement page
+func (s *Server) SetPageSize(size int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.pageSize = size
+}
+
+// SetTokenTTL sets the lifetime of issued tokens, default is one hour
+func (s *Server) SetTokenTTL(d time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokenTTL = d
+}
+
+// RevokeTokens invalidates all issued tokens,
+// subsequent API calls with those tokens fail with 401
+func (s *Server) RevokeTokens() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokens = make(map[string]time.Time)
+}
+
+// Fail makes the next count requests with path starting with prefix
+// to fail with the HTTP status
+func (s *Server) Fail(prefix string, status, count int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.faults = append(s.faults, &fault{
+		prefix: prefix,
+		status: status,
+		count:  count,
+	})
+}
+
+// Requests returns the number of received requests with path starting with prefix
+func (s *Server) Requests(prefix string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	count := 0
+	for path, n := range s.requests {
+		if strings.HasPrefix(path, prefix) {
+			count += n
+		}
+	}
+	return count
+}
+
+func (s *Server) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		s.requests[r.URL.Path]++
+		latency := s.latency
+		status := 0
+		for _, f := range s.faults {
+			if f.count > 0 && strings.HasPrefix(r.URL.Path, f.prefix) {
+				f.count--
+				status = f.status
+				break
+			}
+		}
+		s.lock.Unlock()
+
+		if latency > 0 {
+			select {
+			case <-time.After(latency):
+			case <-r.Context().Done():
+				return
+			}
+		}
+
+		if status != 0 {
+			writeError(w, status, "InjectedFault", "injected fault")
+			return
+		}
+
+		if r.URL.Path != AuthPath && r.URL.Path != NBGPath && !s.authorized(r) {
+			writeError(w, http.StatusUnauthorized, "InvalidToken", "the access token is invalid or expired")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	expires, ok := s.tokens[token]
+	return ok && time.Now().Before(expires)
+}
+
+func (s *Server) handleToken(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request", err.Error())
+		return
+	}
+
+	id, secret, ok := r.BasicAuth()
+	if !ok {
+		id, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if r.PostForm.Get("grant_type") != "client_credentials" {
+		writeError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+	if id != s.ClientID || secret != s.ClientSecret {
+		writeError(w, http.StatusUnauthorized, "invalid_client", "invalid client credentials")
+		return
+	}
+
+	s.lock.Lock()
+	s.nextToken++
+	token := fmt.Sprintf("bogtest-token-%d", s.nextToken)
+	ttl := s.tokenTTL
+	s.tokens[token] = time.Now().Add(ttl)
+	s.lock.Unlock()
+
+	writeJSON(w, http.StatusOK, &bogapi.AuthResponse{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int(ttl / time.Second),
+	})
+}
+
+func (s *Server) handleStatement(w http.ResponseWriter, r *http.Request) {
+	account := r.PathValue("account")
+	currency := r.PathValue("currency")
+
+	if id, err := strconv.Atoi(r.PathValue("from")); err == nil {
+		s.handleStatementPage(w, account, currency, id, r.PathValue("to"))
+		return
+	}
+
+	from, err := time.Parse(time.DateOnly, r.PathValue("from"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidDate", "invalid start date: "+r.PathValue("from"))
+		return
+	}
+	to, err := time.Parse(time.DateOnly, r.PathValue("to"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidDate", "invalid end date: "+r.PathValue("to"))
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key := account + "/" + currency
+	all, ok := s.records[key]
+	if !ok && s.balances[key] == nil {
+		writeError(w, http.StatusNotFound, "AccountNotFound", "account not found: "+account+" "+currency)
+		return
+	}
+
+	opening := s.opening[key]
+	records := []bogapi.Record{}
+	for _, rec := range all {
+		day := truncateDay(time.Time(rec.EntryDate))
+		if day.Before(from) {
+			opening = opening.Add(rec.EntryAmountCredit).Sub(rec.EntryAmountDebit)
+		} else if !day.After(to) {
+			records = append(records, rec)
+		}
+	}
+
+	s.nextID++
+	s.statements[s.nextID] = &issuedStatement{
+		account:  account,
+		currency: currency,
+		opening:  opening,
+		records:  records,
+	}
+
+	writeJSON(w, http.StatusOK, &bogapi.StatementResponse{
+		ID:      s.nextID,
+		Count:   len(records),
+		Records: s.page(records, 1),
+	})
+}
+
+func (s *Server) handleStatementPage(w http.ResponseWriter, account, currency string, id int, pageValue string) {
+	page, err := strconv.Atoi(pageValue)
+	if err != nil || page < 1 {
+		writeError(w, http.StatusBadRequest, "InvalidPage", "invalid page: "+pageValue)
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st := s.statements[id]
+	if st == nil || st.account != account || st.currency != currency {
+		writeError(w, http.StatusNotFound, "StatementNotFound", "statement not found: "+strconv.Itoa(id))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.page(st.records, page))
+}
+
+// page returns records of the 1-based page, the caller must hold the lock
+func (s *Server) page(records []bogapi.Record, page int) []bogapi.Record {
+	if s.pageSize <= 0 {
+		return records
+	}
+	start := min((page-1)*s.pageSize, len(records))
+	end := min(start+s.pageSize, len(records))
+	return records[start:end]
+}
+
+func (s *Server) handleSummary(w http.ResponseWriter, r *http.Request) {
+	account := r.PathValue("account")
+	currency := r.PathValue("currency")
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidStatementId", "invalid statement ID: "+r.PathValue("id"))
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	st := s.statements[id]
+	if st == nil || st.account != account || st.currency != currency {
+		writeError(w, http.StatusNotFound, "StatementNotFound", "statement not found: "+r.PathValue("id"))
+		return
+	}
+
+	writeJSON(w, http.StatusOK, s.summary(st))
+}
+
+// summary builds the statement summary from the records,
+// the caller must hold the lock
+func (s *Server) summary(st *issuedStatement) *bogapi.StatementSummary {
+	balance := st.opening
+
+	res := &bogapi.StatementSummary{
+		GlobalSummary: bogapi.GlobalSummary{
+			AccountNumber: st.account,
+			Currency:      st.currency,
+			InAmount:      balance,
+		},
+	}
+
+	records := append([]bogapi.Record{}, st.records...)
+	sort.SliceStable(records, func(i, j int) bool {
+		return time.Time(records[i].EntryDate).Before(time.Time(records[j].EntryDate))
+	})
+
+	var day *bogapi.DailySummary
+	for _, rec := range records {
+		date := truncateDay(time.Time(rec.EntryDate))
+		if day == nil || !time.Time(day.Date).Equal(date) {
+			res.DailySummaries = append(res.DailySummaries, bogapi.DailySummary{
+				Date: bogapi.Time(date),
+			})
+			day = &res.DailySummaries[len(res.DailySummaries)-1]
+		}
+		balance = balance.Add(rec.EntryAmountCredit).Sub(rec.EntryAmountDebit)
+		day.CreditSum = day.CreditSum.Add(rec.EntryAmountCredit)
+		day.DebitSum = day.DebitSum.Add(rec.EntryAmountDebit)
+		day.EntryCount++
+		day.Balance = balance
+
+		res.GlobalSummary.CreditSum = res.GlobalSummary.CreditSum.Add(rec.EntryAmountCredit)
+		res.GlobalSummary.DebitSum = res.GlobalSummary.DebitSum.Add(rec.EntryAmountDebit)
+	}
+	res.GlobalSummary.OutAmount = balance
+
+	if len(records) > 0 {
+		res.GlobalSummary.StartDate = bogapi.Time(truncateDay(time.Time(records[0].EntryDate)))
+		res.GlobalSummary.EndDate = bogapi.Time(truncateDay(time.Time(records[len(records)-1].EntryDate)))
+	}
+	return res
+}
+
+func (s *Server) handleBalance(w http.ResponseWriter, r *http.Request) {
+	account := r.PathValue("account")
+	currency := r.PathValue("currency")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	balance := s.balances[account+"/"+currency]
+	if balance == nil {
+		writeError(w, http.StatusNotFound, "AccountNotFound", "account not found: "+account+" "+currency)
+		return
+	}
+	writeJSON(w, http.StatusOK, balance)
+}
+
+// hasAccount returns true if records or balance were seeded for the "account/currency" key,
+// the caller must hold the lock
+func (s *Server) hasAccount(key string) bool {
+	_, ok := s.records[key]
+	return ok || s.balances[key] != nil
+}
+
+// handleTodayActivities returns the records of the account with today's entry date
+func (s *Server) handleTodayActivities(w http.ResponseWriter, r *http.Request) {
+	account := r.PathValue("account")
+	currency := r.PathValue("currency")
+	key := account + "/" + currency
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if !s.hasAccount(key) {
+		writeError(w, http.StatusNotFound, "AccountNotFound", "account not found: "+account+" "+currency)
+		return
+	}
+
+	today := today()
+	records := []bogapi.Record{}
+	for _, rec := range s.records[key] {
+		if truncateDay(time.Time(rec.EntryDate)).Equal(today) {
+			records = append(records, rec)
+		}
+	}
+	writeJSON(w, http.StatusOK, records)
+}
+
+func (s *Server) handleCreatePayment(w http.ResponseWriter, r *http.Request) {
+	req := new(bogapi.PaymentRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+
+	typ, ok := paymentType(r.PathValue("type"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", "unknown document type: "+r.PathValue("type"))
+		return
+	}
+	req.Type = typ
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	res, status, code := s.createPayment(req)
+	if status != http.StatusOK {
+		writeError(w, status, code, res.Message)
+		return
+	}
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleCreateBulkPayment(w http.ResponseWriter, r *http.Request) {
+	var docs []*bogapi.PaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&docs); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if len(docs) == 0 {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", "no documents")
+		return
+	}
+
+	typ, ok := paymentType(r.PathValue("type"))
+	if !ok {
+		writeError(w, http.StatusNotFound, "NotFound", "unknown document type: "+r.PathValue("type"))
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// invalid documents are rejected individually
+	results := make([]*bogapi.PaymentResponse, len(docs))
+	for i, req := range docs {
+		req.Type = typ
+		res, status, _ := s.createPayment(req)
+		if status != http.StatusOK {
+			res.ResultCode = status
+		}
+		results[i] = res
+	}
+	writeJSON(w, http.StatusOK, results)
+}
+
+func paymentType(value string) (bogapi.PaymentType, bool) {
+	switch typ := bogapi.PaymentType(value); typ {
+	case bogapi.PaymentDomestic, bogapi.PaymentIntraBank:
+		return typ, true
+	}
+	return "", false
+}
+
+// createPayment validates and stores the document,
+// the caller must hold the lock
+func (s *Server) createPayment(req *bogapi.PaymentRequest) (*bogapi.PaymentResponse, int, string) {
+	res := &bogapi.PaymentResponse{
+		UniqueID: req.UniqueID,
+	}
+
+	if err := req.Validate(); err != nil {
+		res.Message = err.Error()
+		return res, http.StatusBadRequest, "InvalidDocument"
+	}
+
+	key := req.SourceAccountNumber + "/" + req.Currency
+	if !s.hasAccount(key) {
+		res.Message = "account not found: " + req.SourceAccountNumber + " " + req.Currency
+		return res, http.StatusNotFound, "AccountNotFound"
+	}
+
+	// documents are idempotent by the unique ID
+	for key, p := range s.payments {
+		if p.request != nil && p.request.UniqueID == req.UniqueID {
+			res.UniqueKey = key
+			return res, http.StatusOK, ""
+		}
+	}
+
+	s.nextKey++
+	s.payments[s.nextKey] = &payment{
+		request: req,
+		status: &bogapi.PaymentStatus{
+			UniqueID:  req.UniqueID,
+			UniqueKey: s.nextKey,
+			Status:    bogapi.PaymentStatusInProgress,
+		},
+	}
+	res.UniqueKey = s.nextKey
+	return res, http.StatusOK, ""
+}
+
+func (s *Server) handleCommercialRate(w http.ResponseWriter, r *http.Request) {
+	currency := r.PathValue("currency")
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	rate := s.rates[currency]
+	if rate == nil {
+		writeError(w, http.StatusNotFound, "CurrencyNotFound", "rate not found: "+currency)
+		return
+	}
+	writeJSON(w, http.StatusOK, rate)
+}
+
+func (s *Server) handleOfficialRates(w http.ResponseWriter, r *http.Request) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		date = today().Format(rates.DateFormat)
+	}
+	var filter []string
+	if list := r.URL.Query().Get("currencies"); list != "" {
+		filter = strings.Split(list, ",")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	latest := ""
+	for day := range s.official {
+		if day <= date && day > latest {
+			latest = day
+		}
+	}
+
+	currencies := []*rates.OfficialRate{}
+	for currency, rate := range s.official[latest] {
+		if len(filter) == 0 || slices.Contains(filter, currency) {
+			currencies = append(currencies, rate)
+		}
+	}
+	sort.Slice(currencies, func(i, j int) bool {
+		return currencies[i].Currency < currencies[j].Currency
+	})
+
+	writeJSON(w, http.StatusOK, []map[string]any{{
+		"date":       date + "T00:00:00.000Z",
+		"currencies": currencies,
+	}})
+}
+
+// rate returns the commercial rate, GEL is always available,
+// the caller must hold the lock
+func (s *Server) rate(currency string) *bogapi.CommercialRate {
+	if currency == "GEL" {
+		return &bogapi.CommercialRate{Currency: currency, Buy: 1, Sell: 1}
+	}
+	return s.rates[currency]
+}
+
+// handleExchange executes the conversion immediately, debits and credits
+// seeded balances and adds statement records to both sub-accounts
+func (s *Server) handleExchange(w http.ResponseWriter, r *http.Request) {
+	req := new(bogapi.ExchangeRequest)
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidRequest", err.Error())
+		return
+	}
+	if err := req.Validate(); err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidDocument", err.Error())
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// documents are idempotent by the unique ID
+	for key, p := range s.payments {
+		if p.exchange != nil && p.exchange.UniqueID == req.UniqueID {
+			writeJSON(w, http.StatusOK, &bogapi.PaymentResponse{UniqueID: req.UniqueID, UniqueKey: key})
+			return
+		}
+	}
+
+	from := req.AccountNumber + "/" + req.FromCurrency
+	to := req.AccountNumber + "/" + req.ToCurrency
+	for _, key := range []string{from, to} {
+		if !s.hasAccount(key) {
+			writeError(w, http.StatusNotFound, "AccountNotFound", "account not found: "+strings.Replace(key, "/", " ", 1))
+			return
+		}
+	}
+
+	fromRate, toRate := s.rate(req.FromCurrency), s.rate(req.ToCurrency)
+	if fromRate == nil || toRate == nil {
+		writeError(w, http.StatusNotFound, "CurrencyNotFound", "rate not found: "+req.FromCurrency+"/"+req.ToCurrency)
+		return
+	}
+	rate := bogapi.CrossRate(fromRate, toRate)
+	if !req.Rate.IsZero() && !req.Rate.Equal(rate) {
+		writeError(w, http.StatusConflict, "RateChanged", "rate has changed: "+rate.String())
+		return
+	}
+	sent, received := req.Amount, bogapi.ExchangeAmount(req.Amount, rate, "")
+
+	if balance := s.balances[from]; balance != nil {
+		if balance.AvailableBalance.Cmp(sent) < 0 {
+			writeError(w, http.StatusConflict, "InsufficientFunds", "insufficient funds: "+strings.Replace(from, "/", " ", 1))
+			return
+		}
+		balance.AvailableBalance = balance.AvailableBalance.Sub(sent)
+		balance.CurrentBalance = balance.CurrentBalance.Sub(sent)
+	}
+	if balance := s.balances[to]; balance != nil {
+		balance.AvailableBalance = balance.AvailableBalance.Add(received)
+		balance.CurrentBalance = balance.CurrentBalance.Add(received)
+	}
+
+	s.nextKey++
+	s.payments[s.nextKey] = &payment{
+		exchange: req,
+		status: &bogapi.PaymentStatus{
+			UniqueID:  req.UniqueID,
+			UniqueKey: s.nextKey,
+			Status:    bogapi.PaymentStatusCompleted,
+		},
+	}
+
+	now := bogapi.Time(today())
+	record := func(amount bogapi.Money, counter string) bogapi.Record {
+		return bogapi.Record{
+			EntryDate:                   now,
+			EntryDocumentNumber:         strconv.FormatInt(s.nextKey, 10),
+			EntryAccountNumber:          req.AccountNumber,
+			EntryAmount:                 amount,
+			EntryComment:                fmt.Sprintf("ვალუტის გაცვლითი ოპერაცია. კურსი:%s კონტრთანხა: %s. %s", rate, counter, req.Nomination),
+			DocumentProductGroup:        "CCO",
+			DocumentNomination:          req.Nomination,
+			DocumentSourceAmount:        sent,
+			DocumentSourceCurrency:      req.FromCurrency,
+			DocumentDestinationAmount:   received,
+			DocumentDestinationCurrency: req.ToCurrency,
+			DocumentRate:                rate.InexactFloat64(),
+			DocumentKey:                 float64(s.nextKey),
+		}
+	}
+	debit := record(sent.Neg(), req.ToCurrency+received.Format())
+	debit.EntryAmountDebit = sent
+	credit := record(received, req.FromCurrency+sent.Format())
+	credit.EntryAmountCredit = received
+	s.records[from] = append(s.records[from], debit)
+	s.records[to] = append(s.records[to], credit)
+
+	writeJSON(w, http.StatusOK, &bogapi.PaymentResponse{UniqueID: req.UniqueID, UniqueKey: s.nextKey})
+}
+
+func (s *Server) handlePaymentStatus(w http.ResponseWriter, r *http.Request) {
+	p := s.findPayment(w, r.PathValue("key"))
+	if p == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	writeJSON(w, http.StatusOK, p.status)
+}
+
+func (s *Server) handleCancelPayment(w http.ResponseWriter, r *http.Request) {
+	p := s.findPayment(w, r.PathValue("key"))
+	if p == nil {
+		return
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if p.status.IsFinal() {
+		writeError(w, http.StatusConflict, "InvalidDocumentStatus", "document can not be canceled in status: "+p.status.Status)
+		return
+	}
+	p.status.Status = bogapi.PaymentStatusCanceled
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) findPayment(w http.ResponseWriter, value string) *payment {
+	key, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "InvalidUniqueKey", "invalid unique key: "+value)
+		return nil
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	p := s.payments[key]
+	if p == nil {
+		writeError(w, http.StatusNotFound, "DocumentNotFound", "document not found: "+value)
+	}
+	return p
+}
+
+// today returns the current date in the time zone of the bank, see bogapi.NowFunc
+func today() time.Time {
+	return truncateDay(bogapi.NowFunc().In(bogapi.Location))
+}
+
+func truncateDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(value)
+}
+
+func writeError(w http.ResponseWriter, status int, code, message string) {
+	writeJSON(w, status, map[string]string{
+		"code":    code,
+		"message": message,
+	})
+}
@@ -0,0 +1,89 @@
+package bogtest_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+)
+
+func token(t *testing.T, srv *bogtest.Server) string {
+	data := url.Values{}
+	data.Set("grant_type", "client_credentials")
+	req, err := http.NewRequest(http.MethodPost, srv.URL+bogtest.AuthPath, strings.NewReader(data.Encode()))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(srv.ClientID, srv.ClientSecret)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var res bogapi.AuthResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+	assert.Equal(t, "Bearer", res.TokenType)
+	return res.AccessToken
+}
+
+func get(t *testing.T, srv *bogtest.Server, path, token string) int {
+	req, err := http.NewRequest(http.MethodGet, srv.URL+path, nil)
+	require.NoError(t, err)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestServer(t *testing.T) {
+	t.Parallel()
+
+	srv := bogtest.NewServer()
+	defer srv.Close()
+
+	srv.SetBalance("GE00BG0000000000000001", "GEL", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(1)})
+	srv.AddRecords("GE00BG0000000000000001", "GEL", bogapi.Record{
+		EntryDate:         bogapi.Time(time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)),
+		EntryAmountCredit: bogapi.MoneyFromFloat(1),
+		EntryAmount:       bogapi.MoneyFromFloat(1),
+	})
+
+	cfg := srv.Config()
+	require.Len(t, cfg.Accounts, 1)
+	assert.Equal(t, []string{"GEL"}, cfg.Accounts[0].Currency)
+	assert.Equal(t, srv.URL, cfg.ApiHost)
+
+	path := "/api/accounts/GE00BG0000000000000001/GEL"
+	assert.Equal(t, http.StatusUnauthorized, get(t, srv, path, "invalid"))
+
+	tok := token(t, srv)
+	assert.Equal(t, http.StatusOK, get(t, srv, path, tok))
+	assert.Equal(t, http.StatusNotFound, get(t, srv, "/api/accounts/GE00BG0000000000000001/USD", tok))
+	assert.Equal(t, http.StatusOK, get(t, srv, "/api/statement/GE00BG0000000000000001/GEL/2025-02-01/2025-02-28", tok))
+	assert.Equal(t, http.StatusBadRequest, get(t, srv, "/api/statement/GE00BG0000000000000001/GEL/2025-02-01/feb", tok))
+	assert.Equal(t, http.StatusNotFound, get(t, srv, "/api/statement/summary/GE00BG0000000000000001/GEL/1", tok))
+
+	srv.Fail("/api/accounts/", http.StatusServiceUnavailable, 2)
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, srv, path, tok))
+	assert.Equal(t, http.StatusServiceUnavailable, get(t, srv, path, tok))
+	assert.Equal(t, http.StatusOK, get(t, srv, path, tok))
+	assert.Equal(t, 6, srv.Requests("/api/accounts/"))
+
+	srv.SetLatency(50 * time.Millisecond)
+	started := time.Now()
+	assert.Equal(t, http.StatusOK, get(t, srv, path, tok))
+	assert.GreaterOrEqual(t, time.Since(started), 50*time.Millisecond)
+	srv.SetLatency(0)
+
+	srv.RevokeTokens()
+	assert.Equal(t, http.StatusUnauthorized, get(t, srv, path, tok))
+}
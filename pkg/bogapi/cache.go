@@ -0,0 +1,158 @@
+package bogapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/effective-security/x/fileutil"
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// ErrNotCached is returned in offline mode for data missing in the cache
+var ErrNotCached = errors.New("not cached")
+
+// IsNotCached returns true if the error was returned in offline mode for data missing in the cache
+func IsNotCached(err error) bool {
+	return errors.Is(err, ErrNotCached)
+}
+
+// Cache stores statements, summaries and balances on disk. Statements of closed periods,
+// which end more than ClosedAfterDays before today, are served from the cache forever,
+// other entries until TTL expires.
+// In offline mode, all entries are served regardless of their age, and requests
+// to the API fail with ErrNotCached.
+type Cache struct {
+	dir     string
+	ttl     time.Duration
+	offline bool
+}
+
+// cacheEntry is the file format of the cache
+type cacheEntry struct {
+	CachedAt time.Time `json:"cached_at"`
+	// Closed entries never expire
+	Closed bool            `json:"closed"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// NewCache returns the cache in the folder, entries of open periods expire immediately
+func NewCache(dir string) *Cache {
+	return &Cache{dir: dir}
+}
+
+// WithTTL allows to specify how long entries of open periods and balances are served
+func (c *Cache) WithTTL(ttl time.Duration) *Cache {
+	c.ttl = ttl
+	return c
+}
+
+// WithOffline allows to serve the data only from the cache
+func (c *Cache) WithOffline(offline bool) *Cache {
+	c.offline = offline
+	return c
+}
+
+// Offline returns true if the data is served only from the cache
+func (c *Cache) Offline() bool {
+	return c != nil && c.offline
+}
+
+// WithCache specifies the cache of statements, summaries and balances
+func WithCache(cache *Cache) ClientOption {
+	return func(o *clientOptions) {
+		o.cache = cache
+	}
+}
+
+func (c *Cache) file(key string) string {
+	return filepath.Join(c.dir, key+".json")
+}
+
+// get decodes the entry into value, and returns false if it is missing or expired
+func (c *Cache) get(ctx context.Context, key string, value any) bool {
+	if c == nil || fileutil.FileExists(c.file(key)) != nil {
+		return false
+	}
+
+	data, err := os.ReadFile(c.file(key))
+	if err == nil {
+		var entry cacheEntry
+		if err = json.Unmarshal(data, &entry); err == nil {
+			if !c.offline && !entry.Closed && NowFunc().Sub(entry.CachedAt) >= c.ttl {
+				return false
+			}
+			err = json.Unmarshal(entry.Data, value)
+		}
+	}
+	if err != nil {
+		logger.ContextKV(ctx, xlog.WARNING, "reason", "load_cache", "key", key, "err", err.Error())
+		return false
+	}
+	logger.ContextKV(ctx, xlog.DEBUG, "reason", "cached", "key", key)
+	return true
+}
+
+// put stores the value, failures are logged as the cache is optional
+func (c *Cache) put(ctx context.Context, key string, closed bool, value any) {
+	if c == nil {
+		return
+	}
+	err := c.save(key, closed, value)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.WARNING, "reason", "save_cache", "key", key, "err", err.Error())
+	}
+}
+
+func (c *Cache) save(key string, closed bool, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal value")
+	}
+	data, err = json.MarshalIndent(&cacheEntry{
+		CachedAt: NowFunc(),
+		Closed:   closed,
+		Data:     data,
+	}, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal entry")
+	}
+	if err = os.MkdirAll(filepath.Dir(c.file(key)), 0700); err != nil {
+		return errors.WithMessage(err, "failed to create folder")
+	}
+	if err = os.WriteFile(c.file(key), data, 0600); err != nil {
+		return errors.WithMessage(err, "failed to write file")
+	}
+	return nil
+}
+
+// notCached returns the error for data missing in the cache in offline mode
+func notCached(format string, args ...any) error {
+	return errors.WithMessage(ErrNotCached, "offline: "+fmt.Sprintf(format, args...))
+}
+
+func statementKey(account, currency, startDate, endDate string) string {
+	return filepath.Join("statements", account+"_"+currency+"_"+startDate+"_"+endDate)
+}
+
+func summaryKey(account, currency string, id int) string {
+	return filepath.Join("summaries", fmt.Sprintf("%s_%s_%d", account, currency, id))
+}
+
+func balanceKey(account, currency string) string {
+	return filepath.Join("balances", account+"_"+currency)
+}
+
+// ClosedAfterDays specifies how many days after the end of a period its statements
+// may still change by late bookings, and are not cached forever
+const ClosedAfterDays = 5
+
+// isClosed returns true if the date in DateFormat is more than ClosedAfterDays before today
+func isClosed(date string) bool {
+	closed := NowFunc().In(Location).AddDate(0, 0, -ClosedAfterDays).Format(DateFormat)
+	return date != "" && date < closed
+}
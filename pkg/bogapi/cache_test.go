@@ -0,0 +1,154 @@
+package bogapi_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+)
+
+func TestClient_Cache(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, srv.WriteConfig(cfgFile))
+	dir := t.TempDir()
+
+	newClient := func(cache *bogapi.Cache) bogapi.Client {
+		client, err := bogapi.CreateClient(cfgFile, 6, bogapi.WithCache(cache))
+		require.NoError(t, err)
+		return client
+	}
+
+	ctx := context.Background()
+	closed := &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "EUR",
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+	}
+	today := time.Now().In(bogapi.Location).Format(bogapi.DateFormat)
+	open := &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "EUR",
+		StartDate: "2025-02-01",
+		EndDate:   today,
+	}
+
+	client := newClient(bogapi.NewCache(dir))
+	st, err := client.Statement(ctx, closed)
+	require.NoError(t, err)
+	require.Len(t, st.Records, 3)
+	summary, err := client.StatementSummary(ctx, closed.Account, closed.Currency, st.ID)
+	require.NoError(t, err)
+	_, err = client.Statement(ctx, open)
+	require.NoError(t, err)
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	statements := srv.Requests("/api/statement/")
+
+	// the closed period is cached forever, the open period expires immediately
+	client = newClient(bogapi.NewCache(dir))
+	cached, err := client.Statement(ctx, closed)
+	require.NoError(t, err)
+	assert.Equal(t, st, cached)
+	cachedSummary, err := client.StatementSummary(ctx, closed.Account, closed.Currency, st.ID)
+	require.NoError(t, err)
+	assert.Equal(t, summary, cachedSummary)
+	assert.Equal(t, statements, srv.Requests("/api/statement/"))
+
+	_, err = client.Statement(ctx, open)
+	require.NoError(t, err)
+	assert.Equal(t, statements+1, srv.Requests("/api/statement/"))
+
+	// within TTL, the open period and the balance are served from the cache
+	client = newClient(bogapi.NewCache(dir).WithTTL(time.Hour))
+	_, err = client.Statement(ctx, open)
+	require.NoError(t, err)
+	assert.Equal(t, statements+1, srv.Requests("/api/statement/"))
+	balances := srv.Requests("/api/accounts/")
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, balances, srv.Requests("/api/accounts/"))
+
+	// streaming returns the cached statement in one page
+	pages := 0
+	err = client.StreamStatement(ctx, closed, func(page *bogapi.StatementPage) error {
+		pages++
+		assert.Len(t, page.Records, 3)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, pages)
+
+	// offline, the cached data is served regardless of TTL, and the API is not called
+	auth := srv.Requests(bogtest.AuthPath)
+	client = newClient(bogapi.NewCache(dir).WithOffline(true))
+	require.NoError(t, client.Authenticate(ctx))
+	_, err = client.Statement(ctx, open)
+	require.NoError(t, err)
+	res, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		Account:   closed.Account,
+		Currency:  closed.Currency,
+		StartDate: closed.StartDate,
+		EndDate:   closed.EndDate,
+		Summary:   true,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 1)
+	assert.Len(t, res.Combined[0].Records, 3)
+	assert.NotNil(t, res.Combined[0].Summary)
+
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "GEL")
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotCached(err))
+	assert.Contains(t, err.Error(), "offline: GET /api/accounts/GE12BG0000000106360002/GEL: not cached")
+
+	_, err = client.AllStatements(ctx, &bogapi.StatementRequest{StartDate: "2025-01-01", EndDate: "2025-01-31"})
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotCached(err))
+	assert.Equal(t, auth, srv.Requests(bogtest.AuthPath))
+
+	// a period ended recently may still get late bookings, so it expires like the open one
+	statements = srv.Requests("/api/statement/")
+	client = newClient(bogapi.NewCache(dir))
+	recent := &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "EUR",
+		StartDate: "2025-02-01",
+		EndDate:   time.Now().In(bogapi.Location).AddDate(0, 0, -1).Format(bogapi.DateFormat),
+	}
+	_, err = client.Statement(ctx, recent)
+	require.NoError(t, err)
+	_, err = client.Statement(ctx, recent)
+	require.NoError(t, err)
+	assert.Equal(t, statements+2, srv.Requests("/api/statement/"))
+
+	// the cache of closed periods is bypassed with NoCache
+	_, err = client.Statement(ctx, &bogapi.StatementRequest{
+		Account:   closed.Account,
+		Currency:  closed.Currency,
+		StartDate: closed.StartDate,
+		EndDate:   closed.EndDate,
+		NoCache:   true,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, statements+3, srv.Requests("/api/statement/"))
+
+	// and so is the cache of balances within TTL
+	client = newClient(bogapi.NewCache(dir).WithTTL(time.Hour))
+	balances = srv.Requests("/api/accounts/")
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, balances, srv.Requests("/api/accounts/"))
+	fresh, err := client.AllBalances(ctx, &bogapi.BalanceRequest{Account: "GE12BG0000000106360002", Currency: "USD", NoCache: true})
+	require.NoError(t, err)
+	assert.Equal(t, "23083.33", fresh["GE12BG0000000106360002 USD"].AvailableBalance.String())
+	assert.Equal(t, balances+1, srv.Requests("/api/accounts/"))
+}
@@ -0,0 +1,108 @@
+package bogapi
+
+import (
+	"strings"
+	"time"
+)
+
+// CardPayment describes the card operation, which the bank reports only in the comment, like
+// "გადახდა - თანხა: GEL 135; MCC: 4814; მერჩანტის დასახელება: salerequest.silknet.com;
+// ავტორიზაციის თარიღი: 19/02/2025 16:06:42; ბარათის ნომერი: 42222*******0002; ავტორიზაციის კოდი: 442775",
+// or in its English translation
+type CardPayment struct {
+	// Amount is the original amount of the operation, in Currency
+	Amount   Money  `json:"Amount"`
+	Currency string `json:"Currency"`
+	// MCC is the merchant category code
+	MCC      string `json:"MCC"`
+	Merchant string `json:"Merchant"`
+	// AuthorizationDate is zero if not reported or not parsed
+	AuthorizationDate Time `json:"AuthorizationDate"`
+	// CardNumber is the masked card number
+	CardNumber        string `json:"CardNumber"`
+	AuthorizationCode string `json:"AuthorizationCode"`
+}
+
+// cardFields maps the field names of the comment, in Georgian and lower case English
+var cardFields = map[string]string{
+	"თანხა":  "amount",
+	"amount": "amount",
+	"mcc":    "mcc",
+	"მერჩანტის დასახელება": "merchant",
+	"merchant name": "merchant",
+	"merchant":      "merchant",
+	"ავტორიზაციის თარიღი": "date",
+	"authorization date": "date",
+	"ბარათის ნომერი":     "card",
+	"card number":        "card",
+	"ავტორიზაციის კოდი":  "code",
+	"authorization code": "code",
+}
+
+// cardDateFormat is the format of the authorization date, in the time zone of the bank
+const cardDateFormat = "02/01/2006 15:04:05"
+
+// ParseCardPayment returns the card operation described by the comment,
+// or nil if the comment has neither MCC nor card number
+func ParseCardPayment(comment string) *CardPayment {
+	c := new(CardPayment)
+	for i, part := range strings.Split(comment, ";") {
+		if i == 0 {
+			// the operation type precedes the first field: "Payment - Amount: GEL 135"
+			if _, field, ok := strings.Cut(part, " - "); ok {
+				part = field
+			}
+		}
+		name, value, ok := strings.Cut(part, ":")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch cardFields[strings.ToLower(strings.TrimSpace(name))] {
+		case "amount":
+			c.Amount, c.Currency = parseCardAmount(value)
+		case "mcc":
+			c.MCC = value
+		case "merchant":
+			c.Merchant = value
+		case "date":
+			if t, err := time.ParseInLocation(cardDateFormat, value, Location); err == nil {
+				c.AuthorizationDate = Time(t)
+			}
+		case "card":
+			c.CardNumber = value
+		case "code":
+			c.AuthorizationCode = value
+		}
+	}
+	if c.MCC == "" && c.CardNumber == "" {
+		return nil
+	}
+	return c
+}
+
+// parseCardAmount parses "GEL 135" or "135 GEL"
+func parseCardAmount(value string) (Money, string) {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return Money{}, ""
+	}
+	currency, amount := fields[0], fields[1]
+	m, err := ParseMoney(amount)
+	if err != nil {
+		currency, amount = amount, currency
+		if m, err = ParseMoney(amount); err != nil {
+			return Money{}, ""
+		}
+	}
+	return m.In(currency), currency
+}
+
+// CardPayment returns the card operation parsed from EntryComment, or DocumentNomination,
+// or nil for other records
+func (r *Record) CardPayment() *CardPayment {
+	if c := ParseCardPayment(r.EntryComment); c != nil {
+		return c
+	}
+	return ParseCardPayment(r.DocumentNomination)
+}
@@ -0,0 +1,55 @@
+package bogapi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestParseCardPayment(t *testing.T) {
+	authorized := bogapi.Time(time.Date(2025, 2, 19, 16, 6, 42, 0, bogapi.Location))
+	expected := &bogapi.CardPayment{
+		Amount:            bogapi.MoneyFromFloat(135).In("GEL"),
+		Currency:          "GEL",
+		MCC:               "4814",
+		Merchant:          "salerequest.silknet.com",
+		AuthorizationDate: authorized,
+		CardNumber:        "42222*******0002",
+		AuthorizationCode: "442775",
+	}
+
+	c := bogapi.ParseCardPayment("გადახდა - თანხა: GEL 135; MCC: 4814; მერჩანტის დასახელება: salerequest.silknet.com; ავტორიზაციის თარიღი: 19/02/2025 16:06:42; ბარათის ნომერი: 42222*******0002; ავტორიზაციის კოდი: 442775")
+	require.NotNil(t, c)
+	assert.Equal(t, expected.AuthorizationDate.String(), c.AuthorizationDate.String())
+	c.AuthorizationDate = authorized
+	assert.Equal(t, expected, c)
+
+	c = bogapi.ParseCardPayment("Payment - Amount: GEL 135; MCC: 4814; Merchant Name: salerequest.silknet.com; Authorization Date: 19/02/2025 16:06:42; Card Number: 42222*******0002; Authorization Code: 442775")
+	require.NotNil(t, c)
+	c.AuthorizationDate = authorized
+	assert.Equal(t, expected, c)
+
+	// the amount after the currency, and a merchant with a colon
+	c = bogapi.ParseCardPayment("Refund - amount: 12.50 USD; mcc: 5411; merchant name: SHOP: TBILISI MALL; authorization date: invalid")
+	require.NotNil(t, c)
+	assert.Equal(t, "12.50", c.Amount.Format())
+	assert.Equal(t, "USD", c.Currency)
+	assert.Equal(t, "5411", c.MCC)
+	assert.Equal(t, "SHOP: TBILISI MALL", c.Merchant)
+	assert.True(t, time.Time(c.AuthorizationDate).IsZero())
+	assert.Empty(t, c.CardNumber)
+
+	assert.Nil(t, bogapi.ParseCardPayment("ბარათის დაცვის მომსახურების საკომისიო 0002"))
+	assert.Nil(t, bogapi.ParseCardPayment("ვალუტის გაცვლითი ოპერაცია. კურსი:2.893 კონტრთანხა: GEL578.6. Conversion"))
+	assert.Nil(t, bogapi.ParseCardPayment(""))
+
+	r := &bogapi.Record{
+		EntryComment:       "Card payment",
+		DocumentNomination: "MCC: 5812; Card Number: 4***1",
+	}
+	require.NotNil(t, r.CardPayment())
+	assert.Equal(t, "5812", r.CardPayment().MCC)
+}
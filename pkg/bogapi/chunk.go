@@ -0,0 +1,152 @@
+package bogapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+)
+
+// monthlyChunks splits the period into calendar months.
+// The period is returned as is if it is within one month, or the dates are not in DateFormat,
+// so the API validates them.
+func monthlyChunks(startDate, endDate string) []*Period {
+	start, err1 := time.ParseInLocation(DateFormat, startDate, Location)
+	end, err2 := time.ParseInLocation(DateFormat, endDate, Location)
+	if err1 != nil || err2 != nil || end.Before(start) ||
+		(start.Year() == end.Year() && start.Month() == end.Month()) {
+		return []*Period{{}}
+	}
+
+	var chunks []*Period
+	for from := start; !from.After(end); {
+		next := time.Date(from.Year(), from.Month()+1, 1, 0, 0, 0, 0, Location)
+		to := next.AddDate(0, 0, -1)
+		if to.After(end) {
+			to = end
+		}
+		chunks = append(chunks, &Period{Start: from, End: to})
+		from = next
+	}
+	return chunks
+}
+
+// chunkedStatement fetches the statement of the target by chunks, and stitches them
+// in chronological order. A zero chunk stands for the period of the request.
+func (c *client) chunkedStatement(ctx context.Context, t target, req *StatementRequest, chunks []*Period) (*AccountStatement, error) {
+	type result struct {
+		chunk   *StatementChunk
+		records []Record
+		summary *StatementSummary
+	}
+	results := make([]*result, len(chunks))
+
+	fetch := func(i int) error {
+		start, end := req.StartDate, req.EndDate
+		if !chunks[i].Start.IsZero() {
+			start, end = chunks[i].StartDate(), chunks[i].EndDate()
+		}
+
+		st, err := c.Statement(ctx, &StatementRequest{
+			Account:   t.Account,
+			Currency:  t.Currency,
+			StartDate: start,
+			EndDate:   end,
+			NoCache:   req.NoCache,
+		})
+		if err != nil {
+			if len(chunks) > 1 {
+				err = errors.WithMessagef(err, "chunk %s..%s", start, end)
+			}
+			return err
+		}
+
+		r := &result{
+			chunk: &StatementChunk{
+				StartDate:   start,
+				EndDate:     end,
+				StatementID: st.ID,
+				Pages:       st.Pages,
+				Count:       len(st.Records),
+			},
+			records: st.Records,
+		}
+		if req.Summary {
+			if r.summary, err = c.StatementSummary(ctx, t.Account, t.Currency, st.ID); err != nil {
+				return err
+			}
+		}
+		results[i] = r
+		return nil
+	}
+
+	workers := max(req.ChunkWorkers, 1)
+	errs := make([]error, len(chunks))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range chunks {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fetch(i)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	ast := &AccountStatement{
+		Account:     t.Account,
+		Currency:    t.Currency,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		StatementID: results[0].chunk.StatementID,
+	}
+	var summaries []*StatementSummary
+	for _, r := range results {
+		ast.Records = append(ast.Records, r.records...)
+		ast.Pages += r.chunk.Pages
+		summaries = append(summaries, r.summary)
+	}
+	if len(results) == 1 {
+		ast.Summary = results[0].summary
+		return ast, nil
+	}
+
+	for _, r := range results {
+		ast.Chunks = append(ast.Chunks, r.chunk)
+	}
+	if req.Summary {
+		ast.Summary = MergeSummaries(summaries)
+	}
+	return ast, nil
+}
+
+// MergeSummaries combines summaries of consecutive chunks into the summary of the whole period
+func MergeSummaries(list []*StatementSummary) *StatementSummary {
+	first, last := list[0].GlobalSummary, list[len(list)-1].GlobalSummary
+
+	res := &StatementSummary{GlobalSummary: first}
+	g := &res.GlobalSummary
+	g.EndDate = last.EndDate
+	g.PeriodEndDate = last.PeriodEndDate
+	g.OutAmount = last.OutAmount
+	g.OutAmountBase = last.OutAmountBase
+	g.OutRate = last.OutRate
+	g.CreditSum = Money{}
+	g.DebitSum = Money{}
+	for _, s := range list {
+		g.CreditSum = g.CreditSum.Add(s.GlobalSummary.CreditSum)
+		g.DebitSum = g.DebitSum.Add(s.GlobalSummary.DebitSum)
+		res.DailySummaries = append(res.DailySummaries, s.DailySummaries...)
+	}
+	return res
+}
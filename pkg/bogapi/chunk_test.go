@@ -0,0 +1,71 @@
+package bogapi_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestAllStatements_Chunks(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	day := func(month time.Month, d int) bogapi.Time {
+		return bogapi.Time(time.Date(2024, month, d, 10, 0, 0, 0, time.UTC))
+	}
+	srv.AddRecords("GE35BG0000000106360001", "GEL",
+		bogapi.Record{EntryDate: day(12, 20), EntryDocumentNumber: "DEC", EntryAmountCredit: bogapi.MoneyFromFloat(100)},
+		bogapi.Record{EntryDate: day(11, 5), EntryDocumentNumber: "NOV", EntryAmountCredit: bogapi.MoneyFromFloat(50)},
+		bogapi.Record{EntryDate: day(10, 31), EntryDocumentNumber: "OCT", EntryAmountDebit: bogapi.MoneyFromFloat(20)},
+		bogapi.Record{EntryDate: day(10, 1), EntryDocumentNumber: "EXCLUDED", EntryAmountCredit: bogapi.MoneyFromFloat(1)},
+	)
+	cfg := srv.Config()
+	cfg.Accounts = []bogapi.Account{{ID: "GE35BG0000000106360001", Currency: []string{"GEL"}}}
+	client := newTestClientWithConfig(t, cfg)
+	ctx := context.Background()
+
+	for _, workers := range []int{0, 3} {
+		res, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+			StartDate:    "2024-10-15",
+			EndDate:      "2024-12-20",
+			Summary:      true,
+			ChunkWorkers: workers,
+		})
+		require.NoError(t, err)
+		require.Len(t, res.Combined, 1)
+
+		st := res.Combined[0]
+		assert.Equal(t, "2024-10-15", st.StartDate)
+		assert.Equal(t, "2024-12-20", st.EndDate)
+		require.Len(t, st.Chunks, 3)
+		assert.Equal(t, &bogapi.StatementChunk{StartDate: "2024-10-15", EndDate: "2024-10-31", StatementID: st.Chunks[0].StatementID, Pages: 1, Count: 1}, st.Chunks[0])
+		assert.Equal(t, "2024-11-01", st.Chunks[1].StartDate)
+		assert.Equal(t, "2024-11-30", st.Chunks[1].EndDate)
+		assert.Equal(t, "2024-12-01", st.Chunks[2].StartDate)
+		assert.Equal(t, "2024-12-20", st.Chunks[2].EndDate)
+		assert.Equal(t, st.Chunks[0].StatementID, st.StatementID)
+
+		require.Len(t, st.Records, 3)
+		assert.Equal(t, "OCT", st.Records[0].EntryDocumentNumber)
+		assert.Equal(t, "NOV", st.Records[1].EntryDocumentNumber)
+		assert.Equal(t, "DEC", st.Records[2].EntryDocumentNumber)
+
+		require.NotNil(t, st.Summary)
+		assert.Equal(t, "150", st.Summary.GlobalSummary.CreditSum.String())
+		assert.Equal(t, "20", st.Summary.GlobalSummary.DebitSum.String())
+		assert.Len(t, st.Summary.DailySummaries, 3)
+	}
+
+	res, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		StartDate: "2024-10-15",
+		EndDate:   "2024-12-20",
+		NoSplit:   true,
+	})
+	require.NoError(t, err)
+	assert.Empty(t, res.Combined[0].Chunks)
+	assert.Len(t, res.Combined[0].Records, 3)
+}
@@ -8,6 +8,8 @@ import (
 	"net/http"
 	"net/url"
 	"os"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/effective-security/porto/pkg/retriable"
@@ -28,23 +30,78 @@ type Client interface {
 	Accounts() []Account
 	Authenticate(ctx context.Context) error
 	Statement(ctx context.Context, req *StatementRequest) (*StatementResponse, error)
+	// StreamStatement calls fn for every page of the statement,
+	// so the caller does not need to keep all records in memory
+	StreamStatement(ctx context.Context, req *StatementRequest, fn func(page *StatementPage) error) error
 	AllStatements(ctx context.Context, req *StatementRequest) (*AccountStatements, error)
 	StatementSummary(ctx context.Context, account, currency string, id int) (*StatementSummary, error)
 	Balance(ctx context.Context, account, currency string) (*AccountBalance, error)
-	AllBalances(ctx context.Context) (map[string]*AccountBalance, error)
+	AllBalances(ctx context.Context, req *BalanceRequest) (map[string]*AccountBalance, error)
+	// TodayActivities returns today's operations of the account, which are not in statements yet
+	TodayActivities(ctx context.Context, account, currency string) ([]Record, error)
+	// AllTodayActivities returns today's operations for all configured accounts and currencies
+	AllTodayActivities(ctx context.Context, req *BalanceRequest) (*AccountStatements, error)
+	// DiscoverAccounts returns the accounts with currency sub-accounts visible to the credentials
+	DiscoverAccounts(ctx context.Context, req *DiscoverRequest) ([]Account, error)
+
+	// CreatePayment validates and submits the payment document
+	CreatePayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error)
+	// GetPaymentStatus returns the status of the payment document
+	GetPaymentStatus(ctx context.Context, uniqueKey int64) (*PaymentStatus, error)
+	// CancelPayment cancels the payment document, which has not been executed yet
+	CancelPayment(ctx context.Context, uniqueKey int64) error
+	// CreateBatchPayment submits the documents of the same type as a bulk payment
+	CreateBatchPayment(ctx context.Context, typ PaymentType, docs []*PaymentRequest) ([]*PaymentResponse, error)
+
+	// CommercialRate returns the current commercial rate of the currency
+	CommercialRate(ctx context.Context, currency string) (*CommercialRate, error)
+	// QuoteExchange returns the conversion at the current commercial rate
+	QuoteExchange(ctx context.Context, req *ExchangeRequest) (*ExchangeQuote, error)
+	// Exchange submits the conversion between currency sub-accounts of the same account
+	Exchange(ctx context.Context, req *ExchangeRequest) (*PaymentResponse, error)
 }
 
+// DefaultWorkers specifies the default number of accounts fetched concurrently
+const DefaultWorkers = 4
+
 type client struct {
 	cfg        *Config
 	httpClient *retriable.Client
-	auth       *AuthResponse
+	host       string
+	skew       time.Duration
+	cache      *Cache
+
+	// lock protects auth
+	lock sync.RWMutex
+	auth *AuthResponse
+}
+
+// CreateClient returns a client for the default profile of the configuration file
+func CreateClient(file string, timeoutSec int, opts ...ClientOption) (Client, error) {
+	return CreateProfileClient(file, "", timeoutSec, opts...)
 }
 
-func CreateClient(file string, timeoutSec int) (Client, error) {
+// CreateProfileClient returns a client for the named profile of the configuration file
+func CreateProfileClient(file, profile string, timeoutSec int, opts ...ClientOption) (Client, error) {
+	var o clientOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	cfg, err := LoadConfig(file)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed to load config")
 	}
+	cfg, err = cfg.Profile(profile)
+	if err != nil {
+		return nil, err
+	}
+	// the credentials are not used in offline mode
+	if !o.cache.Offline() {
+		if err = cfg.ResolveSecrets(); err != nil {
+			return nil, err
+		}
+	}
 
 	server := values.StringsCoalesce(cfg.ApiHost, os.Getenv("BOG_SERVER"))
 	client, err := retriable.Default(server)
@@ -52,19 +109,50 @@ func CreateClient(file string, timeoutSec int) (Client, error) {
 		return nil, err
 	}
 
-	if timeoutSec > 0 {
+	rt := o.roundTripper
+	if rt == nil && cfg.Transport != nil {
+		if rt, err = cfg.Transport.RoundTripper(); err != nil {
+			return nil, errors.WithMessage(err, "invalid transport")
+		}
+	}
+	if rt != nil {
+		client.WithTransport(rt)
+	}
+
+	if cfg.Transport != nil && cfg.Transport.Timeout == 0 && timeoutSec > 0 {
+		// the timeout of the client is the default for endpoints without their own timeout
+		tr := *cfg.Transport
+		tr.Timeout = timeoutSec
+		cfg.Transport = &tr
+	}
+	if timeout := cfg.Transport.maxTimeout(); timeout > 0 {
+		// shorter endpoint timeouts are applied per request
+		client.WithTimeout(timeout)
+	} else if timeoutSec > 0 {
 		client.WithTimeout(time.Second * time.Duration(timeoutSec))
 	}
 
 	client = client.WithUserAgent("tbilicode-bogclient " + version.Current().String())
 
-	return NewClient(cfg, client), nil
+	c := newClient(cfg, client)
+	c.cache = o.cache
+	return c, nil
 }
 
+// NewClient returns a new client, which is safe for concurrent use
 func NewClient(cfg *Config, httpClient *retriable.Client) Client {
+	return newClient(cfg, httpClient)
+}
+
+func newClient(cfg *Config, httpClient *retriable.Client) *client {
 	c := &client{
 		cfg:        cfg,
 		httpClient: httpClient,
+		host:       strings.TrimSuffix(values.StringsCoalesce(cfg.ApiHost, os.Getenv("BOG_SERVER")), "/"),
+		skew:       DefaultTokenRefreshSkew,
+	}
+	if cfg.TokenRefreshSkew > 0 {
+		c.skew = time.Second * time.Duration(cfg.TokenRefreshSkew)
 	}
 	return c
 }
@@ -77,27 +165,78 @@ func (c *client) Accounts() []Account {
 	return c.cfg.Accounts
 }
 
+// DefaultTokenRefreshSkew specifies how long before expiration the token is refreshed
+const DefaultTokenRefreshSkew = time.Minute
+
 type AuthResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
 
 	Expires time.Time `json:"-"`
+	// RefreshAt specifies when the token should be refreshed, ahead of Expires
+	RefreshAt time.Time `json:"-"`
 }
 
+// Authenticate obtains an access token, if the current one is missing or about to expire.
+// In offline mode, it does nothing.
 func (c *client) Authenticate(ctx context.Context) error {
-	if c.auth != nil && time.Now().Before(c.auth.Expires) {
+	if c.cache.Offline() {
 		return nil
 	}
+	_, err := c.token(ctx)
+	return err
+}
+
+// token returns a valid access token, authenticating if needed
+func (c *client) token(ctx context.Context) (*AuthResponse, error) {
+	c.lock.RLock()
+	auth := c.auth
+	c.lock.RUnlock()
+	if auth != nil && time.Now().Before(auth.RefreshAt) {
+		return auth, nil
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// another goroutine may have refreshed the token while waiting for the lock
+	if c.auth != nil && time.Now().Before(c.auth.RefreshAt) {
+		return c.auth, nil
+	}
 
+	auth, err := c.authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.auth = auth
+	return auth, nil
+}
+
+// invalidate drops the token, unless it has already been refreshed
+func (c *client) invalidate(auth *AuthResponse) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.auth == auth {
+		c.auth = nil
+	}
+}
+
+func (c *client) authenticate(ctx context.Context) (*AuthResponse, error) {
 	data := url.Values{}
 	data.Set("grant_type", "client_credentials")
 	data.Set("client_id", c.cfg.ClientID)
 	data.Set("client_secret", c.cfg.ClientSecret)
 
-	req, err := http.NewRequest(http.MethodPost, c.cfg.AuthURL, bytes.NewBufferString(data.Encode()))
+	if timeout := c.cfg.Transport.timeout(AuthEndpoint); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.cfg.AuthURL, bytes.NewBufferString(data.Encode()))
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	req.Header.Set(header.ContentType, "application/x-www-form-urlencoded")
@@ -108,42 +247,129 @@ func (c *client) Authenticate(ctx context.Context) error {
 		logger.ContextKV(ctx, xlog.ERROR,
 			"err", err.Error(),
 		)
-		return errors.WithMessage(err, "failed to authenticate")
+		return nil, errors.WithMessage(err, "failed to authenticate")
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return errors.Errorf("authentication failed: %s", resp.Status)
-	}
-
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return errors.WithMessage(err, "failed to read response body")
+		return nil, errors.WithMessage(err, "failed to read response body")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.WithMessage(
+			newAPIError(http.MethodPost, c.cfg.AuthURL, resp.StatusCode, resp.Header, body),
+			"authentication failed")
 	}
 
 	var authResp AuthResponse
 	if err := json.Unmarshal(body, &authResp); err != nil {
-		return errors.WithMessage(err, "failed to parse response body")
+		return nil, errors.WithMessage(err, "failed to parse response body")
 	}
 
-	authResp.Expires = time.Now().Add(time.Second * time.Duration(authResp.ExpiresIn))
-	c.auth = &authResp
+	now := time.Now()
+	lifetime := time.Second * time.Duration(authResp.ExpiresIn)
+	// do not refresh too often for short-lived tokens
+	skew := min(c.skew, lifetime/2)
 
-	c.httpClient.AddHeader(header.Authorization, authResp.TokenType+" "+authResp.AccessToken)
-	c.httpClient.AddHeader(header.ContentType, "application/json")
-	c.httpClient.AddHeader("Accept-Language", "en")
-	return nil
+	authResp.Expires = now.Add(lifetime)
+	authResp.RefreshAt = authResp.Expires.Add(-skew)
+	return &authResp, nil
 }
 
-func (c *client) StatementSummary(ctx context.Context, account, currency string, id int) (*StatementSummary, error) {
-	err := c.Authenticate(ctx)
+// call sends an authenticated request to the API, and decodes the response into res.
+// If the token was revoked before its expiration, it re-authenticates and retries once.
+func (c *client) call(ctx context.Context, method, path string, body, res any) (http.Header, int, error) {
+	if c.cache.Offline() {
+		return nil, 0, notCached("%s %s", method, path)
+	}
+
+	var payload []byte
+	if body != nil {
+		var err error
+		payload, err = json.Marshal(body)
+		if err != nil {
+			return nil, 0, errors.WithMessage(err, "failed to marshal request")
+		}
+	}
+
+	for attempt := 0; ; attempt++ {
+		auth, err := c.token(ctx)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		hdr, status, data, err := c.do(ctx, method, path, auth, payload)
+		if err != nil {
+			return hdr, status, err
+		}
+
+		if status == http.StatusUnauthorized && attempt == 0 {
+			logger.ContextKV(ctx, xlog.WARNING,
+				"reason", "token_revoked",
+				"path", path,
+			)
+			c.invalidate(auth)
+			continue
+		}
+
+		if status < 200 || status >= 300 {
+			return hdr, status, newAPIError(method, path, status, hdr, data)
+		}
+
+		if res != nil && len(data) > 0 {
+			if err = json.Unmarshal(data, res); err != nil {
+				return hdr, status, errors.WithMessage(err, "failed to parse response body")
+			}
+		}
+		return hdr, status, nil
+	}
+}
+
+func (c *client) do(ctx context.Context, method, path string, auth *AuthResponse, payload []byte) (http.Header, int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	if timeout := c.cfg.Transport.timeout(path); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, body)
 	if err != nil {
-		return nil, err
+		return nil, 0, nil, err
 	}
 
-	path := fmt.Sprintf("/api/statement/summary/%s/%s/%d", account, currency, id)
+	// headers are set per request, as the client is shared between goroutines
+	req.Header.Set(header.Authorization, auth.TokenType+" "+auth.AccessToken)
+	req.Header.Set(header.ContentType, "application/json")
+	req.Header.Set("Accept-Language", "en")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return resp.Header, resp.StatusCode, nil, errors.WithMessage(err, "failed to read response body")
+	}
+	return resp.Header, resp.StatusCode, data, nil
+}
+
+func (c *client) StatementSummary(ctx context.Context, account, currency string, id int) (*StatementSummary, error) {
+	key := summaryKey(account, currency, id)
 	var summary StatementSummary
-	hdr, status, err := c.httpClient.Get(ctx, path, &summary)
+	if c.cache.get(ctx, key, &summary) {
+		return &summary, nil
+	}
+
+	path := fmt.Sprintf("/api/statement/summary/%s/%s/%d", account, currency, id)
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &summary)
 	if err != nil {
 		logger.ContextKV(ctx, xlog.ERROR,
 			"account", account,
@@ -152,9 +378,12 @@ func (c *client) StatementSummary(ctx context.Context, account, currency string,
 			"header", hdr,
 			"err", err.Error(),
 		)
-		return nil, errors.WithMessagef(err, "failed to get statement summary: %s %s", account, currency)
+		return nil, errors.WithMessagef(withAccount(err, account, currency),
+			"failed to get statement summary: %s %s", account, currency)
 	}
 
+	end := time.Time(summary.GlobalSummary.EndDate)
+	c.cache.put(ctx, key, !end.IsZero() && isClosed(end.In(Location).Format(DateFormat)), &summary)
 	return &summary, err
 }
 
@@ -166,17 +395,63 @@ type StatementRequest struct {
 	// EndDate specifies the end date for the statement period
 	EndDate string
 	Summary bool
+	// Workers specifies the number of accounts fetched concurrently by AllStatements,
+	// DefaultWorkers is used if not set
+	Workers int
+	// NoSplit disables splitting periods longer than a month into monthly chunks by AllStatements
+	NoSplit bool
+	// ChunkWorkers specifies the number of monthly chunks of one account fetched concurrently
+	// by AllStatements, the chunks are fetched one by one if not set
+	ChunkWorkers int
+	// NoCache fetches the statement from the API even if it is cached,
+	// and replaces the cached one
+	NoCache bool
 }
 
+// Statement returns the statement for the requested period,
+// following the statement ID to fetch all pages
 func (c *client) Statement(ctx context.Context, req *StatementRequest) (*StatementResponse, error) {
-	err := c.Authenticate(ctx)
+	res := new(StatementResponse)
+	cached, err := c.streamStatement(ctx, req, func(page *StatementPage) error {
+		res.ID = page.StatementID
+		res.Count = page.Count
+		res.Pages = page.Page
+		res.Records = append(res.Records, page.Records...)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	if res.Records == nil {
+		res.Records = []Record{}
+	}
+	if !cached {
+		c.cache.put(ctx, statementKey(req.Account, req.Currency, req.StartDate, req.EndDate), isClosed(req.EndDate), res)
+	}
+	return res, nil
+}
 
-	path := fmt.Sprintf("/api/statement/%s/%s/%s/%s", req.Account, req.Currency, req.StartDate, req.EndDate)
+func (c *client) StreamStatement(ctx context.Context, req *StatementRequest, fn func(page *StatementPage) error) error {
+	_, err := c.streamStatement(ctx, req, fn)
+	return err
+}
+
+// streamStatement calls fn for each page of the statement,
+// and returns true if the statement is read from the cache
+func (c *client) streamStatement(ctx context.Context, req *StatementRequest, fn func(page *StatementPage) error) (bool, error) {
 	var res StatementResponse
-	hdr, status, err := c.httpClient.Get(ctx, path, &res)
+	if !req.NoCache && c.cache.get(ctx, statementKey(req.Account, req.Currency, req.StartDate, req.EndDate), &res) {
+		// the cached statement is returned in one page
+		return true, fn(&StatementPage{
+			StatementID: res.ID,
+			Count:       res.Count,
+			Page:        1,
+			Records:     res.Records,
+		})
+	}
+
+	path := fmt.Sprintf("/api/statement/%s/%s/%s/%s", req.Account, req.Currency, req.StartDate, req.EndDate)
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &res)
 	if err != nil {
 		logger.ContextKV(ctx, xlog.ERROR,
 			"account", req.Account,
@@ -185,64 +460,135 @@ func (c *client) Statement(ctx context.Context, req *StatementRequest) (*Stateme
 			"header", hdr,
 			"err", err.Error(),
 		)
-		return nil, errors.WithMessagef(err, "failed to create statement: %s %s - [%s,%s]",
+		return false, errors.WithMessagef(withAccount(err, req.Account, req.Currency),
+			"failed to create statement: %s %s - [%s,%s]",
 			req.Account, req.Currency, req.StartDate, req.EndDate)
 	}
-	return &res, err
+
+	page := &StatementPage{
+		StatementID: res.ID,
+		Count:       res.Count,
+		Page:        1,
+		Records:     res.Records,
+	}
+	fetched := len(res.Records)
+	if err = fn(page); err != nil {
+		return false, err
+	}
+
+	for fetched < res.Count {
+		page, err = c.statementPage(ctx, req.Account, req.Currency, res.ID, page.Page+1)
+		if err != nil {
+			return false, err
+		}
+		if len(page.Records) == 0 {
+			logger.ContextKV(ctx, xlog.WARNING,
+				"reason", "incomplete_statement",
+				"account", req.Account,
+				"currency", req.Currency,
+				"id", res.ID,
+				"count", res.Count,
+				"fetched", fetched,
+			)
+			break
+		}
+		page.Count = res.Count
+		fetched += len(page.Records)
+		if err = fn(page); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+func (c *client) statementPage(ctx context.Context, account, currency string, id, page int) (*StatementPage, error) {
+	path := fmt.Sprintf("/api/statement/%s/%s/%d/%d", account, currency, id, page)
+	var raw json.RawMessage
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &raw)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"account", account,
+			"currency", currency,
+			"id", id,
+			"page", page,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(withAccount(err, account, currency),
+			"failed to get statement page: %s %s - %d/%d",
+			account, currency, id, page)
+	}
+
+	// the page is returned either as a list of records, or in the statement envelope
+	res := &StatementResponse{ID: id}
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '[' {
+		err = json.Unmarshal(raw, &res.Records)
+	} else {
+		err = json.Unmarshal(raw, res)
+	}
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to parse statement page: %s %s - %d/%d",
+			account, currency, id, page)
+	}
+
+	return &StatementPage{
+		StatementID: id,
+		Page:        page,
+		Records:     res.Records,
+	}, nil
 }
 
+// AllStatements returns statements for all configured accounts and currencies.
+// If some of them fail, statements for the others are returned along with FetchError.
 func (c *client) AllStatements(ctx context.Context, req *StatementRequest) (*AccountStatements, error) {
-	res := AccountStatements{}
-	for _, acc := range c.cfg.Accounts {
-		if req.Account != "" && req.Account != acc.ID {
-			continue
-		}
-		for _, currency := range acc.Currency {
-			if req.Currency != "" && req.Currency != currency {
-				continue
-			}
+	// authenticate once, before fetching accounts concurrently
+	err := c.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-			st, err := c.Statement(ctx, &StatementRequest{
-				Account:   acc.ID,
-				Currency:  currency,
-				StartDate: req.StartDate,
-				EndDate:   req.EndDate,
-			})
-			if err != nil {
-				return nil, err
-			}
+	targets := c.targets(req.Account, req.Currency)
+	combined := make([]*AccountStatement, len(targets))
 
-			ast := &AccountStatement{
-				Account:     acc.ID,
-				Currency:    currency,
-				StartDate:   req.StartDate,
-				EndDate:     req.EndDate,
-				StatementID: st.ID,
-				Records:     st.Records,
-			}
+	chunks := []*Period{{}}
+	if !req.NoSplit {
+		chunks = monthlyChunks(req.StartDate, req.EndDate)
+	}
 
-			if req.Summary {
-				sum, err := c.StatementSummary(ctx, acc.ID, currency, st.ID)
-				if err != nil {
-					return nil, err
-				}
-				ast.Summary = sum
-			}
+	err = forEach(ctx, targets, req.Workers, func(ctx context.Context, i int, t target) error {
+		ast, err := c.chunkedStatement(ctx, t, req, chunks)
+		if err != nil {
+			return err
+		}
+		combined[i] = ast
+		return nil
+	})
+
+	res := &AccountStatements{}
+	for _, ast := range combined {
+		if ast != nil {
 			res.Combined = append(res.Combined, ast)
 		}
 	}
-	return &res, nil
+	return res, err
 }
 
 func (c *client) Balance(ctx context.Context, account, currency string) (*AccountBalance, error) {
-	err := c.Authenticate(ctx)
-	if err != nil {
-		return nil, err
+	return c.balance(ctx, account, currency, false)
+}
+
+// balance returns the balance of the account, from the cache unless noCache is set
+func (c *client) balance(ctx context.Context, account, currency string, noCache bool) (*AccountBalance, error) {
+	key := balanceKey(account, currency)
+	var balance AccountBalance
+	if !noCache && c.cache.get(ctx, key, &balance) {
+		return &balance, nil
 	}
 
 	path := fmt.Sprintf("/api/accounts/%s/%s", account, currency)
-	var balance AccountBalance
-	hdr, status, err := c.httpClient.Get(ctx, path, &balance)
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &balance)
 	if err != nil {
 		logger.ContextKV(ctx, xlog.ERROR,
 			"account", account,
@@ -251,25 +597,186 @@ func (c *client) Balance(ctx context.Context, account, currency string) (*Accoun
 			"header", hdr,
 			"err", err.Error(),
 		)
-		return nil, errors.WithMessagef(err, "failed to get balance: %s %s", account, currency)
+		return nil, errors.WithMessagef(withAccount(err, account, currency),
+			"failed to get balance: %s %s", account, currency)
 	}
+	c.cache.put(ctx, key, false, &balance)
 	return &balance, err
 }
 
-func (c *client) AllBalances(ctx context.Context) (map[string]*AccountBalance, error) {
+// BalanceRequest specifies accounts to fetch balances or today's activities for
+type BalanceRequest struct {
+	// Account to filter by, empty for all
+	Account string
+	// Currency to filter by, empty for all
+	Currency string
+	// Workers specifies the number of accounts fetched concurrently,
+	// DefaultWorkers is used if not set
+	Workers int
+	// NoCache fetches balances from the API even if they are cached,
+	// and replaces the cached ones
+	NoCache bool
+}
+
+// AllBalances returns balances for all configured accounts and currencies, keyed by "account currency".
+// If some of them fail, balances for the others are returned along with FetchError.
+func (c *client) AllBalances(ctx context.Context, req *BalanceRequest) (map[string]*AccountBalance, error) {
+	if req == nil {
+		req = &BalanceRequest{}
+	}
+
+	// authenticate once, before fetching accounts concurrently
+	err := c.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := c.targets(req.Account, req.Currency)
+	balances := make([]*AccountBalance, len(targets))
+
+	err = forEach(ctx, targets, req.Workers, func(ctx context.Context, i int, t target) error {
+		balance, err := c.balance(ctx, t.Account, t.Currency, req.NoCache)
+		if err != nil {
+			return err
+		}
+		balances[i] = balance
+		return nil
+	})
+
 	summary := make(map[string]*AccountBalance)
+	for i, balance := range balances {
+		if balance != nil {
+			summary[targets[i].Account+" "+targets[i].Currency] = balance
+		}
+	}
+	return summary, err
+}
+
+// TodayActivities returns today's operations of the account, which are not in statements yet
+func (c *client) TodayActivities(ctx context.Context, account, currency string) ([]Record, error) {
+	path := fmt.Sprintf("/api/documents/todayactivities/%s/%s", account, currency)
+	var records []Record
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &records)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"account", account,
+			"currency", currency,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(withAccount(err, account, currency),
+			"failed to get today activities: %s %s", account, currency)
+	}
+	return records, nil
+}
+
+// AllTodayActivities returns today's operations for all configured accounts and currencies,
+// as statements for today. If some of them fail, the others are returned along with FetchError.
+func (c *client) AllTodayActivities(ctx context.Context, req *BalanceRequest) (*AccountStatements, error) {
+	if req == nil {
+		req = &BalanceRequest{}
+	}
+
+	// authenticate once, before fetching accounts concurrently
+	err := c.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	today := NowFunc().In(Location).Format(DateFormat)
+	targets := c.targets(req.Account, req.Currency)
+	statements := make([]*AccountStatement, len(targets))
+
+	err = forEach(ctx, targets, req.Workers, func(ctx context.Context, i int, t target) error {
+		records, err := c.TodayActivities(ctx, t.Account, t.Currency)
+		if err != nil {
+			return err
+		}
+		statements[i] = &AccountStatement{
+			Account:   t.Account,
+			Currency:  t.Currency,
+			StartDate: today,
+			EndDate:   today,
+			Records:   records,
+		}
+		return nil
+	})
+
+	res := &AccountStatements{}
+	for _, st := range statements {
+		if st != nil {
+			res.Combined = append(res.Combined, st)
+		}
+	}
+	return res, err
+}
+
+// target is an account and currency pair to fetch
+type target struct {
+	Account  string
+	Currency string
+}
+
+// targets returns configured account and currency pairs matching the filter
+func (c *client) targets(account, currency string) []target {
+	var res []target
 	for _, acc := range c.cfg.Accounts {
-		for _, currency := range acc.Currency {
-			sum, err := c.Balance(ctx, acc.ID, currency)
-			if err != nil {
-				return nil, err
+		if account != "" && account != acc.ID {
+			continue
+		}
+		for _, cur := range acc.Currency {
+			if currency != "" && currency != cur {
+				continue
 			}
-			summary[acc.ID+" "+currency] = sum
+			res = append(res, target{Account: acc.ID, Currency: cur})
+		}
+	}
+	return res
+}
+
+// forEach calls fn for every target using a bounded number of workers,
+// and returns FetchError describing all failed targets
+func forEach(ctx context.Context, targets []target, workers int, fn func(ctx context.Context, i int, t target) error) error {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+
+	errs := make([]error, len(targets))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, t := range targets {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			errs[i] = fn(ctx, i, t)
+		}()
+	}
+	wg.Wait()
+
+	var failed []*AccountError
+	for i, err := range errs {
+		if err != nil {
+			failed = append(failed, &AccountError{
+				Account:  targets[i].Account,
+				Currency: targets[i].Currency,
+				Err:      err,
+			})
 		}
 	}
-	return summary, nil
+	if len(failed) > 0 {
+		return &FetchError{Errors: failed}
+	}
+	return nil
 }
 
+// MonthRange returns the first and the last dates of the month in the current year.
+//
+// Deprecated: the range may be in the future, use RecentMonth or ParsePeriod instead.
 func MonthRange(month int) (string, string) {
 	now := NowFunc()
 	start := time.Date(now.Year(), time.Month(month), 1, 0, 0, 0, 0, time.UTC)
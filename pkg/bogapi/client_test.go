@@ -0,0 +1,379 @@
+package bogapi_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/effective-security/porto/pkg/retriable"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+	"gopkg.in/yaml.v3"
+)
+
+func newTestServer(t *testing.T) *bogtest.Server {
+	srv := bogtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	require.NoError(t, srv.LoadStatements("testdata/statement_feb.json"))
+	require.NoError(t, srv.LoadBalance("GE12BG0000000106360002", "USD", "testdata/account_balance.json"))
+	return srv
+}
+
+func newTestClient(t *testing.T, srv *bogtest.Server) bogapi.Client {
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, srv.WriteConfig(cfgFile))
+
+	client, err := bogapi.CreateClient(cfgFile, 6)
+	require.NoError(t, err)
+	return client
+}
+
+// newTestClientWithConfig returns a client for the modified configuration of the test server
+func newTestClientWithConfig(t *testing.T, cfg *bogapi.Config) bogapi.Client {
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+
+	client, err := bogapi.CreateClient(cfgFile, 6)
+	require.NoError(t, err)
+	return client
+}
+
+func TestClient_Authenticate(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	require.NoError(t, client.Authenticate(ctx))
+	require.NoError(t, client.Authenticate(ctx))
+	assert.Equal(t, 1, srv.Requests(bogtest.AuthPath))
+
+	srv.Fail(bogtest.AuthPath, http.StatusInternalServerError, 1)
+	client = newTestClient(t, srv)
+	err := client.Authenticate(ctx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "authentication failed")
+}
+
+func TestClient_InvalidCredentials(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+	srv.ClientSecret = "rotated"
+
+	err := client.Authenticate(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+}
+
+func TestClient_AllStatements(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+	require.Len(t, client.Accounts(), 2)
+
+	ctx := context.Background()
+	res, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+		Summary:   true,
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 6)
+
+	count := 0
+	for _, st := range res.Combined {
+		count += len(st.Records)
+		require.NotNil(t, st.Summary)
+		assert.Equal(t, st.Account, st.Summary.GlobalSummary.AccountNumber)
+	}
+	assert.Equal(t, 8, count)
+
+	res, err = client.AllStatements(ctx, &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "EUR",
+		StartDate: "2025-02-19",
+		EndDate:   "2025-02-28",
+	})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 1)
+	assert.Len(t, res.Combined[0].Records, 1)
+	assert.Nil(t, res.Combined[0].Summary)
+
+	srv.Fail("/api/statement/", http.StatusInternalServerError, 1)
+	_, err = client.AllStatements(ctx, &bogapi.StatementRequest{
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+	})
+	require.Error(t, err)
+}
+
+func TestClient_Balance(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	res, err := client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "23083.33", res.AvailableBalance.String())
+
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "GEL")
+	require.Error(t, err)
+}
+
+func TestClient_TodayActivities(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.AddRecords("GE12BG0000000106360002", "USD", bogapi.Record{
+		EntryDate:           bogapi.Time(time.Now().UTC()),
+		EntryDocumentNumber: "INCOMING1",
+		EntryAmountCredit:   bogapi.MoneyFromFloat(1500),
+		EntryAmount:         bogapi.MoneyFromFloat(1500),
+		SenderDetails:       bogapi.SenderDetails{Name: "ACME Corp"},
+		DocumentNomination:  "Invoice 42",
+	})
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	records, err := client.TodayActivities(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "INCOMING1", records[0].EntryDocumentNumber)
+
+	// February records are booked, and not returned
+	records, err = client.TodayActivities(ctx, "GE12BG0000000106360001", "GEL")
+	require.NoError(t, err)
+	assert.Empty(t, records)
+
+	_, err = client.TodayActivities(ctx, "GE12BG0000000106360001", "CHF")
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotFound(err))
+
+	res, err := client.AllTodayActivities(ctx, nil)
+	require.NoError(t, err)
+	assert.Len(t, res.Combined, 6)
+
+	res, err = client.AllTodayActivities(ctx, &bogapi.BalanceRequest{Currency: "USD"})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 2)
+	// today is in the time zone of the bank
+	assert.Equal(t, time.Now().In(bogapi.Location).Format(bogapi.DateFormat), res.Combined[0].StartDate)
+}
+
+func TestClient_TodayActivitiesClock(t *testing.T) {
+	// late evening in UTC is already the next day in Tbilisi
+	bogapi.NowFunc = func() time.Time {
+		return time.Date(2025, 2, 17, 21, 0, 0, 0, time.UTC)
+	}
+	defer func() {
+		bogapi.NowFunc = time.Now
+	}()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+
+	records, err := client.TodayActivities(context.Background(), "GE12BG0000000106360002", "EUR")
+	require.NoError(t, err)
+	require.NotEmpty(t, records)
+	for _, rec := range records {
+		assert.Equal(t, "2025-02-18", time.Time(rec.EntryDate).Format(bogapi.DateFormat))
+	}
+}
+
+func TestClient_ExpiredToken(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SetTokenTTL(time.Second)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	require.NoError(t, client.Authenticate(ctx))
+	time.Sleep(1100 * time.Millisecond)
+
+	_, err := client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 2, srv.Requests(bogtest.AuthPath))
+}
+
+func TestClient_StatementPages(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SetPageSize(1)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	req := &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "EUR",
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+	}
+	res, err := client.Statement(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Count)
+	assert.Equal(t, 3, res.Pages)
+	assert.Len(t, res.Records, 3)
+
+	var pages []int
+	err = client.StreamStatement(ctx, req, func(page *bogapi.StatementPage) error {
+		assert.Equal(t, 3, page.Count)
+		assert.Len(t, page.Records, 1)
+		pages = append(pages, page.Page)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{1, 2, 3}, pages)
+
+	all, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+	})
+	require.NoError(t, err)
+	for _, st := range all.Combined {
+		assert.Equal(t, max(len(st.Records), 1), st.Pages, "%s %s", st.Account, st.Currency)
+	}
+
+	srv.Fail("/api/statement/GE12BG0000000106360002/EUR/", http.StatusInternalServerError, 1)
+	_, err = client.Statement(ctx, req)
+	require.Error(t, err)
+
+	pages = nil
+	err = client.StreamStatement(ctx, req, func(page *bogapi.StatementPage) error {
+		pages = append(pages, page.Page)
+		if page.Page == 2 {
+			return assert.AnError
+		}
+		return nil
+	})
+	assert.Equal(t, assert.AnError, err)
+	assert.Equal(t, []int{1, 2}, pages)
+}
+
+func TestClient_PartialFailure(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SetLatency(10 * time.Millisecond)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	srv.Fail("/api/statement/GE12BG0000000106360001/EUR/", http.StatusInternalServerError, 1)
+	srv.Fail("/api/statement/GE12BG0000000106360002/USD/", http.StatusBadGateway, 1)
+
+	res, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+		Workers:   2,
+	})
+	require.Error(t, err)
+	require.NotNil(t, res)
+	assert.Len(t, res.Combined, 4)
+
+	var ferr *bogapi.FetchError
+	require.ErrorAs(t, err, &ferr)
+	require.Len(t, ferr.Errors, 2)
+	assert.Equal(t, "GE12BG0000000106360001", ferr.Errors[0].Account)
+	assert.Equal(t, "EUR", ferr.Errors[0].Currency)
+	assert.Equal(t, "GE12BG0000000106360002", ferr.Errors[1].Account)
+	assert.Equal(t, "USD", ferr.Errors[1].Currency)
+	assert.Contains(t, err.Error(), "failed to fetch 2 accounts")
+
+	balances, err := client.AllBalances(ctx, nil)
+	require.ErrorAs(t, err, &ferr)
+	assert.Len(t, ferr.Errors, 5)
+	require.Len(t, balances, 1)
+	assert.Equal(t, "23083.33", balances["GE12BG0000000106360002 USD"].CurrentBalance.String())
+
+	balances, err = client.AllBalances(ctx, &bogapi.BalanceRequest{
+		Account:  "GE12BG0000000106360002",
+		Currency: "USD",
+	})
+	require.NoError(t, err)
+	assert.Len(t, balances, 1)
+}
+
+func TestClient_RevokedToken(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	_, err := client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+
+	srv.RevokeTokens()
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, 2, srv.Requests(bogtest.AuthPath))
+	assert.Equal(t, 3, srv.Requests("/api/accounts/"))
+
+	// the retry is attempted only once
+	srv.Fail("/api/accounts/", http.StatusUnauthorized, 2)
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.Error(t, err)
+	assert.Equal(t, 3, srv.Requests(bogtest.AuthPath))
+}
+
+func TestClient_TokenRefreshSkew(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SetTokenTTL(4 * time.Second)
+
+	cfg := srv.Config()
+	cfg.TokenRefreshSkew = 3
+	httpClient, err := retriable.Default(cfg.ApiHost)
+	require.NoError(t, err)
+	client := bogapi.NewClient(cfg, httpClient)
+
+	ctx := context.Background()
+	require.NoError(t, client.Authenticate(ctx))
+	require.NoError(t, client.Authenticate(ctx))
+	assert.Equal(t, 1, srv.Requests(bogtest.AuthPath))
+
+	// the skew is capped to the half of the token lifetime
+	time.Sleep(2100 * time.Millisecond)
+	require.NoError(t, client.Authenticate(ctx))
+	assert.Equal(t, 2, srv.Requests(bogtest.AuthPath))
+}
+
+func TestClient_Concurrent(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i == 10 {
+				srv.RevokeTokens()
+			}
+			_, err := client.Balance(ctx, "GE12BG0000000106360002", "USD")
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+	assert.LessOrEqual(t, srv.Requests(bogtest.AuthPath), 3)
+}
@@ -1,6 +1,17 @@
 package bogapi
 
-import "github.com/effective-security/x/configloader"
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/effective-security/x/configloader"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/secrets"
+)
+
+// DefaultProfile is the name of the profile defined by the top level of the configuration
+const DefaultProfile = "default"
 
 type Config struct {
 	Accounts     []Account `json:"accounts" yaml:"accounts"`
@@ -8,6 +19,32 @@ type Config struct {
 	ClientSecret string    `json:"client_secret" yaml:"client_secret"`
 	AuthURL      string    `json:"auth_url" yaml:"auth_url"`
 	ApiHost      string    `json:"api_host" yaml:"api_host"`
+	// TokenRefreshSkew specifies in seconds how long before expiration
+	// the access token is refreshed, one minute by default
+	TokenRefreshSkew int `json:"token_refresh_skew,omitempty" yaml:"token_refresh_skew,omitempty"`
+	// NBGRatesURL specifies the endpoint of the National Bank of Georgia official rates,
+	// the public NBG API is used by default
+	NBGRatesURL string `json:"nbg_rates_url,omitempty" yaml:"nbg_rates_url,omitempty"`
+	// Transport specifies proxy, TLS and timeout settings
+	Transport *TransportConfig `json:"transport,omitempty" yaml:"transport,omitempty"`
+
+	// Profiles specifies named credentials and accounts, such as for multiple legal entities.
+	// Settings not specified in a profile are inherited from the top level.
+	Profiles map[string]*Config `json:"profiles,omitempty" yaml:"profiles,omitempty"`
+	// DefaultProfile specifies the profile used when none is selected,
+	// the top level settings are used if empty
+	DefaultProfile string `json:"default_profile,omitempty" yaml:"default_profile,omitempty"`
+}
+
+// SupportedCurrencies are the currencies of BOG accounts
+var SupportedCurrencies = []string{"GEL", "USD", "EUR", "GBP", "CHF", "TRY", "AMD", "AZN", "UAH", "CNY", "JPY", "CAD", "AUD", "SEK", "NOK", "DKK", "PLN", "CZK", "ILS", "AED", "KZT"}
+
+// ValidateCurrency returns an error if the currency is not in SupportedCurrencies
+func ValidateCurrency(currency string) error {
+	if !slices.Contains(SupportedCurrencies, currency) {
+		return errors.Errorf("unsupported currency: %q", currency)
+	}
+	return nil
 }
 
 type Account struct {
@@ -28,3 +65,75 @@ func LoadConfig(file string) (*Config, error) {
 	}
 	return cfg, nil
 }
+
+// ProfileNames returns sorted names of the profiles, including DefaultProfile
+// if the top level of the configuration has credentials
+func (c *Config) ProfileNames() []string {
+	var names []string
+	if c.ClientID != "" && c.Profiles[DefaultProfile] == nil {
+		names = append(names, DefaultProfile)
+	}
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Profile returns the configuration of the named profile, with settings inherited from the top level.
+// If name is empty, DefaultProfile or the top level settings are returned.
+func (c *Config) Profile(name string) (*Config, error) {
+	if name == "" {
+		name = c.DefaultProfile
+	}
+	if name == "" || (name == DefaultProfile && c.Profiles[name] == nil) {
+		cfg := *c
+		cfg.Profiles = nil
+		return &cfg, nil
+	}
+
+	p := c.Profiles[name]
+	if p == nil {
+		return nil, errors.Errorf("profile not found: %s, available: %s", name, strings.Join(c.ProfileNames(), ", "))
+	}
+
+	cfg := *c
+	cfg.Profiles = nil
+	cfg.DefaultProfile = ""
+	if p.ClientID != "" {
+		cfg.ClientID = p.ClientID
+		cfg.ClientSecret = p.ClientSecret
+	}
+	if p.AuthURL != "" {
+		cfg.AuthURL = p.AuthURL
+	}
+	if p.ApiHost != "" {
+		cfg.ApiHost = p.ApiHost
+	}
+	if p.TokenRefreshSkew != 0 {
+		cfg.TokenRefreshSkew = p.TokenRefreshSkew
+	}
+	if p.NBGRatesURL != "" {
+		cfg.NBGRatesURL = p.NBGRatesURL
+	}
+	if p.Transport != nil {
+		cfg.Transport = p.Transport
+	}
+	// accounts are never shared between legal entities
+	cfg.Accounts = p.Accounts
+	return &cfg, nil
+}
+
+// ResolveSecrets replaces secret references in the credentials,
+// such as env:BOG_SECRET, file:/run/secrets/bog or exec:pass show bog/secret,
+// with the resolved values
+func (c *Config) ResolveSecrets() error {
+	var err error
+	if c.ClientID, err = secrets.Resolve(c.ClientID); err != nil {
+		return errors.WithMessage(err, "client_id")
+	}
+	if c.ClientSecret, err = secrets.Resolve(c.ClientSecret); err != nil {
+		return errors.WithMessage(err, "client_secret")
+	}
+	return nil
+}
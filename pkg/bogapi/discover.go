@@ -0,0 +1,281 @@
+package bogapi
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"golang.org/x/net/context"
+	"gopkg.in/yaml.v3"
+)
+
+// DiscoverCurrencies are the currencies probed by DiscoverAccounts by default
+var DiscoverCurrencies = SupportedCurrencies
+
+// DiscoverRequest specifies the accounts and currencies to probe
+type DiscoverRequest struct {
+	// Accounts specifies the account numbers to probe in addition to the configured ones
+	Accounts []string
+	// Currencies to probe, DiscoverCurrencies by default
+	Currencies []string
+	// Workers specifies the number of probes run concurrently,
+	// DefaultWorkers is used if not set
+	Workers int
+}
+
+// DiscoverAccounts returns the accounts with currency sub-accounts visible to the credentials,
+// by probing the balance of every currency. Names of the configured accounts are kept.
+// If some probes fail for reasons other than a missing sub-account, the discovered accounts
+// are returned along with FetchError.
+func (c *client) DiscoverAccounts(ctx context.Context, req *DiscoverRequest) ([]Account, error) {
+	if req == nil {
+		req = &DiscoverRequest{}
+	}
+	currencies := req.Currencies
+	if len(currencies) == 0 {
+		currencies = DiscoverCurrencies
+	}
+
+	var accounts []Account
+	for _, acc := range c.cfg.Accounts {
+		accounts = append(accounts, Account{ID: acc.ID, Name: acc.Name})
+	}
+	for _, id := range req.Accounts {
+		if !slices.ContainsFunc(accounts, func(a Account) bool { return a.ID == id }) {
+			accounts = append(accounts, Account{ID: id})
+		}
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("no accounts to probe: configure accounts or specify account numbers")
+	}
+
+	// authenticate once, before probing accounts concurrently
+	err := c.Authenticate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []target
+	for _, acc := range accounts {
+		for _, cur := range currencies {
+			targets = append(targets, target{Account: acc.ID, Currency: cur})
+		}
+	}
+	found := make([]bool, len(targets))
+
+	err = forEach(ctx, targets, req.Workers, func(ctx context.Context, i int, t target) error {
+		ok, err := c.probe(ctx, t.Account, t.Currency)
+		found[i] = ok
+		return err
+	})
+
+	var res []Account
+	for _, acc := range accounts {
+		for i, t := range targets {
+			if found[i] && t.Account == acc.ID {
+				acc.Currency = append(acc.Currency, t.Currency)
+			}
+		}
+		if len(acc.Currency) > 0 {
+			res = append(res, acc)
+		}
+	}
+	return res, err
+}
+
+// probe returns true if the currency sub-account exists.
+// Missing sub-accounts are expected, so they are not logged as errors like in Balance.
+func (c *client) probe(ctx context.Context, account, currency string) (bool, error) {
+	path := fmt.Sprintf("/api/accounts/%s/%s", account, currency)
+	var balance AccountBalance
+	_, status, err := c.call(ctx, http.MethodGet, path, nil, &balance)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.DEBUG,
+			"account", account,
+			"currency", currency,
+			"status", status,
+			"err", err.Error(),
+		)
+		if IsNotFound(err) {
+			return false, nil
+		}
+		return false, errors.WithMessagef(withAccount(err, account, currency),
+			"failed to probe account: %s %s", account, currency)
+	}
+	return true, nil
+}
+
+// AccountChange describes currencies added or removed for the account
+type AccountChange struct {
+	Account string   `json:"account" yaml:"account"`
+	Name    string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Added   []string `json:"added,omitempty" yaml:"added,omitempty"`
+	Removed []string `json:"removed,omitempty" yaml:"removed,omitempty"`
+}
+
+// DiffAccounts returns the changes from the configured to the discovered accounts,
+// accounts without changes are not included
+func DiffAccounts(configured, discovered []Account) []*AccountChange {
+	find := func(list []Account, id string) *Account {
+		for i := range list {
+			if list[i].ID == id {
+				return &list[i]
+			}
+		}
+		return nil
+	}
+
+	var res []*AccountChange
+	add := func(acc *Account, old, cur []string) {
+		ch := &AccountChange{Account: acc.ID, Name: acc.Name}
+		for _, c := range cur {
+			if !slices.Contains(old, c) {
+				ch.Added = append(ch.Added, c)
+			}
+		}
+		for _, c := range old {
+			if !slices.Contains(cur, c) {
+				ch.Removed = append(ch.Removed, c)
+			}
+		}
+		if len(ch.Added) > 0 || len(ch.Removed) > 0 {
+			res = append(res, ch)
+		}
+	}
+
+	for i := range configured {
+		acc := &configured[i]
+		var cur []string
+		if d := find(discovered, acc.ID); d != nil {
+			cur = d.Currency
+		}
+		add(acc, acc.Currency, cur)
+	}
+	for i := range discovered {
+		acc := &discovered[i]
+		if find(configured, acc.ID) == nil {
+			add(acc, nil, acc.Currency)
+		}
+	}
+	return res
+}
+
+// MergeAccounts returns the configured accounts updated with the discovered ones.
+// Only the probed currencies are added or removed, other configured currencies are kept.
+// Accounts without currencies left are removed, and new accounts are appended.
+func MergeAccounts(configured, discovered []Account, probed []string) []Account {
+	find := func(list []Account, id string) *Account {
+		for i := range list {
+			if list[i].ID == id {
+				return &list[i]
+			}
+		}
+		return nil
+	}
+
+	var res []Account
+	for _, acc := range configured {
+		var found []string
+		if d := find(discovered, acc.ID); d != nil {
+			found = d.Currency
+		}
+		merged := Account{ID: acc.ID, Name: acc.Name}
+		for _, c := range acc.Currency {
+			if !slices.Contains(probed, c) || slices.Contains(found, c) {
+				merged.Currency = append(merged.Currency, c)
+			}
+		}
+		for _, c := range found {
+			if !slices.Contains(merged.Currency, c) {
+				merged.Currency = append(merged.Currency, c)
+			}
+		}
+		if len(merged.Currency) > 0 {
+			res = append(res, merged)
+		}
+	}
+	for _, acc := range discovered {
+		if find(configured, acc.ID) == nil {
+			res = append(res, acc)
+		}
+	}
+	return res
+}
+
+// UpdateConfigAccounts replaces the accounts of the profile in the configuration file,
+// keeping the rest of the file, including comments and environment references, as is.
+// The top level accounts are replaced if profile is empty or DefaultProfile
+// is not defined in profiles.
+func UpdateConfigAccounts(file, profile string, accounts []Account) error {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return errors.WithMessage(err, "failed to read file")
+	}
+
+	var doc yaml.Node
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return errors.WithMessage(err, "failed to parse config")
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return errors.Errorf("invalid config: %s", file)
+	}
+
+	root := doc.Content[0]
+	if profile == "" {
+		profile = mappingValue(root, "default_profile").Value
+	}
+	if profile != "" {
+		node := mappingValue(mappingValue(root, "profiles"), profile)
+		if node.Kind == yaml.MappingNode {
+			root = node
+		} else if profile != DefaultProfile {
+			return errors.Errorf("profile not found: %s", profile)
+		}
+	}
+
+	var value yaml.Node
+	if err = value.Encode(accounts); err != nil {
+		return errors.WithMessage(err, "failed to encode accounts")
+	}
+	setMappingValue(root, "accounts", &value)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err = enc.Encode(&doc); err != nil {
+		return errors.WithMessage(err, "failed to encode config")
+	}
+	if err = os.WriteFile(file, buf.Bytes(), 0600); err != nil {
+		return errors.WithMessage(err, "failed to write file")
+	}
+	return nil
+}
+
+// mappingValue returns the value of the key in the mapping node, or an empty node if not found
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node != nil && node.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return node.Content[i+1]
+			}
+		}
+	}
+	return &yaml.Node{}
+}
+
+// setMappingValue replaces or appends the value of the key in the mapping node
+func setMappingValue(node *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			node.Content[i+1] = value
+			return
+		}
+	}
+	node.Content = append(node.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value)
+}
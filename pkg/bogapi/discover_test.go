@@ -0,0 +1,112 @@
+package bogapi_test
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestDiscoverAccounts(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetBalance("GE12BG0000000106360001", "GEL", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(10)})
+	srv.SetBalance("GE12BG0000000106360001", "CHF", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(20)})
+	srv.SetBalance("GE35BG0000000106360001", "GEL", &bogapi.AccountBalance{AvailableBalance: bogapi.MoneyFromFloat(30)})
+	client := newTestClient(t, srv)
+
+	ctx := context.Background()
+	res, err := client.DiscoverAccounts(ctx, &bogapi.DiscoverRequest{
+		Accounts: []string{"GE35BG0000000106360001", "GE12BG0000000106360002"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Currency: []string{"GEL", "CHF"}},
+		{ID: "GE12BG0000000106360002", Currency: []string{"USD"}},
+		{ID: "GE35BG0000000106360001", Currency: []string{"GEL"}},
+	}, res)
+
+	res, err = client.DiscoverAccounts(ctx, &bogapi.DiscoverRequest{Currencies: []string{"USD"}})
+	require.NoError(t, err)
+	assert.Equal(t, []bogapi.Account{
+		{ID: "GE12BG0000000106360002", Currency: []string{"USD"}},
+	}, res)
+}
+
+func TestDiscoverAccounts_BadRequest(t *testing.T) {
+	srv := newTestServer(t)
+	srv.Fail("/api/accounts/GE12BG0000000106360002/USD", http.StatusBadRequest, 1)
+	client := newTestClient(t, srv)
+
+	// only a missing sub-account is absent, a rejected probe is an error
+	res, err := client.DiscoverAccounts(context.Background(), &bogapi.DiscoverRequest{Currencies: []string{"USD"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to probe account: GE12BG0000000106360002 USD")
+	assert.Empty(t, res)
+}
+
+func TestMergeAccounts(t *testing.T) {
+	configured := []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Name: "Primary", Currency: []string{"USD", "EUR", "GEL", "XYZ"}},
+		{ID: "GE12BG0000000106360002", Name: "Card", Currency: []string{"USD"}},
+		{ID: "GE12BG0000000106360003", Name: "Closed", Currency: []string{"GEL"}},
+	}
+	discovered := []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Name: "Primary", Currency: []string{"GEL", "CHF"}},
+		{ID: "GE35BG0000000106360001", Currency: []string{"GEL"}},
+	}
+	assert.Equal(t, []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Name: "Primary", Currency: []string{"USD", "GEL", "XYZ", "CHF"}},
+		{ID: "GE12BG0000000106360002", Name: "Card", Currency: []string{"USD"}},
+		{ID: "GE35BG0000000106360001", Currency: []string{"GEL"}},
+	}, bogapi.MergeAccounts(configured, discovered, []string{"GEL", "EUR", "CHF"}))
+}
+
+func TestDiffAccounts(t *testing.T) {
+	configured := []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Name: "Primary", Currency: []string{"USD", "EUR", "GEL"}},
+		{ID: "GE12BG0000000106360002", Name: "Card", Currency: []string{"USD"}},
+		{ID: "GE12BG0000000106360003", Name: "Closed", Currency: []string{"GEL"}},
+	}
+	discovered := []bogapi.Account{
+		{ID: "GE12BG0000000106360001", Name: "Primary", Currency: []string{"GEL", "CHF"}},
+		{ID: "GE12BG0000000106360002", Name: "Card", Currency: []string{"USD"}},
+		{ID: "GE35BG0000000106360001", Currency: []string{"GEL"}},
+	}
+	assert.Equal(t, []*bogapi.AccountChange{
+		{Account: "GE12BG0000000106360001", Name: "Primary", Added: []string{"CHF"}, Removed: []string{"USD", "EUR"}},
+		{Account: "GE12BG0000000106360003", Name: "Closed", Removed: []string{"GEL"}},
+		{Account: "GE35BG0000000106360001", Added: []string{"GEL"}},
+	}, bogapi.DiffAccounts(configured, discovered))
+	assert.Empty(t, bogapi.DiffAccounts(discovered, discovered))
+}
+
+func TestUpdateConfigAccounts(t *testing.T) {
+	dir := t.TempDir()
+	accounts := []bogapi.Account{{ID: "GE35BG0000000106360001", Name: "Main", Currency: []string{"GEL", "USD"}}}
+
+	data, err := os.ReadFile("testdata/config_profiles.yaml")
+	require.NoError(t, err)
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, data, 0600))
+
+	require.NoError(t, bogapi.UpdateConfigAccounts(file, "beta", accounts))
+	cfg, err := bogapi.LoadConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, accounts, cfg.Profiles["beta"].Accounts)
+	// other profiles are not changed
+	assert.Equal(t, "GE12BG0000000106360001", cfg.Accounts[0].ID)
+	assert.Equal(t, "acme-id", cfg.Profiles["acme"].ClientID)
+
+	require.NoError(t, bogapi.UpdateConfigAccounts(file, bogapi.DefaultProfile, accounts))
+	cfg, err = bogapi.LoadConfig(file)
+	require.NoError(t, err)
+	assert.Equal(t, accounts, cfg.Accounts)
+
+	err = bogapi.UpdateConfigAccounts(file, "gamma", accounts)
+	assert.EqualError(t, err, "profile not found: gamma")
+}
@@ -0,0 +1,190 @@
+package bogapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/effective-security/x/slices"
+	"github.com/effective-security/x/values"
+	"github.com/pkg/errors"
+)
+
+// AccountError describes a failure for a single account and currency
+type AccountError struct {
+	Account  string
+	Currency string
+	Err      error
+}
+
+func (e *AccountError) Error() string {
+	return e.Account + " " + e.Currency + ": " + e.Err.Error()
+}
+
+func (e *AccountError) Unwrap() error {
+	return e.Err
+}
+
+// FetchError is returned when fetching data failed for some of the accounts,
+// the results for the other accounts are still returned along with the error
+type FetchError struct {
+	Errors []*AccountError
+}
+
+func (e *FetchError) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return "failed to fetch " + plural(len(e.Errors), "account") + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *FetchError) Unwrap() []error {
+	errs := make([]error, len(e.Errors))
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+	return errs
+}
+
+func plural(n int, noun string) string {
+	if n == 1 {
+		return "1 " + noun
+	}
+	return strconv.Itoa(n) + " " + noun + "s"
+}
+
+// APIError is returned when BOG API responds with an error status
+type APIError struct {
+	// StatusCode is the HTTP status code of the response
+	StatusCode int `json:"status"`
+	// Code is the error code returned by BOG, if any
+	Code string `json:"code,omitempty"`
+	// Message is the error message returned by BOG, or the response body
+	Message string `json:"message,omitempty"`
+	// Method and Endpoint identify the failed request
+	Method   string `json:"method,omitempty"`
+	Endpoint string `json:"endpoint,omitempty"`
+	// Account and Currency are set for account specific requests
+	Account  string `json:"account,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	// RetryAfter is parsed from the Retry-After header of rate limited responses
+	RetryAfter time.Duration `json:"retry_after,omitempty"`
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode))
+	if e.Endpoint != "" {
+		msg = strings.TrimSpace(e.Method+" "+e.Endpoint) + ": " + msg
+	}
+	if e.Code != "" {
+		msg += ": " + e.Code
+	}
+	if e.Message != "" {
+		msg += ": " + e.Message
+	}
+	return msg
+}
+
+// newAPIError creates APIError from the response, parsing the error code and message from the body
+func newAPIError(method, endpoint string, status int, hdr http.Header, body []byte) *APIError {
+	e := &APIError{
+		StatusCode: status,
+		Method:     method,
+		Endpoint:   endpoint,
+	}
+
+	// BOG services are not consistent in the error format
+	var res struct {
+		Code             any    `json:"code"`
+		ErrorCode        any    `json:"ErrorCode"`
+		Error            string `json:"error"`
+		Message          string `json:"message"`
+		ErrorMessage     string `json:"ErrorMessage"`
+		ErrorDescription string `json:"error_description"`
+		Title            string `json:"title"`
+		Detail           string `json:"detail"`
+	}
+	body = bytes.TrimSpace(body)
+	if json.Unmarshal(body, &res) == nil {
+		e.Code = values.StringsCoalesce(codeString(res.Code), codeString(res.ErrorCode), res.Error)
+		e.Message = values.StringsCoalesce(res.Message, res.ErrorMessage, res.ErrorDescription, res.Detail, res.Title)
+	} else if len(body) > 0 && body[0] != '<' {
+		// plain text, but not HTML error pages
+		e.Message = slices.StringUpto(string(body), 256)
+	}
+
+	if sec, err := strconv.Atoi(hdr.Get("Retry-After")); err == nil && sec > 0 {
+		e.RetryAfter = time.Duration(sec) * time.Second
+	}
+	return e
+}
+
+func codeString(v any) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	}
+	return ""
+}
+
+// withAccount sets the account and currency on APIError
+func withAccount(err error, account, currency string) error {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		apiErr.Account = account
+		apiErr.Currency = currency
+	}
+	return err
+}
+
+// StatusCode returns the HTTP status of APIError, or 0 for other errors
+func StatusCode(err error) int {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+// IsNotFound returns true if the account, statement or resource was not found
+func IsNotFound(err error) bool {
+	return StatusCode(err) == http.StatusNotFound
+}
+
+// IsUnauthorized returns true if the credentials or the access token were rejected
+func IsUnauthorized(err error) bool {
+	return StatusCode(err) == http.StatusUnauthorized
+}
+
+// IsForbidden returns true if the credentials are not permitted to access the resource
+func IsForbidden(err error) bool {
+	return StatusCode(err) == http.StatusForbidden
+}
+
+// IsBadRequest returns true if the request was rejected as invalid
+func IsBadRequest(err error) bool {
+	return StatusCode(err) == http.StatusBadRequest
+}
+
+// IsConflict returns true if the document can not be processed in its current state,
+// such as a canceled payment or a changed exchange rate
+func IsConflict(err error) bool {
+	return StatusCode(err) == http.StatusConflict
+}
+
+// IsRateLimited returns true if the request was rejected due to rate limits
+func IsRateLimited(err error) bool {
+	return StatusCode(err) == http.StatusTooManyRequests
+}
+
+// IsUnavailable returns true if BOG service failed or is unavailable
+func IsUnavailable(err error) bool {
+	return StatusCode(err) >= http.StatusInternalServerError
+}
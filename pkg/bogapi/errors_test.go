@@ -0,0 +1,77 @@
+package bogapi_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+)
+
+func TestAPIError(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	_, err := client.Balance(ctx, "GE12BG0000000106360002", "GEL")
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotFound(err))
+	assert.False(t, bogapi.IsUnauthorized(err))
+
+	var apiErr *bogapi.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusNotFound, apiErr.StatusCode)
+	assert.Equal(t, "AccountNotFound", apiErr.Code)
+	assert.Equal(t, "account not found: GE12BG0000000106360002 GEL", apiErr.Message)
+	assert.Equal(t, "/api/accounts/GE12BG0000000106360002/GEL", apiErr.Endpoint)
+	assert.Equal(t, "GE12BG0000000106360002", apiErr.Account)
+	assert.Equal(t, "GEL", apiErr.Currency)
+	assert.Equal(t,
+		"failed to get balance: GE12BG0000000106360002 GEL: GET /api/accounts/GE12BG0000000106360002/GEL: 404 Not Found: AccountNotFound: account not found: GE12BG0000000106360002 GEL",
+		err.Error())
+
+	srv.Fail("/api/statement/", http.StatusTooManyRequests, 1)
+	_, err = client.Statement(ctx, &bogapi.StatementRequest{
+		Account:   "GE12BG0000000106360002",
+		Currency:  "GEL",
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+	})
+	assert.True(t, bogapi.IsRateLimited(err))
+
+	srv.Fail("/api/statement/summary/", http.StatusBadGateway, 1)
+	_, err = client.StatementSummary(ctx, "GE12BG0000000106360002", "GEL", 1)
+	assert.True(t, bogapi.IsUnavailable(err))
+
+	_, err = client.StatementSummary(ctx, "GE12BG0000000106360002", "GEL", 1)
+	assert.True(t, bogapi.IsNotFound(err))
+
+	srv.ClientSecret = "rotated"
+	srv.RevokeTokens()
+	_, err = client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	assert.True(t, bogapi.IsUnauthorized(err))
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, srv.URL+bogtest.AuthPath, apiErr.Endpoint)
+	assert.Equal(t, "invalid_client", apiErr.Code)
+
+	assert.Equal(t, 0, bogapi.StatusCode(errors.New("network")))
+	assert.False(t, bogapi.IsNotFound(nil))
+}
+
+func TestFetchError(t *testing.T) {
+	t.Parallel()
+
+	err := &bogapi.FetchError{
+		Errors: []*bogapi.AccountError{
+			{Account: "GE1", Currency: "GEL", Err: &bogapi.APIError{StatusCode: http.StatusNotFound}},
+		},
+	}
+	assert.Equal(t, "failed to fetch 1 account: GE1 GEL: 404 Not Found", err.Error())
+	assert.True(t, bogapi.IsNotFound(err))
+}
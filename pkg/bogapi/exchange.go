@@ -0,0 +1,191 @@
+package bogapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+)
+
+// ExchangeNomination is the default nomination of the conversion document
+const ExchangeNomination = "Conversion"
+
+// CommercialRate is the rate at which the bank buys and sells the currency for GEL
+type CommercialRate struct {
+	Currency string  `json:"Currency" yaml:"currency"`
+	Buy      float64 `json:"Buy" yaml:"buy"`
+	Sell     float64 `json:"Sell" yaml:"sell"`
+}
+
+// RateDecimals is the number of decimals of the conversion rates quoted by the bank
+const RateDecimals = 4
+
+// CrossRate returns the amount of the destination currency,
+// which the bank pays for one unit of the source currency, rounded to RateDecimals
+func CrossRate(from, to *CommercialRate) decimal.Decimal {
+	if from.Buy <= 0 || to.Sell <= 0 {
+		return decimal.Zero
+	}
+	rate := decimal.NewFromFloat(from.Buy).Div(decimal.NewFromFloat(to.Sell))
+	return rate.Round(RateDecimals)
+}
+
+// ExchangeRequest is a conversion between two currency sub-accounts of the same account
+type ExchangeRequest struct {
+	// UniqueID identifies the document for idempotency, generated if not provided
+	UniqueID string `json:"UniqueId" yaml:"unique_id"`
+	// AccountNumber is the IBAN of the account
+	AccountNumber string `json:"AccountNumber" yaml:"account_number"`
+	// FromCurrency is the currency sold by the customer
+	FromCurrency string `json:"SourceCurrency" yaml:"from_currency"`
+	// ToCurrency is the currency bought by the customer
+	ToCurrency string `json:"DestinationCurrency" yaml:"to_currency"`
+	// Amount is the amount to sell, in FromCurrency
+	Amount Money `json:"SourceAmount" yaml:"amount"`
+	// Rate is the quoted rate, the bank rejects the document if the current rate differs.
+	// If not provided, the conversion is executed at the current rate.
+	Rate       decimal.Decimal `json:"Rate,omitzero" yaml:"rate,omitempty"`
+	Nomination string          `json:"Nomination" yaml:"nomination"`
+}
+
+// MarshalJSON writes the rate as a JSON number, as expected by the bank
+func (r ExchangeRequest) MarshalJSON() ([]byte, error) {
+	type request ExchangeRequest
+	var rate json.Number
+	if !r.Rate.IsZero() {
+		rate = json.Number(r.Rate.String())
+	}
+	return json.Marshal(&struct {
+		request
+		Rate json.Number `json:"Rate,omitempty"`
+	}{request(r), rate})
+}
+
+// ExchangeQuote is the result of the conversion at the current commercial rate
+type ExchangeQuote struct {
+	AccountNumber string `json:"AccountNumber" yaml:"account_number"`
+	FromCurrency  string `json:"FromCurrency" yaml:"from_currency"`
+	ToCurrency    string `json:"ToCurrency" yaml:"to_currency"`
+	Amount        Money  `json:"Amount" yaml:"amount"`
+	// Rate is the amount of ToCurrency for one unit of FromCurrency
+	Rate decimal.Decimal `json:"Rate" yaml:"rate"`
+	// Result is the amount credited to the ToCurrency sub-account
+	Result Money `json:"Result" yaml:"result"`
+}
+
+// Validate checks the document before sending it to the bank
+func (r *ExchangeRequest) Validate() error {
+	if r.AccountNumber == "" {
+		return errors.New("account is required")
+	}
+	if len(r.FromCurrency) != 3 {
+		return errors.Errorf("invalid source currency: %s", r.FromCurrency)
+	}
+	if len(r.ToCurrency) != 3 {
+		return errors.Errorf("invalid destination currency: %s", r.ToCurrency)
+	}
+	if r.FromCurrency == r.ToCurrency {
+		return errors.New("source and destination currencies must be different")
+	}
+	if r.Rate.Sign() < 0 {
+		return errors.Errorf("invalid rate: %s", r.Rate)
+	}
+	return validateAmount(r.Amount.In(r.FromCurrency))
+}
+
+// ExchangeAmount returns the amount credited for the conversion at the rate,
+// rounded down to the decimals of the currency
+func ExchangeAmount(amount Money, rate decimal.Decimal, currency string) Money {
+	return NewMoney(amount.Decimal().Mul(rate), currency).Truncate()
+}
+
+// CommercialRate returns the current commercial rate of the currency
+func (c *client) CommercialRate(ctx context.Context, currency string) (*CommercialRate, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "GEL" {
+		return &CommercialRate{Currency: currency, Buy: 1, Sell: 1}, nil
+	}
+
+	var res CommercialRate
+	hdr, status, err := c.call(ctx, http.MethodGet, "/api/rates/commercial/"+currency, nil, &res)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"currency", currency,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(err, "failed to get commercial rate: %s", currency)
+	}
+	res.Currency = currency
+	return &res, nil
+}
+
+// QuoteExchange returns the conversion at the current commercial rate
+func (c *client) QuoteExchange(ctx context.Context, req *ExchangeRequest) (*ExchangeQuote, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	from, err := c.CommercialRate(ctx, req.FromCurrency)
+	if err != nil {
+		return nil, err
+	}
+	to, err := c.CommercialRate(ctx, req.ToCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	rate := CrossRate(from, to)
+	if rate.IsZero() {
+		return nil, errors.Errorf("rate is not available: %s/%s", req.FromCurrency, req.ToCurrency)
+	}
+	return &ExchangeQuote{
+		AccountNumber: req.AccountNumber,
+		FromCurrency:  req.FromCurrency,
+		ToCurrency:    req.ToCurrency,
+		Amount:        req.Amount.In(req.FromCurrency),
+		Rate:          rate,
+		Result:        ExchangeAmount(req.Amount, rate, req.ToCurrency),
+	}, nil
+}
+
+// Exchange validates and submits the conversion document
+func (c *client) Exchange(ctx context.Context, req *ExchangeRequest) (*PaymentResponse, error) {
+	if req.UniqueID == "" {
+		req.UniqueID = newUniqueID()
+	}
+	if req.Nomination == "" {
+		req.Nomination = ExchangeNomination
+	}
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	var res PaymentResponse
+	hdr, status, err := c.call(ctx, http.MethodPost, "/api/documents/currency-exchange", req, &res)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"account", req.AccountNumber,
+			"from", req.FromCurrency,
+			"to", req.ToCurrency,
+			"unique_id", req.UniqueID,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(withAccount(err, req.AccountNumber, ""),
+			"failed to exchange %s to %s: %s", req.FromCurrency, req.ToCurrency, req.UniqueID)
+	}
+
+	logger.ContextKV(ctx, xlog.INFO,
+		"status", "exchange_created",
+		"unique_id", res.UniqueID,
+		"unique_key", res.UniqueKey,
+	)
+	return &res, nil
+}
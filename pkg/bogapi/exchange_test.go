@@ -0,0 +1,161 @@
+package bogapi_test
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestCrossRate(t *testing.T) {
+	gel := &bogapi.CommercialRate{Currency: "GEL", Buy: 1, Sell: 1}
+	usd := &bogapi.CommercialRate{Currency: "USD", Buy: 2.70, Sell: 2.75}
+	eur := &bogapi.CommercialRate{Currency: "EUR", Buy: 2.90, Sell: 3.00}
+
+	assert.Equal(t, "2.7", bogapi.CrossRate(usd, gel).String())
+	assert.Equal(t, "0.3636", bogapi.CrossRate(gel, usd).String())
+	assert.Equal(t, "0.9", bogapi.CrossRate(usd, eur).String())
+	assert.True(t, bogapi.CrossRate(usd, &bogapi.CommercialRate{}).IsZero())
+
+	hundred := bogapi.MoneyFromInt(100)
+	assert.Equal(t, "270.00", bogapi.ExchangeAmount(hundred, bogapi.CrossRate(usd, gel), "GEL").Format())
+	assert.Equal(t, "36.36", bogapi.ExchangeAmount(hundred, bogapi.CrossRate(gel, usd), "USD").String())
+	assert.Equal(t, "USD", bogapi.ExchangeAmount(hundred, bogapi.CrossRate(gel, usd), "USD").Currency())
+}
+
+func TestExchangeRequest_MarshalJSON(t *testing.T) {
+	req := &bogapi.ExchangeRequest{
+		AccountNumber: "GE12BG0000000106360002",
+		FromCurrency:  "GEL",
+		ToCurrency:    "USD",
+		Amount:        bogapi.MoneyFromInt(100),
+		Rate:          decimal.RequireFromString("0.3636"),
+	}
+	data, err := json.Marshal(req)
+	require.NoError(t, err)
+	// the rate is a number, as expected by the bank
+	assert.Contains(t, string(data), `"Rate":0.3636`)
+	assert.Contains(t, string(data), `"SourceAmount":100`)
+
+	var decoded bogapi.ExchangeRequest
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.True(t, req.Rate.Equal(decoded.Rate))
+
+	req.Rate = decimal.Zero
+	data, err = json.Marshal(req)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), `"Rate"`)
+}
+
+func TestExchangeRequest_Validate(t *testing.T) {
+	valid := func() *bogapi.ExchangeRequest {
+		return &bogapi.ExchangeRequest{
+			AccountNumber: "GE12BG0000000106360002",
+			FromCurrency:  "USD",
+			ToCurrency:    "EUR",
+			Amount:        bogapi.MoneyFromInt(100),
+		}
+	}
+	require.NoError(t, valid().Validate())
+
+	tcases := []struct {
+		name   string
+		modify func(r *bogapi.ExchangeRequest)
+		err    string
+	}{
+		{"account", func(r *bogapi.ExchangeRequest) { r.AccountNumber = "" }, "account is required"},
+		{"from", func(r *bogapi.ExchangeRequest) { r.FromCurrency = "US" }, "invalid source currency: US"},
+		{"to", func(r *bogapi.ExchangeRequest) { r.ToCurrency = "" }, "invalid destination currency: "},
+		{"same", func(r *bogapi.ExchangeRequest) { r.ToCurrency = "USD" }, "source and destination currencies must be different"},
+		{"amount", func(r *bogapi.ExchangeRequest) { r.Amount = bogapi.Money{} }, "invalid amount: 0"},
+		{"decimals", func(r *bogapi.ExchangeRequest) { r.Amount = bogapi.MoneyFromFloat(1.005) }, "at most 2 decimal places"},
+		{"rate", func(r *bogapi.ExchangeRequest) { r.Rate = decimal.NewFromInt(-1) }, "invalid rate: -1"},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := valid()
+			tc.modify(r)
+			err := r.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tc.err)
+		})
+	}
+}
+
+func TestClient_Exchange(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SetRate("USD", 2.70, 2.75)
+	srv.SetRate("EUR", 2.90, 3.00)
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	rate, err := client.CommercialRate(ctx, "USD")
+	require.NoError(t, err)
+	assert.Equal(t, &bogapi.CommercialRate{Currency: "USD", Buy: 2.70, Sell: 2.75}, rate)
+
+	_, err = client.CommercialRate(ctx, "CHF")
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotFound(err))
+
+	req := &bogapi.ExchangeRequest{
+		AccountNumber: "GE12BG0000000106360002",
+		FromCurrency:  "USD",
+		ToCurrency:    "EUR",
+		Amount:        bogapi.MoneyFromInt(1000),
+	}
+	quote, err := client.QuoteExchange(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, "0.9", quote.Rate.String())
+	assert.Equal(t, "900", quote.Result.String())
+	assert.Equal(t, "EUR", quote.Result.Currency())
+
+	// the rate changes after the quote
+	srv.SetRate("USD", 2.72, 2.77)
+	req.Rate = quote.Rate
+	_, err = client.Exchange(ctx, req)
+	require.Error(t, err)
+	assert.True(t, bogapi.IsConflict(err))
+	assert.Contains(t, err.Error(), "RateChanged")
+	assert.Equal(t, 0, srv.Payments())
+
+	quote, err = client.QuoteExchange(ctx, req)
+	require.NoError(t, err)
+	req.Rate = quote.Rate
+	res, err := client.Exchange(ctx, req)
+	require.NoError(t, err)
+	assert.NotZero(t, res.UniqueKey)
+	assert.Equal(t, bogapi.ExchangeNomination, req.Nomination)
+
+	doc, status := srv.Exchange(res.UniqueKey)
+	require.NotNil(t, doc)
+	assert.Equal(t, req.UniqueID, doc.UniqueID)
+	assert.Equal(t, bogapi.PaymentStatusCompleted, status.Status)
+
+	// the seeded USD balance is debited
+	balance, err := client.Balance(ctx, "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, "22083.33", balance.AvailableBalance.String())
+
+	// resubmitting the same document does not exchange again
+	again, err := client.Exchange(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, res.UniqueKey, again.UniqueKey)
+	assert.Equal(t, 1, srv.Payments())
+
+	// the account has no CHF sub-account
+	srv.SetRate("CHF", 3.00, 3.10)
+	_, err = client.Exchange(ctx, &bogapi.ExchangeRequest{
+		AccountNumber: "GE12BG0000000106360002",
+		FromCurrency:  "USD",
+		ToCurrency:    "CHF",
+		Amount:        bogapi.MoneyFromInt(10),
+	})
+	require.Error(t, err)
+	assert.True(t, bogapi.IsNotFound(err))
+}
@@ -0,0 +1,80 @@
+package bogapi
+
+import (
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// BOGBankCode is the bank code of Bank of Georgia in IBAN
+const BOGBankCode = "BG"
+
+// BOGSwiftCode is the SWIFT code of Bank of Georgia
+const BOGSwiftCode = "BAGAGE22"
+
+// ValidateIBAN checks the format and the checksum of the IBAN,
+// Georgian IBANs are also checked for the length and the bank code format
+func ValidateIBAN(iban string) error {
+	if len(iban) < 15 || len(iban) > 34 {
+		return errors.Errorf("invalid IBAN length: %s", iban)
+	}
+	for i, c := range iban {
+		switch {
+		case i < 2 && c >= 'A' && c <= 'Z':
+		case i >= 2 && i < 4 && c >= '0' && c <= '9':
+		case i >= 4 && (c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'):
+		default:
+			return errors.Errorf("invalid IBAN format: %s", iban)
+		}
+	}
+
+	if strings.HasPrefix(iban, "GE") {
+		// GEkk BBcc cccc cccc cccc cc
+		if len(iban) != 22 || !isUpper(iban[4:6]) || !isDigits(iban[6:]) {
+			return errors.Errorf("invalid Georgian IBAN format: %s", iban)
+		}
+	}
+
+	// move the country code and check digits to the end, and convert letters to numbers
+	var sb strings.Builder
+	for _, c := range iban[4:] + iban[:4] {
+		if c >= 'A' && c <= 'Z' {
+			sb.WriteString(strconv.Itoa(int(c - 'A' + 10)))
+		} else {
+			sb.WriteRune(c)
+		}
+	}
+	n, _ := new(big.Int).SetString(sb.String(), 10)
+	if new(big.Int).Mod(n, big.NewInt(97)).Int64() != 1 {
+		return errors.Errorf("invalid IBAN checksum: %s", iban)
+	}
+	return nil
+}
+
+// IBANBankCode returns the bank code of the Georgian IBAN, or empty string
+func IBANBankCode(iban string) string {
+	if len(iban) < 6 || !strings.HasPrefix(iban, "GE") {
+		return ""
+	}
+	return iban[4:6]
+}
+
+func isUpper(s string) bool {
+	for _, c := range s {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+	return true
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
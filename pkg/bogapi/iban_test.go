@@ -0,0 +1,41 @@
+package bogapi_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestValidateIBAN(t *testing.T) {
+	t.Parallel()
+
+	tcases := []struct {
+		iban string
+		err  string
+	}{
+		{"GE29NB0000000101904917", ""},
+		{"GE35BG0000000106360001", ""},
+		{"DE89370400440532013000", ""},
+		{"GB82WEST12345698765432", ""},
+		{"GE12BG0000000106360001", "invalid IBAN checksum: GE12BG0000000106360001"},
+		{"GE35BG000000010636000", "invalid Georgian IBAN format: GE35BG000000010636000"},
+		{"GE3500000000106360001X", "invalid Georgian IBAN format: GE3500000000106360001X"},
+		{"ge35BG0000000106360001", "invalid IBAN format: ge35BG0000000106360001"},
+		{"GE35BG00000001063600-1", "invalid IBAN format: GE35BG00000001063600-1"},
+		{"GE35", "invalid IBAN length: GE35"},
+		{"", "invalid IBAN length: "},
+	}
+	for _, tc := range tcases {
+		err := bogapi.ValidateIBAN(tc.iban)
+		if tc.err == "" {
+			assert.NoError(t, err, tc.iban)
+		} else {
+			assert.EqualError(t, err, tc.err)
+		}
+	}
+
+	assert.Equal(t, "BG", bogapi.IBANBankCode("GE35BG0000000106360001"))
+	assert.Equal(t, "NB", bogapi.IBANBankCode("GE29NB0000000101904917"))
+	assert.Equal(t, "", bogapi.IBANBankCode("DE89370400440532013000"))
+}
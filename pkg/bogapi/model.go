@@ -52,21 +52,21 @@ type GlobalSummary struct {
 	EndDate         Time    `json:"EndDate"`
 	PeriodStartDate Time    `json:"PeriodStartDate"`
 	PeriodEndDate   Time    `json:"PeriodEndDate"`
-	InAmount        float64 `json:"InAmount"`
-	InAmountBase    float64 `json:"InAmountBase"`
+	InAmount        Money   `json:"InAmount"`
+	InAmountBase    Money   `json:"InAmountBase"`
 	InRate          float64 `json:"InRate"`
-	OutAmount       float64 `json:"OutAmount"`
-	OutAmountBase   float64 `json:"OutAmountBase"`
+	OutAmount       Money   `json:"OutAmount"`
+	OutAmountBase   Money   `json:"OutAmountBase"`
 	OutRate         float64 `json:"OutRate"`
-	CreditSum       float64 `json:"CreditSum"`
-	DebitSum        float64 `json:"DebitSum"`
+	CreditSum       Money   `json:"CreditSum"`
+	DebitSum        Money   `json:"DebitSum"`
 }
 
 type DailySummary struct {
-	Balance     float64 `json:"Balance"`
-	BalanceBase float64 `json:"BalanceBase"`
-	CreditSum   float64 `json:"CreditSum"`
-	DebitSum    float64 `json:"DebitSum"`
+	Balance     Money   `json:"Balance"`
+	BalanceBase Money   `json:"BalanceBase"`
+	CreditSum   Money   `json:"CreditSum"`
+	DebitSum    Money   `json:"DebitSum"`
 	Rate        float64 `json:"Rate"`
 	EntryCount  int     `json:"EntryCount"`
 	Date        Time    `json:"Date"`
@@ -78,8 +78,8 @@ type StatementSummary struct {
 }
 
 type AccountBalance struct {
-	AvailableBalance float64 `json:"AvailableBalance"`
-	CurrentBalance   float64 `json:"CurrentBalance"`
+	AvailableBalance Money `json:"AvailableBalance"`
+	CurrentBalance   Money `json:"CurrentBalance"`
 }
 
 type SenderDetails struct {
@@ -102,12 +102,12 @@ type Record struct {
 	EntryDate                          Time               `json:"EntryDate"`
 	EntryDocumentNumber                string             `json:"EntryDocumentNumber"`
 	EntryAccountNumber                 string             `json:"EntryAccountNumber"`
-	EntryAmountDebit                   float64            `json:"EntryAmountDebit"`
-	EntryAmountDebitBase               float64            `json:"EntryAmountDebitBase"`
-	EntryAmountCredit                  float64            `json:"EntryAmountCredit"`
-	EntryAmountCreditBase              float64            `json:"EntryAmountCreditBase"`
-	EntryAmountBase                    float64            `json:"EntryAmountBase"`
-	EntryAmount                        float64            `json:"EntryAmount"`
+	EntryAmountDebit                   Money              `json:"EntryAmountDebit"`
+	EntryAmountDebitBase               Money              `json:"EntryAmountDebitBase"`
+	EntryAmountCredit                  Money              `json:"EntryAmountCredit"`
+	EntryAmountCreditBase              Money              `json:"EntryAmountCreditBase"`
+	EntryAmountBase                    Money              `json:"EntryAmountBase"`
+	EntryAmount                        Money              `json:"EntryAmount"`
 	EntryComment                       string             `json:"EntryComment"`
 	EntryDepartment                    string             `json:"EntryDepartment"`
 	EntryAccountPoint                  string             `json:"EntryAccountPoint"`
@@ -118,9 +118,9 @@ type Record struct {
 	DocumentTreasuryCode               string             `json:"DocumentTreasuryCode"`
 	DocumentNomination                 string             `json:"DocumentNomination"`
 	DocumentInformation                string             `json:"DocumentInformation"`
-	DocumentSourceAmount               float64            `json:"DocumentSourceAmount"`
+	DocumentSourceAmount               Money              `json:"DocumentSourceAmount"`
 	DocumentSourceCurrency             string             `json:"DocumentSourceCurrency"`
-	DocumentDestinationAmount          float64            `json:"DocumentDestinationAmount"`
+	DocumentDestinationAmount          Money              `json:"DocumentDestinationAmount"`
 	DocumentDestinationCurrency        string             `json:"DocumentDestinationCurrency"`
 	DocumentReceiveDate                *Time              `json:"DocumentReceiveDate"`
 	DocumentBranch                     string             `json:"DocumentBranch"`
@@ -148,6 +148,18 @@ type StatementResponse struct {
 	ID      int      `json:"Id"`
 	Count   int      `json:"Count"`
 	Records []Record `json:"Records"`
+	// Pages is the number of pages fetched to build the response
+	Pages int `json:"Pages,omitempty"`
+}
+
+// StatementPage is a single page of the statement
+type StatementPage struct {
+	StatementID int
+	// Count is the total number of records in the statement
+	Count int
+	// Page is the page number, starting from 1
+	Page    int
+	Records []Record
 }
 
 type AccountStatement struct {
@@ -157,8 +169,21 @@ type AccountStatement struct {
 	EndDate   string `json:"EndDate"`
 
 	StatementID int               `json:"StatementID"`
+	Pages       int               `json:"Pages,omitempty"`
 	Records     []Record          `json:"Records"`
 	Summary     *StatementSummary `json:"Summary"`
+	// Chunks lists the statements the records were stitched from,
+	// if the period was split into monthly chunks
+	Chunks []*StatementChunk `json:"Chunks,omitempty"`
+}
+
+// StatementChunk is a part of the period fetched as a separate statement
+type StatementChunk struct {
+	StartDate   string `json:"StartDate"`
+	EndDate     string `json:"EndDate"`
+	StatementID int    `json:"StatementID"`
+	Pages       int    `json:"Pages,omitempty"`
+	Count       int    `json:"Count"`
 }
 
 // AccountStatements provides combined account statements for multiple accounts.
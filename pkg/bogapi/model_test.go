@@ -34,8 +34,8 @@ func TestAccountBalance(t *testing.T) {
 	var res bogapi.AccountBalance
 	err = json.Unmarshal(data, &res)
 	require.NoError(t, err)
-	assert.Equal(t, 23083.33, res.AvailableBalance)
-	assert.Equal(t, 23083.33, res.CurrentBalance)
+	assert.Equal(t, "23083.33", res.AvailableBalance.String())
+	assert.Equal(t, "23083.33", res.CurrentBalance.String())
 }
 
 func TestStatementSummary(t *testing.T) {
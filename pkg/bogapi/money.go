@@ -0,0 +1,225 @@
+package bogapi
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shopspring/decimal"
+	"gopkg.in/yaml.v3"
+)
+
+// Money is an exact decimal amount in a currency.
+// The API returns amounts as JSON numbers without the currency, so Money is marshaled
+// as a number, and the currency is only known if set by the code, see In.
+// The zero value is zero without a currency.
+type Money struct {
+	amount   decimal.Decimal
+	currency string
+}
+
+// currencyDecimals lists currencies with the number of decimals other than 2
+var currencyDecimals = map[string]int32{
+	"JPY": 0,
+	"KRW": 0,
+	"BHD": 3,
+	"KWD": 3,
+	"OMR": 3,
+}
+
+// NewMoney returns the amount in the currency
+func NewMoney(amount decimal.Decimal, currency string) Money {
+	return newMoney(amount, currency)
+}
+
+// MoneyFromFloat returns the shortest decimal amount, which converts to f
+func MoneyFromFloat(f float64) Money {
+	return newMoney(decimal.NewFromFloat(f), "")
+}
+
+// MoneyFromInt returns the integer amount
+func MoneyFromInt(i int64) Money {
+	return newMoney(decimal.NewFromInt(i), "")
+}
+
+// ParseMoney parses the decimal amount, like 1500.25 or -0.5
+func ParseMoney(s string) (Money, error) {
+	d, err := decimal.NewFromString(strings.TrimSpace(s))
+	if err != nil {
+		return Money{}, errors.Errorf("invalid amount: %q", s)
+	}
+	return newMoney(d, ""), nil
+}
+
+// newMoney returns the amount in the canonical form without trailing zeros,
+// so equal amounts are also equal as Go values
+func newMoney(d decimal.Decimal, currency string) Money {
+	if d.IsZero() {
+		return Money{currency: currency}
+	}
+	if d.Exponent() != 0 {
+		d = decimal.RequireFromString(d.String())
+	}
+	return Money{amount: d, currency: currency}
+}
+
+// In returns the amount in the currency
+func (m Money) In(currency string) Money {
+	m.currency = currency
+	return m
+}
+
+// Currency returns the currency, or empty string if not known
+func (m Money) Currency() string {
+	return m.currency
+}
+
+// Decimal returns the amount
+func (m Money) Decimal() decimal.Decimal {
+	return m.amount
+}
+
+// Add returns m+o, in the currency of m, or of o if m has none.
+// Use CheckedAdd if the currencies may differ.
+func (m Money) Add(o Money) Money {
+	return newMoney(m.amount.Add(o.amount), m.pick(o))
+}
+
+// Sub returns m-o, in the currency of m, or of o if m has none.
+// Use CheckedSub if the currencies may differ.
+func (m Money) Sub(o Money) Money {
+	return newMoney(m.amount.Sub(o.amount), m.pick(o))
+}
+
+// CheckedAdd returns m+o, or an error if both currencies are known and differ
+func (m Money) CheckedAdd(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return m.Add(o), nil
+}
+
+// CheckedSub returns m-o, or an error if both currencies are known and differ
+func (m Money) CheckedSub(o Money) (Money, error) {
+	if err := m.checkCurrency(o); err != nil {
+		return Money{}, err
+	}
+	return m.Sub(o), nil
+}
+
+// pick returns the currency of m, or of o if m has none
+func (m Money) pick(o Money) string {
+	if m.currency == "" {
+		return o.currency
+	}
+	return m.currency
+}
+
+// checkCurrency returns an error if both currencies are known and differ
+func (m Money) checkCurrency(o Money) error {
+	if m.currency != "" && o.currency != "" && m.currency != o.currency {
+		return errors.Errorf("currency mismatch: %s and %s", m.currency, o.currency)
+	}
+	return nil
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return newMoney(m.amount.Neg(), m.currency)
+}
+
+// Abs returns the absolute amount
+func (m Money) Abs() Money {
+	if m.amount.Sign() < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// Cmp compares the amounts, ignoring currencies, and returns -1, 0 or +1
+func (m Money) Cmp(o Money) int {
+	return m.amount.Cmp(o.amount)
+}
+
+// Equal returns true if the amounts are equal, ignoring currencies
+func (m Money) Equal(o Money) bool {
+	return m.amount.Equal(o.amount)
+}
+
+// IsZero returns true if the amount is zero
+func (m Money) IsZero() bool {
+	return m.amount.IsZero()
+}
+
+// Sign returns -1, 0 or +1 for negative, zero and positive amounts
+func (m Money) Sign() int {
+	return m.amount.Sign()
+}
+
+// Truncate returns the amount rounded toward zero to the decimals of the currency
+func (m Money) Truncate() Money {
+	return newMoney(m.amount.Truncate(m.Decimals()), m.currency)
+}
+
+// Float64 returns the nearest float64 value, for spreadsheets and charts
+func (m Money) Float64() float64 {
+	f, _ := m.amount.Float64()
+	return f
+}
+
+// String returns the exact amount without trailing zeros, like 1500 or 17.39
+func (m Money) String() string {
+	return m.amount.String()
+}
+
+// Decimals returns the number of decimals of the currency, 2 if the currency is not known
+func (m Money) Decimals() int32 {
+	if d, ok := currencyDecimals[m.currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// Format returns the amount rounded to the decimals of the currency, like 1500.00
+func (m Money) Format() string {
+	return m.amount.StringFixed(m.Decimals())
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.amount.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		*m = Money{currency: m.currency}
+		return nil
+	}
+	v, err := ParseMoney(strings.Trim(string(b), `"`))
+	if err != nil {
+		return err
+	}
+	*m = v.In(m.currency)
+	return nil
+}
+
+func (m Money) MarshalYAML() (any, error) {
+	s := m.amount.String()
+	tag := "!!int"
+	if strings.Contains(s, ".") {
+		tag = "!!float"
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: s}, nil
+}
+
+func (m *Money) UnmarshalYAML(value *yaml.Node) error {
+	if value.Tag == "!!null" {
+		*m = Money{currency: m.currency}
+		return nil
+	}
+	v, err := ParseMoney(value.Value)
+	if err != nil {
+		return err
+	}
+	*m = v.In(m.currency)
+	return nil
+}
@@ -0,0 +1,95 @@
+package bogapi_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMoney_JSON(t *testing.T) {
+	var r bogapi.Record
+	err := json.Unmarshal([]byte(`{"EntryAmountDebit":12345678901234567.89,"EntryAmountCredit":"0.10","EntryAmount":null,"EntryAmountBase":500.00}`), &r)
+	require.NoError(t, err)
+	assert.Equal(t, "12345678901234567.89", r.EntryAmountDebit.String())
+	assert.Equal(t, "0.1", r.EntryAmountCredit.String())
+	assert.True(t, r.EntryAmount.IsZero())
+	// trailing zeros are dropped, so equal amounts are equal values
+	assert.Equal(t, bogapi.MoneyFromFloat(500), r.EntryAmountBase)
+
+	data, err := json.Marshal(&r)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"EntryAmountDebit":12345678901234567.89,`)
+	assert.Contains(t, string(data), `"EntryAmountCredit":0.1,`)
+	assert.Contains(t, string(data), `"EntryAmount":0,`)
+
+	var m bogapi.Money
+	assert.EqualError(t, json.Unmarshal([]byte(`"abc"`), &m), `invalid amount: "abc"`)
+}
+
+func TestMoney_YAML(t *testing.T) {
+	balance := bogapi.AccountBalance{
+		AvailableBalance: bogapi.MoneyFromFloat(23083.33),
+		CurrentBalance:   bogapi.MoneyFromInt(100),
+	}
+	data, err := yaml.Marshal(balance)
+	require.NoError(t, err)
+	assert.Equal(t, "availablebalance: 23083.33\ncurrentbalance: 100\n", string(data))
+
+	var res bogapi.AccountBalance
+	require.NoError(t, yaml.Unmarshal(data, &res))
+	assert.Equal(t, balance, res)
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	// float64 sums drift: 0.1+0.2 != 0.3
+	var sum bogapi.Money
+	for i := 0; i < 1000; i++ {
+		sum = sum.Add(bogapi.MoneyFromFloat(0.1))
+	}
+	assert.Equal(t, bogapi.MoneyFromInt(100), sum)
+	assert.True(t, bogapi.MoneyFromFloat(0.1).Add(bogapi.MoneyFromFloat(0.2)).Equal(bogapi.MoneyFromFloat(0.3)))
+
+	a := bogapi.MoneyFromFloat(17.39).In("EUR")
+	b := bogapi.MoneyFromFloat(500)
+	assert.Equal(t, "-482.61", a.Sub(b).String())
+	assert.Equal(t, "EUR", a.Sub(b).Currency())
+	assert.Equal(t, "EUR", b.Add(a).Currency())
+	assert.Equal(t, "482.61", a.Sub(b).Abs().String())
+	assert.Equal(t, -1, a.Cmp(b))
+	assert.Equal(t, -1, a.Neg().Sign())
+	assert.Equal(t, bogapi.MoneyFromInt(0), a.Sub(a).In(""))
+	assert.Equal(t, 17.39, a.Float64())
+	assert.Equal(t, "17.38", bogapi.MoneyFromFloat(17.389).In("EUR").Truncate().String())
+
+	// amounts in different currencies are reported by the checked variants
+	usd := bogapi.MoneyFromInt(1).In("USD")
+	assert.NotPanics(t, func() { a.Add(usd) })
+	assert.Equal(t, "EUR", a.Add(usd).Currency())
+	_, err := a.CheckedAdd(usd)
+	assert.EqualError(t, err, "currency mismatch: EUR and USD")
+	_, err = a.CheckedSub(usd)
+	assert.EqualError(t, err, "currency mismatch: EUR and USD")
+	total, err := a.CheckedAdd(b)
+	require.NoError(t, err)
+	assert.Equal(t, "517.39", total.String())
+
+	m, err := bogapi.ParseMoney(" 1500.250 ")
+	require.NoError(t, err)
+	assert.Equal(t, "1500.25", m.String())
+	_, err = bogapi.ParseMoney("1,5")
+	assert.EqualError(t, err, `invalid amount: "1,5"`)
+}
+
+func TestMoney_Format(t *testing.T) {
+	m := bogapi.MoneyFromFloat(1234.565)
+	assert.Equal(t, "1234.57", m.Format())
+	assert.Equal(t, "1234.57", m.In("GEL").Format())
+	assert.Equal(t, "1235", m.In("JPY").Format())
+	assert.Equal(t, "1234.565", m.In("KWD").Format())
+	assert.Equal(t, "0.00", bogapi.Money{}.Format())
+	assert.Equal(t, int32(0), m.In("JPY").Decimals())
+}
@@ -0,0 +1,239 @@
+package bogapi
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+)
+
+// PaymentType specifies the type of the transfer
+type PaymentType string
+
+const (
+	// PaymentDomestic is a transfer in GEL to any Georgian bank
+	PaymentDomestic PaymentType = "domestic"
+	// PaymentIntraBank is a transfer to another Bank of Georgia account,
+	// in any currency of the beneficiary account
+	PaymentIntraBank PaymentType = "intrabank"
+)
+
+// Payment statuses returned by GetPaymentStatus
+const (
+	// PaymentStatusDraft is a document waiting for a signature
+	PaymentStatusDraft = "Draft"
+	// PaymentStatusInProgress is a signed document being processed by the bank
+	PaymentStatusInProgress = "InProgress"
+	// PaymentStatusCompleted is a executed document
+	PaymentStatusCompleted = "Completed"
+	// PaymentStatusRejected is a document rejected by the bank
+	PaymentStatusRejected = "Rejected"
+	// PaymentStatusCanceled is a document canceled by the customer
+	PaymentStatusCanceled = "Canceled"
+)
+
+// PaymentRequest is a payment document
+type PaymentRequest struct {
+	Type PaymentType `json:"-" yaml:"type"`
+	// UniqueID identifies the document for idempotency, generated if not provided
+	UniqueID string `json:"UniqueId" yaml:"unique_id"`
+	// SourceAccountNumber is the IBAN of the payer
+	SourceAccountNumber string `json:"SourceAccountNumber" yaml:"source_account_number"`
+	// BeneficiaryAccountNumber is the IBAN of the beneficiary
+	BeneficiaryAccountNumber string `json:"BeneficiaryAccountNumber" yaml:"beneficiary_account_number"`
+	BeneficiaryBankCode      string `json:"BeneficiaryBankCode,omitempty" yaml:"beneficiary_bank_code,omitempty"`
+	BeneficiaryName          string `json:"BeneficiaryName" yaml:"beneficiary_name"`
+	// BeneficiaryInn is the tax ID or the personal number of the beneficiary
+	BeneficiaryInn        string `json:"BeneficiaryInn,omitempty" yaml:"beneficiary_inn,omitempty"`
+	Nomination            string `json:"Nomination" yaml:"nomination"`
+	AdditionalInformation string `json:"AdditionalInformation,omitempty" yaml:"additional_information,omitempty"`
+	Amount                Money  `json:"Amount" yaml:"amount"`
+	Currency              string `json:"Currency" yaml:"currency"`
+	DocumentNo            string `json:"DocumentNo,omitempty" yaml:"document_no,omitempty"`
+}
+
+// PaymentResponse is returned by CreatePayment
+type PaymentResponse struct {
+	UniqueID string `json:"UniqueId"`
+	// UniqueKey identifies the document in the bank
+	UniqueKey  int64  `json:"UniqueKey"`
+	ResultCode int    `json:"ResultCode"`
+	Message    string `json:"Message,omitempty"`
+}
+
+// PaymentStatus is returned by GetPaymentStatus
+type PaymentStatus struct {
+	UniqueID     string `json:"UniqueId"`
+	UniqueKey    int64  `json:"UniqueKey"`
+	Status       string `json:"Status"`
+	RejectReason string `json:"RejectReason,omitempty"`
+}
+
+// IsFinal returns true if the status of the document will not change
+func (s *PaymentStatus) IsFinal() bool {
+	switch s.Status {
+	case PaymentStatusCompleted, PaymentStatusRejected, PaymentStatusCanceled:
+		return true
+	}
+	return false
+}
+
+var innRegex = regexp.MustCompile(`^(\d{9}|\d{11})$`)
+
+// SetDefaults generates the unique ID if not provided,
+// and sets the bank code of intra-bank transfers
+func (r *PaymentRequest) SetDefaults() {
+	if r.UniqueID == "" {
+		r.UniqueID = newUniqueID()
+	}
+	if r.Type == PaymentIntraBank && r.BeneficiaryBankCode == "" {
+		r.BeneficiaryBankCode = BOGSwiftCode
+	}
+}
+
+// Validate checks the document before sending it to the bank
+func (r *PaymentRequest) Validate() error {
+	if r.SourceAccountNumber == "" {
+		return errors.New("source account is required")
+	}
+	if err := ValidateIBAN(r.SourceAccountNumber); err != nil {
+		return errors.WithMessage(err, "invalid source account")
+	}
+	if err := ValidateIBAN(r.BeneficiaryAccountNumber); err != nil {
+		return errors.WithMessage(err, "invalid beneficiary account")
+	}
+	if r.SourceAccountNumber == r.BeneficiaryAccountNumber {
+		return errors.New("source and beneficiary accounts must be different")
+	}
+	if strings.TrimSpace(r.BeneficiaryName) == "" {
+		return errors.New("beneficiary name is required")
+	}
+	if r.BeneficiaryInn != "" && !innRegex.MatchString(r.BeneficiaryInn) {
+		return errors.Errorf("invalid beneficiary INN: %s, must be 9 or 11 digits", r.BeneficiaryInn)
+	}
+	if strings.TrimSpace(r.Nomination) == "" {
+		return errors.New("nomination is required")
+	}
+	if err := validateAmount(r.Amount.In(r.Currency)); err != nil {
+		return err
+	}
+
+	switch r.Type {
+	case PaymentDomestic:
+		if r.Currency != "GEL" {
+			return errors.Errorf("domestic transfers must be in GEL: %s", r.Currency)
+		}
+	case PaymentIntraBank:
+		if IBANBankCode(r.BeneficiaryAccountNumber) != BOGBankCode {
+			return errors.Errorf("intra-bank transfers must be to Bank of Georgia account: %s", r.BeneficiaryAccountNumber)
+		}
+		if len(r.Currency) != 3 {
+			return errors.Errorf("invalid currency: %s", r.Currency)
+		}
+	default:
+		return errors.Errorf("unsupported payment type: %q", r.Type)
+	}
+	return nil
+}
+
+// ParseAmount parses the amount to transfer, which must be positive.
+// 1,500 or 1.500,00 can be read two ways, so the amount fails instead of guessing.
+func ParseAmount(s string) (Money, error) {
+	m, err := ParseMoney(s)
+	if err != nil || m.Sign() <= 0 {
+		if strings.ContainsAny(s, ", '") {
+			return Money{}, errors.Errorf("invalid amount: %s, use a dot for decimals and no grouping, like 1200.50", s)
+		}
+		return Money{}, errors.Errorf("invalid amount: %s", s)
+	}
+	return m, nil
+}
+
+// validateAmount checks that the amount is positive, with at most the decimals of its currency
+func validateAmount(amount Money) error {
+	if amount.Sign() <= 0 {
+		return errors.Errorf("invalid amount: %s", amount)
+	}
+	if !amount.Truncate().Equal(amount) {
+		return errors.Errorf("invalid amount: %s, at most %d decimal places are allowed", amount, amount.Decimals())
+	}
+	return nil
+}
+
+// CreatePayment validates and submits the payment document
+func (c *client) CreatePayment(ctx context.Context, req *PaymentRequest) (*PaymentResponse, error) {
+	req.SetDefaults()
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	path := "/api/documents/" + string(req.Type)
+	var res PaymentResponse
+	hdr, status, err := c.call(ctx, http.MethodPost, path, req, &res)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"account", req.SourceAccountNumber,
+			"currency", req.Currency,
+			"unique_id", req.UniqueID,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(withAccount(err, req.SourceAccountNumber, req.Currency),
+			"failed to create payment: %s", req.UniqueID)
+	}
+
+	logger.ContextKV(ctx, xlog.INFO,
+		"status", "payment_created",
+		"unique_id", res.UniqueID,
+		"unique_key", res.UniqueKey,
+	)
+	return &res, nil
+}
+
+// GetPaymentStatus returns the status of the payment document
+func (c *client) GetPaymentStatus(ctx context.Context, uniqueKey int64) (*PaymentStatus, error) {
+	path := fmt.Sprintf("/api/documents/statuses/%d", uniqueKey)
+	var res PaymentStatus
+	hdr, status, err := c.call(ctx, http.MethodGet, path, nil, &res)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"unique_key", uniqueKey,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return nil, errors.WithMessagef(err, "failed to get payment status: %d", uniqueKey)
+	}
+	return &res, nil
+}
+
+// CancelPayment cancels the payment document, which has not been executed yet
+func (c *client) CancelPayment(ctx context.Context, uniqueKey int64) error {
+	path := fmt.Sprintf("/api/documents/%d", uniqueKey)
+	hdr, status, err := c.call(ctx, http.MethodDelete, path, nil, nil)
+	if err != nil {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"unique_key", uniqueKey,
+			"status", status,
+			"header", hdr,
+			"err", err.Error(),
+		)
+		return errors.WithMessagef(err, "failed to cancel payment: %d", uniqueKey)
+	}
+	return nil
+}
+
+// newUniqueID returns a random UUID v4
+func newUniqueID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,126 @@
+package bogapi_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+)
+
+func domesticPayment() *bogapi.PaymentRequest {
+	return &bogapi.PaymentRequest{
+		Type:                     bogapi.PaymentDomestic,
+		SourceAccountNumber:      bogtest.PaymentAccount,
+		BeneficiaryAccountNumber: "GE29NB0000000101904917",
+		BeneficiaryName:          "Revenue Service",
+		BeneficiaryInn:           "204469032",
+		Nomination:               "Income tax",
+		Amount:                   bogapi.MoneyFromFloat(135.29),
+		Currency:                 "GEL",
+	}
+}
+
+func TestPaymentRequest_Validate(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, domesticPayment().Validate())
+
+	tcases := []struct {
+		update func(r *bogapi.PaymentRequest)
+		err    string
+	}{
+		{func(r *bogapi.PaymentRequest) { r.SourceAccountNumber = "" }, "source account is required"},
+		{func(r *bogapi.PaymentRequest) { r.SourceAccountNumber = "GE12BG0000000106360002" }, "invalid source account: invalid IBAN checksum: GE12BG0000000106360002"},
+		{func(r *bogapi.PaymentRequest) { r.BeneficiaryAccountNumber = "GE00NB0000000101904917" }, "invalid beneficiary account: invalid IBAN checksum: GE00NB0000000101904917"},
+		{func(r *bogapi.PaymentRequest) { r.BeneficiaryAccountNumber = r.SourceAccountNumber }, "source and beneficiary accounts must be different"},
+		{func(r *bogapi.PaymentRequest) { r.BeneficiaryName = " " }, "beneficiary name is required"},
+		{func(r *bogapi.PaymentRequest) { r.BeneficiaryInn = "1234" }, "invalid beneficiary INN: 1234, must be 9 or 11 digits"},
+		{func(r *bogapi.PaymentRequest) { r.Nomination = "" }, "nomination is required"},
+		{func(r *bogapi.PaymentRequest) { r.Amount = bogapi.Money{} }, "invalid amount: 0"},
+		{func(r *bogapi.PaymentRequest) { r.Amount = bogapi.MoneyFromFloat(1.001) }, "invalid amount: 1.001, at most 2 decimal places are allowed"},
+		{func(r *bogapi.PaymentRequest) { r.Currency = "USD" }, "domestic transfers must be in GEL: USD"},
+		{func(r *bogapi.PaymentRequest) { r.Type = bogapi.PaymentIntraBank }, "intra-bank transfers must be to Bank of Georgia account: GE29NB0000000101904917"},
+		{func(r *bogapi.PaymentRequest) { r.Type = "swift" }, `unsupported payment type: "swift"`},
+	}
+	for _, tc := range tcases {
+		req := domesticPayment()
+		tc.update(req)
+		assert.EqualError(t, req.Validate(), tc.err)
+	}
+
+	req := domesticPayment()
+	req.Type = bogapi.PaymentIntraBank
+	req.BeneficiaryAccountNumber = "GE35BG0000000106360001"
+	req.Currency = "USD"
+	assert.NoError(t, req.Validate())
+}
+
+func TestClient_Payment(t *testing.T) {
+	t.Parallel()
+
+	srv := newTestServer(t)
+	srv.SeedPaymentAccount()
+	client := newTestClient(t, srv)
+	ctx := context.Background()
+
+	req := domesticPayment()
+	res, err := client.CreatePayment(ctx, req)
+	require.NoError(t, err)
+	assert.NotEmpty(t, req.UniqueID)
+	assert.Equal(t, req.UniqueID, res.UniqueID)
+	assert.NotZero(t, res.UniqueKey)
+
+	// the same document is not submitted twice
+	res2, err := client.CreatePayment(ctx, req)
+	require.NoError(t, err)
+	assert.Equal(t, res.UniqueKey, res2.UniqueKey)
+	assert.Equal(t, 1, srv.Payments())
+
+	doc, _ := srv.Payment(res.UniqueKey)
+	require.NotNil(t, doc)
+	assert.Equal(t, "135.29", doc.Amount.String())
+	assert.Equal(t, "204469032", doc.BeneficiaryInn)
+
+	status, err := client.GetPaymentStatus(ctx, res.UniqueKey)
+	require.NoError(t, err)
+	assert.Equal(t, bogapi.PaymentStatusInProgress, status.Status)
+	assert.False(t, status.IsFinal())
+
+	require.NoError(t, client.CancelPayment(ctx, res.UniqueKey))
+	status, err = client.GetPaymentStatus(ctx, res.UniqueKey)
+	require.NoError(t, err)
+	assert.Equal(t, bogapi.PaymentStatusCanceled, status.Status)
+	assert.True(t, status.IsFinal())
+
+	err = client.CancelPayment(ctx, res.UniqueKey)
+	require.Error(t, err)
+	assert.Equal(t, http.StatusConflict, bogapi.StatusCode(err))
+
+	_, err = client.GetPaymentStatus(ctx, 1)
+	assert.True(t, bogapi.IsNotFound(err))
+
+	intra := domesticPayment()
+	intra.Type = bogapi.PaymentIntraBank
+	intra.BeneficiaryAccountNumber = "GE35BG0000000106360001"
+	intra.Currency = "USD"
+	res, err = client.CreatePayment(ctx, intra)
+	require.NoError(t, err)
+	doc, _ = srv.Payment(res.UniqueKey)
+	require.NotNil(t, doc)
+	assert.Equal(t, bogapi.BOGSwiftCode, doc.BeneficiaryBankCode)
+
+	invalid := domesticPayment()
+	invalid.Amount = bogapi.MoneyFromInt(-1)
+	_, err = client.CreatePayment(ctx, invalid)
+	assert.EqualError(t, err, "invalid amount: -1")
+	assert.Equal(t, 2, srv.Payments())
+
+	unknown := domesticPayment()
+	unknown.SourceAccountNumber = "GE35BG0000000106360001"
+	_, err = client.CreatePayment(ctx, unknown)
+	assert.True(t, bogapi.IsNotFound(err))
+}
@@ -0,0 +1,172 @@
+package bogapi
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DateFormat is the format of dates in API requests
+const DateFormat = "2006-01-02"
+
+// Location is the time zone of the bank, used to evaluate relative periods
+var Location = loadLocation()
+
+func loadLocation() *time.Location {
+	loc, err := time.LoadLocation("Asia/Tbilisi")
+	if err != nil {
+		// Georgia has not observed daylight saving time since 2005
+		return time.FixedZone("+04", 4*60*60)
+	}
+	return loc
+}
+
+// Period is a range of dates, both inclusive
+type Period struct {
+	Start time.Time
+	End   time.Time
+}
+
+// StartDate returns the start date in DateFormat
+func (p *Period) StartDate() string {
+	return p.Start.Format(DateFormat)
+}
+
+// EndDate returns the end date in DateFormat
+func (p *Period) EndDate() string {
+	return p.End.Format(DateFormat)
+}
+
+func (p *Period) String() string {
+	return p.StartDate() + ".." + p.EndDate()
+}
+
+var (
+	yearRegex    = regexp.MustCompile(`^\d{4}$`)
+	monthRegex   = regexp.MustCompile(`^(\d{4})-(\d{2})$`)
+	quarterRegex = regexp.MustCompile(`^(\d{4})-[qQ]([1-4])$`)
+	lastDaysRe   = regexp.MustCompile(`^last-(\d+)d$`)
+)
+
+// ParsePeriod returns the period by the specification, evaluated in Location:
+//
+//	2025-02-19                a day
+//	2025-02                   a month
+//	2025-Q1                   a quarter
+//	2025                      a year
+//	ytd                       from the start of the year to today
+//	mtd                       from the start of the month to today
+//	today, yesterday
+//	last-month                the previous calendar month
+//	last-30d                  30 days ending today
+//	2025-01-15..2025-02-10    an explicit range, each side can be any of the above
+//
+// Periods ending in the future are truncated to today,
+// and periods starting in the future are rejected.
+func ParsePeriod(spec string) (*Period, error) {
+	now := NowFunc().In(Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, Location)
+
+	var p *Period
+	var err error
+	if from, to, ok := strings.Cut(spec, ".."); ok {
+		p, err = parseRange(from, to, today)
+	} else {
+		p, err = parsePeriod(spec, today)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if p.Start.After(today) {
+		return nil, errors.Errorf("period is in the future: %s", spec)
+	}
+	if p.End.After(today) {
+		p.End = today
+	}
+	return p, nil
+}
+
+func parseRange(from, to string, today time.Time) (*Period, error) {
+	start, err := parsePeriod(from, today)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parsePeriod(to, today)
+	if err != nil {
+		return nil, err
+	}
+	if end.End.Before(start.Start) {
+		return nil, errors.Errorf("invalid period: %s is after %s", from, to)
+	}
+	return &Period{Start: start.Start, End: end.End}, nil
+}
+
+func parsePeriod(spec string, today time.Time) (*Period, error) {
+	spec = strings.TrimSpace(spec)
+	days := func(start time.Time, n int) *Period {
+		return &Period{Start: start, End: start.AddDate(0, 0, n-1)}
+	}
+	months := func(year, month, n int) *Period {
+		start := time.Date(year, time.Month(month), 1, 0, 0, 0, 0, Location)
+		return &Period{Start: start, End: start.AddDate(0, n, -1)}
+	}
+
+	switch strings.ToLower(spec) {
+	case "today":
+		return days(today, 1), nil
+	case "yesterday":
+		return days(today.AddDate(0, 0, -1), 1), nil
+	case "ytd":
+		return &Period{Start: time.Date(today.Year(), 1, 1, 0, 0, 0, 0, Location), End: today}, nil
+	case "mtd":
+		return &Period{Start: time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, Location), End: today}, nil
+	case "last-month":
+		return months(today.Year(), int(today.Month())-1, 1), nil
+	}
+
+	if m := lastDaysRe.FindStringSubmatch(spec); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if n == 0 {
+			return nil, errors.Errorf("invalid period: %s", spec)
+		}
+		return days(today.AddDate(0, 0, 1-n), n), nil
+	}
+	if yearRegex.MatchString(spec) {
+		year, _ := strconv.Atoi(spec)
+		return months(year, 1, 12), nil
+	}
+	if m := quarterRegex.FindStringSubmatch(spec); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		q, _ := strconv.Atoi(m[2])
+		return months(year, (q-1)*3+1, 3), nil
+	}
+	if m := monthRegex.FindStringSubmatch(spec); m != nil {
+		year, _ := strconv.Atoi(m[1])
+		month, _ := strconv.Atoi(m[2])
+		if month < 1 || month > 12 {
+			return nil, errors.Errorf("invalid month: %s", spec)
+		}
+		return months(year, month, 1), nil
+	}
+	if date, err := time.ParseInLocation(DateFormat, spec, Location); err == nil {
+		return days(date, 1), nil
+	}
+	return nil, errors.Errorf("invalid period: %q, expected 2025-02-19, 2025-02, 2025-Q1, 2025, ytd, mtd, today, yesterday, last-month, last-30d or a range like 2025-01-15..2025-02-10", spec)
+}
+
+// RecentMonth returns the latest period of the month, 1-12, which is not in the future
+func RecentMonth(month int) (*Period, error) {
+	if month < 1 || month > 12 {
+		return nil, errors.Errorf("invalid month: %d, expected 1-12", month)
+	}
+	now := NowFunc().In(Location)
+	year := now.Year()
+	if time.Month(month) > now.Month() {
+		year--
+	}
+	return ParsePeriod(time.Date(year, time.Month(month), 1, 0, 0, 0, 0, Location).Format("2006-01"))
+}
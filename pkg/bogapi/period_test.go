@@ -0,0 +1,80 @@
+package bogapi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestParsePeriod(t *testing.T) {
+	// 2025-03-01 01:30 in Tbilisi is still February in UTC
+	bogapi.NowFunc = func() time.Time {
+		return time.Date(2025, 2, 28, 21, 30, 0, 0, time.UTC)
+	}
+	defer func() {
+		bogapi.NowFunc = time.Now
+	}()
+
+	tcases := []struct {
+		spec  string
+		start string
+		end   string
+		err   string
+	}{
+		{spec: "2025-02-19", start: "2025-02-19", end: "2025-02-19"},
+		{spec: "2025-02", start: "2025-02-01", end: "2025-02-28"},
+		{spec: "2024-02", start: "2024-02-01", end: "2024-02-29"},
+		{spec: "2024-q4", start: "2024-10-01", end: "2024-12-31"},
+		{spec: "2025-Q1", start: "2025-01-01", end: "2025-03-01"},
+		{spec: "2024", start: "2024-01-01", end: "2024-12-31"},
+		{spec: "ytd", start: "2025-01-01", end: "2025-03-01"},
+		{spec: "mtd", start: "2025-03-01", end: "2025-03-01"},
+		{spec: "today", start: "2025-03-01", end: "2025-03-01"},
+		{spec: "yesterday", start: "2025-02-28", end: "2025-02-28"},
+		{spec: "last-month", start: "2025-02-01", end: "2025-02-28"},
+		{spec: "last-30d", start: "2025-01-31", end: "2025-03-01"},
+		{spec: "2025-01-15..2025-02-10", start: "2025-01-15", end: "2025-02-10"},
+		{spec: "2024-11..2025-01", start: "2024-11-01", end: "2025-01-31"},
+		{spec: "2024-12-01..today", start: "2024-12-01", end: "2025-03-01"},
+		{spec: "2025-04", err: "period is in the future: 2025-04"},
+		{spec: "2025-13", err: "invalid month: 2025-13"},
+		{spec: "last-0d", err: "invalid period: last-0d"},
+		{spec: "2025-02-10..2025-01-15", err: "invalid period: 2025-02-10 is after 2025-01-15"},
+		{spec: "19.02.2025", err: `invalid period: "19.02.2025", expected 2025-02-19, 2025-02, 2025-Q1, 2025, ytd, mtd, today, yesterday, last-month, last-30d or a range like 2025-01-15..2025-02-10`},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.spec, func(t *testing.T) {
+			p, err := bogapi.ParsePeriod(tc.spec)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.start, p.StartDate())
+			assert.Equal(t, tc.end, p.EndDate())
+		})
+	}
+}
+
+func TestRecentMonth(t *testing.T) {
+	bogapi.NowFunc = func() time.Time {
+		return time.Date(2025, 1, 10, 12, 0, 0, 0, time.UTC)
+	}
+	defer func() {
+		bogapi.NowFunc = time.Now
+	}()
+
+	p, err := bogapi.RecentMonth(12)
+	require.NoError(t, err)
+	assert.Equal(t, "2024-12-01..2024-12-31", p.String())
+
+	p, err = bogapi.RecentMonth(1)
+	require.NoError(t, err)
+	assert.Equal(t, "2025-01-01..2025-01-10", p.String())
+
+	_, err = bogapi.RecentMonth(13)
+	assert.EqualError(t, err, "invalid month: 13, expected 1-12")
+}
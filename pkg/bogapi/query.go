@@ -0,0 +1,139 @@
+package bogapi
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Query filters statement records, zero values match all records
+type Query struct {
+	Account  string
+	Currency string
+	// Start and End specify the range of entry dates, both inclusive
+	Start time.Time
+	End   time.Time
+	// Side is "debit" for outgoing, or "credit" for incoming records
+	Side string
+	// MinAmount and MaxAmount specify the range of the debit or credit amount
+	MinAmount Money
+	MaxAmount Money
+	// Counterparty matches a part of the sender or beneficiary name, case insensitive
+	Counterparty string
+	// INN matches the taxpayer number of the sender or beneficiary
+	INN string
+	// IBAN matches the account number of the sender or beneficiary
+	IBAN string
+	// ProductGroup matches DocumentProductGroup, case insensitive
+	ProductGroup string
+	// Text matches a part of EntryComment or DocumentNomination, case insensitive
+	Text string
+}
+
+// Validate returns an error if the query is inconsistent
+func (q *Query) Validate() error {
+	switch q.Side {
+	case "", "debit", "credit":
+	default:
+		return errors.Errorf("invalid side: %s, expected debit or credit", q.Side)
+	}
+	if q.MinAmount.Sign() < 0 || q.MaxAmount.Sign() < 0 {
+		return errors.New("amount must not be negative")
+	}
+	if q.MaxAmount.Sign() > 0 && q.MinAmount.Cmp(q.MaxAmount) > 0 {
+		return errors.Errorf("invalid amount range: %s is above %s", q.MinAmount.Format(), q.MaxAmount.Format())
+	}
+	if !q.Start.IsZero() && !q.End.IsZero() && q.End.Before(q.Start) {
+		return errors.New("invalid period: the end is before the start")
+	}
+	return nil
+}
+
+// Match returns true if the record matches the query, regardless of the account
+func (q *Query) Match(r *Record) bool {
+	day := time.Time(r.EntryDate).Format(DateFormat)
+	if !q.Start.IsZero() && day < q.Start.Format(DateFormat) {
+		return false
+	}
+	if !q.End.IsZero() && day > q.End.Format(DateFormat) {
+		return false
+	}
+
+	amount := r.EntryAmountDebit.Add(r.EntryAmountCredit)
+	switch q.Side {
+	case "debit":
+		if r.EntryAmountDebit.IsZero() {
+			return false
+		}
+		amount = r.EntryAmountDebit
+	case "credit":
+		if r.EntryAmountCredit.IsZero() {
+			return false
+		}
+		amount = r.EntryAmountCredit
+	}
+	if q.MinAmount.Sign() > 0 && amount.Cmp(q.MinAmount) < 0 {
+		return false
+	}
+	if q.MaxAmount.Sign() > 0 && amount.Cmp(q.MaxAmount) > 0 {
+		return false
+	}
+
+	if q.Counterparty != "" &&
+		!containsFold(r.SenderDetails.Name, q.Counterparty) &&
+		!containsFold(r.BeneficiaryDetails.Name, q.Counterparty) {
+		return false
+	}
+	if q.INN != "" && r.SenderDetails.Inn != q.INN && r.BeneficiaryDetails.Inn != q.INN {
+		return false
+	}
+	if q.IBAN != "" &&
+		!strings.EqualFold(r.SenderDetails.AccountNumber, q.IBAN) &&
+		!strings.EqualFold(r.BeneficiaryDetails.AccountNumber, q.IBAN) {
+		return false
+	}
+	if q.ProductGroup != "" && !strings.EqualFold(r.DocumentProductGroup, q.ProductGroup) {
+		return false
+	}
+	if q.Text != "" &&
+		!containsFold(r.EntryComment, q.Text) &&
+		!containsFold(r.DocumentNomination, q.Text) {
+		return false
+	}
+	return true
+}
+
+// Filter returns statements with the records matching the query,
+// statements of other accounts and without matching records are omitted
+func (q *Query) Filter(st *AccountStatements) *AccountStatements {
+	res := &AccountStatements{}
+	for _, ast := range st.Combined {
+		if q.Account != "" && q.Account != ast.Account {
+			continue
+		}
+		if q.Currency != "" && q.Currency != ast.Currency {
+			continue
+		}
+
+		var records []Record
+		for i := range ast.Records {
+			if q.Match(&ast.Records[i]) {
+				records = append(records, ast.Records[i])
+			}
+		}
+		if len(records) == 0 {
+			continue
+		}
+		filtered := *ast
+		filtered.Records = records
+		filtered.Summary = nil
+		filtered.Chunks = nil
+		res.Combined = append(res.Combined, &filtered)
+	}
+	return res
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
@@ -0,0 +1,66 @@
+package bogapi_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestQuery(t *testing.T) {
+	data, err := os.ReadFile("testdata/statement_feb.json")
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	count := func(st *bogapi.AccountStatements) int {
+		n := 0
+		for _, ast := range st.Combined {
+			n += len(ast.Records)
+		}
+		return n
+	}
+
+	tcases := []struct {
+		name string
+		q    bogapi.Query
+		exp  int
+	}{
+		{"all", bogapi.Query{}, 8},
+		{"account", bogapi.Query{Account: "GE12BG0000000106360001"}, 2},
+		{"currency", bogapi.Query{Account: "GE12BG0000000106360002", Currency: "EUR"}, 3},
+		{"start", bogapi.Query{Start: time.Date(2025, 2, 19, 0, 0, 0, 0, bogapi.Location)}, 4},
+		{"end", bogapi.Query{End: time.Date(2025, 2, 18, 0, 0, 0, 0, bogapi.Location)}, 4},
+		{"debit", bogapi.Query{Side: "debit"}, 4},
+		{"credit", bogapi.Query{Side: "credit"}, 4},
+		{"min", bogapi.Query{MinAmount: bogapi.MoneyFromFloat(200)}, 4},
+		{"credit range", bogapi.Query{Side: "credit", MinAmount: bogapi.MoneyFromFloat(50), MaxAmount: bogapi.MoneyFromFloat(600)}, 3},
+		{"counterparty", bogapi.Query{Counterparty: "avaleris"}, 1},
+		{"inn", bogapi.Query{INN: "405758318"}, 8},
+		{"inn missing", bogapi.Query{INN: "000000000"}, 0},
+		{"iban", bogapi.Query{IBAN: "ge59bg4501981900100000"}, 1},
+		{"product", bogapi.Query{ProductGroup: "fee"}, 3},
+		{"text", bogapi.Query{Text: "MCC: 4814"}, 1},
+		{"none", bogapi.Query{Text: "missing"}, 0},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.q.Validate())
+			assert.Equal(t, tc.exp, count(tc.q.Filter(&doc)))
+		})
+	}
+
+	// statements without matching records are omitted
+	res := (&bogapi.Query{Counterparty: "avaleris"}).Filter(&doc)
+	require.Len(t, res.Combined, 1)
+	assert.Equal(t, "USD", res.Combined[0].Currency)
+	assert.Len(t, doc.Combined, 6)
+
+	assert.EqualError(t, (&bogapi.Query{Side: "both"}).Validate(), "invalid side: both, expected debit or credit")
+	assert.EqualError(t, (&bogapi.Query{MinAmount: bogapi.MoneyFromFloat(10), MaxAmount: bogapi.MoneyFromFloat(5)}).Validate(), "invalid amount range: 10.00 is above 5.00")
+	assert.EqualError(t, (&bogapi.Query{MinAmount: bogapi.MoneyFromFloat(-1)}).Validate(), "amount must not be negative")
+}
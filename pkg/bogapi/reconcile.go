@@ -0,0 +1,190 @@
+package bogapi
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Reconciliation checks
+const (
+	CheckSummary    = "summary"
+	CheckDebitSum   = "debit_sum"
+	CheckCreditSum  = "credit_sum"
+	CheckClosing    = "closing"
+	CheckEntryCount = "entry_count"
+	CheckDayBalance = "day_balance"
+	CheckContinuity = "continuity"
+)
+
+// Discrepancy describes a mismatch between the statement records and the bank summary
+type Discrepancy struct {
+	Account  string `json:"account" yaml:"account"`
+	Currency string `json:"currency" yaml:"currency"`
+	// Period of the statement, or of two consecutive statements for continuity
+	Period string `json:"period" yaml:"period"`
+	// Date is set for daily checks
+	Date  string `json:"date,omitempty" yaml:"date,omitempty"`
+	Check string `json:"check" yaml:"check"`
+	// Bank is the value reported by the bank, and Computed is the value computed from the records
+	// Counts of entries are reported as amounts without the currency
+	Bank     Money  `json:"bank" yaml:"bank"`
+	Computed Money  `json:"computed" yaml:"computed"`
+	Message  string `json:"message" yaml:"message"`
+}
+
+// Reconcile checks the records of each statement against its summary: debit and credit totals,
+// opening balance plus movements against the closing balance, and entry counts and balances
+// of each day. Closing balances of statements are also checked against opening balances
+// of the consecutive statements of the same account and currency.
+func Reconcile(statements ...*AccountStatement) []*Discrepancy {
+	var list []*Discrepancy
+	for _, st := range statements {
+		list = append(list, reconcileStatement(st)...)
+	}
+	return append(list, reconcileContinuity(statements)...)
+}
+
+func reconcileStatement(st *AccountStatement) []*Discrepancy {
+	var list []*Discrepancy
+	add := func(date, check string, bank, computed Money, format string, args ...any) {
+		list = append(list, &Discrepancy{
+			Account:  st.Account,
+			Currency: st.Currency,
+			Period:   st.StartDate + ".." + st.EndDate,
+			Date:     date,
+			Check:    check,
+			Bank:     bank,
+			Computed: computed,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	if st.Summary == nil {
+		add("", CheckSummary, Money{}, Money{}, "the statement has no summary, create it with --summary")
+		return list
+	}
+	g := &st.Summary.GlobalSummary
+
+	type day struct {
+		count  int
+		debit  Money
+		credit Money
+	}
+	days := make(map[string]*day)
+	var debit, credit Money
+	for i := range st.Records {
+		r := &st.Records[i]
+		debit = debit.Add(r.EntryAmountDebit)
+		credit = credit.Add(r.EntryAmountCredit)
+
+		key := reportDay(r.EntryDate)
+		d := days[key]
+		if d == nil {
+			d = &day{}
+			days[key] = d
+		}
+		d.count++
+		d.debit = d.debit.Add(r.EntryAmountDebit)
+		d.credit = d.credit.Add(r.EntryAmountCredit)
+	}
+
+	if !g.DebitSum.Equal(debit) {
+		add("", CheckDebitSum, g.DebitSum, debit, "debit total of the records differs from the summary")
+	}
+	if !g.CreditSum.Equal(credit) {
+		add("", CheckCreditSum, g.CreditSum, credit, "credit total of the records differs from the summary")
+	}
+	closing := g.InAmount.Add(credit).Sub(debit)
+	if !g.OutAmount.Equal(closing) {
+		add("", CheckClosing, g.OutAmount, closing, "opening balance %s plus movements differs from the closing balance", g.InAmount.In(st.Currency).Format())
+	}
+
+	reported := make(map[string]*DailySummary)
+	dates := make([]string, 0, len(days))
+	for key := range days {
+		dates = append(dates, key)
+	}
+	for i := range st.Summary.DailySummaries {
+		ds := &st.Summary.DailySummaries[i]
+		key := reportDay(ds.Date)
+		reported[key] = ds
+		if days[key] == nil {
+			days[key] = &day{}
+			dates = append(dates, key)
+		}
+	}
+	sort.Strings(dates)
+
+	balance := g.InAmount
+	for _, key := range dates {
+		d := days[key]
+		balance = balance.Add(d.credit).Sub(d.debit)
+
+		ds := reported[key]
+		if ds == nil {
+			add(key, CheckEntryCount, Money{}, MoneyFromInt(int64(d.count)), "the day has records, but no daily summary")
+			continue
+		}
+		if ds.EntryCount != d.count {
+			add(key, CheckEntryCount, MoneyFromInt(int64(ds.EntryCount)), MoneyFromInt(int64(d.count)), "number of records differs from the daily summary")
+		}
+		if !ds.Balance.Equal(balance) {
+			add(key, CheckDayBalance, ds.Balance, balance, "end of day balance differs from the daily summary")
+		}
+	}
+	return list
+}
+
+// reconcileContinuity checks closing balances against opening balances of the next statements,
+// for statements with summaries following each other without a gap
+func reconcileContinuity(statements []*AccountStatement) []*Discrepancy {
+	groups := make(map[string][]*AccountStatement)
+	var keys []string
+	for _, st := range statements {
+		if st.Summary == nil {
+			continue
+		}
+		key := st.Account + " " + st.Currency
+		if groups[key] == nil {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], st)
+	}
+
+	var list []*Discrepancy
+	for _, key := range keys {
+		group := groups[key]
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].StartDate < group[j].StartDate
+		})
+		for i := 1; i < len(group); i++ {
+			prev, next := group[i-1], group[i]
+			if !consecutive(prev.EndDate, next.StartDate) {
+				continue
+			}
+			closing := prev.Summary.GlobalSummary.OutAmount
+			opening := next.Summary.GlobalSummary.InAmount
+			if !opening.Equal(closing) {
+				list = append(list, &Discrepancy{
+					Account:  next.Account,
+					Currency: next.Currency,
+					Period:   prev.StartDate + ".." + next.EndDate,
+					Date:     next.StartDate,
+					Check:    CheckContinuity,
+					Bank:     opening,
+					Computed: closing,
+					Message:  fmt.Sprintf("opening balance differs from the closing balance of %s..%s", prev.StartDate, prev.EndDate),
+				})
+			}
+		}
+	}
+	return list
+}
+
+// consecutive returns true if the next date is the day after the end date, both in DateFormat
+func consecutive(end, next string) bool {
+	e, err1 := time.Parse(DateFormat, end)
+	n, err2 := time.Parse(DateFormat, next)
+	return err1 == nil && err2 == nil && e.AddDate(0, 0, 1).Equal(n)
+}
@@ -0,0 +1,94 @@
+package bogapi_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+func TestReconcile(t *testing.T) {
+	day := func(month time.Month, d int) bogapi.Time {
+		return bogapi.Time(time.Date(2025, month, d, 0, 0, 0, 0, time.UTC))
+	}
+	jan := &bogapi.AccountStatement{
+		Account:   "GE35BG0000000106360001",
+		Currency:  "GEL",
+		StartDate: "2025-01-01",
+		EndDate:   "2025-01-31",
+		Records: []bogapi.Record{
+			{EntryDate: day(1, 10), EntryAmountCredit: bogapi.MoneyFromFloat(50)},
+			{EntryDate: day(1, 10), EntryAmountDebit: bogapi.MoneyFromFloat(20)},
+			{EntryDate: day(1, 20), EntryAmountDebit: bogapi.MoneyFromFloat(10)},
+		},
+		Summary: &bogapi.StatementSummary{
+			GlobalSummary: bogapi.GlobalSummary{InAmount: bogapi.MoneyFromFloat(100), OutAmount: bogapi.MoneyFromFloat(120), CreditSum: bogapi.MoneyFromFloat(50), DebitSum: bogapi.MoneyFromFloat(30)},
+			DailySummaries: []bogapi.DailySummary{
+				{Date: day(1, 10), EntryCount: 2, Balance: bogapi.MoneyFromFloat(130)},
+				{Date: day(1, 20), EntryCount: 1, Balance: bogapi.MoneyFromFloat(120)},
+			},
+		},
+	}
+	feb := &bogapi.AccountStatement{
+		Account:   "GE35BG0000000106360001",
+		Currency:  "GEL",
+		StartDate: "2025-02-01",
+		EndDate:   "2025-02-28",
+		Records: []bogapi.Record{
+			{EntryDate: day(2, 3), EntryAmountCredit: bogapi.MoneyFromFloat(5)},
+		},
+		Summary: &bogapi.StatementSummary{
+			GlobalSummary: bogapi.GlobalSummary{InAmount: bogapi.MoneyFromFloat(120), OutAmount: bogapi.MoneyFromFloat(125), CreditSum: bogapi.MoneyFromFloat(5)},
+			DailySummaries: []bogapi.DailySummary{
+				{Date: day(2, 3), EntryCount: 1, Balance: bogapi.MoneyFromFloat(125)},
+			},
+		},
+	}
+
+	assert.Empty(t, bogapi.Reconcile(feb, jan))
+
+	// a missing record breaks the totals, and the balances from the day on
+	jan.Records = jan.Records[:2]
+	feb.Summary.GlobalSummary.InAmount = bogapi.MoneyFromFloat(119)
+	feb.Summary.GlobalSummary.OutAmount = bogapi.MoneyFromFloat(124)
+	feb.Summary.DailySummaries[0].Balance = bogapi.MoneyFromFloat(124)
+
+	list := bogapi.Reconcile(jan, feb)
+	checks := make([]string, len(list))
+	for i, d := range list {
+		checks[i] = d.Check + " " + d.Date
+	}
+	assert.Equal(t, []string{
+		"debit_sum ",
+		"closing ",
+		"entry_count 2025-01-20",
+		"day_balance 2025-01-20",
+		"continuity 2025-02-01",
+	}, checks)
+	assert.Equal(t, &bogapi.Discrepancy{
+		Account:  "GE35BG0000000106360001",
+		Currency: "GEL",
+		Period:   "2025-01-01..2025-01-31",
+		Check:    bogapi.CheckDebitSum,
+		Bank:     bogapi.MoneyFromFloat(30),
+		Computed: bogapi.MoneyFromFloat(20),
+		Message:  "debit total of the records differs from the summary",
+	}, list[0])
+	assert.Equal(t, "2025-01-01..2025-02-28", list[4].Period)
+	assert.Equal(t, "119", list[4].Bank.String())
+	assert.Equal(t, "120", list[4].Computed.String())
+
+	// records on a day without the daily summary
+	feb.Records = append(feb.Records, bogapi.Record{EntryDate: day(2, 4)})
+	list = bogapi.Reconcile(feb)
+	require.Len(t, list, 1)
+	assert.Equal(t, bogapi.CheckEntryCount, list[0].Check)
+	assert.Equal(t, "2025-02-04", list[0].Date)
+
+	feb.Summary = nil
+	list = bogapi.Reconcile(feb)
+	require.Len(t, list, 1)
+	assert.Equal(t, bogapi.CheckSummary, list[0].Check)
+}
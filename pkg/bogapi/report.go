@@ -6,7 +6,10 @@ import (
 	"io"
 	"sort"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/shopspring/decimal"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -46,46 +49,70 @@ Turnover Debit  in Gel,
 Turnover Credit in Gel,
 Balance at end of day,
 Balance at end of day in Gel,
-Balance
+Balance,
+Balance Mismatch,
+Category,
+Tags,
+Original Amount,
+Original Currency,
+MCC,
+Merchant,
+Authorization Date,
+Card Number,
+Authorization Code
 */
 type Transaction struct {
-	Date                    string  `json:"Date" csv:"Date"`
-	DocumentNumber          string  `json:"DocumentNumber" csv:"Doc N"`
-	OperationID             uint64  `json:"OperationID" csv:"Operation ID"`
-	OperationType           string  `json:"OperationType" csv:"Operation Type"`
-	Account                 string  `json:"Account" csv:"Account"`
-	Currency                string  `json:"Currency" csv:"Currency"`
-	LoroAccount             string  `json:"LoroAccount" csv:"Loro Account"`
-	Debit                   float64 `json:"Debit" csv:"Debit"`
-	Credit                  float64 `json:"Credit" csv:"Credit"`
-	Rate                    float64 `json:"Rate" csv:"Rate"`
-	DebitAmountInGel        float64 `json:"DebitAmountInGel" csv:"Debit Amount in Gel"`
-	CreditAmountInGel       float64 `json:"CreditAmountInGel" csv:"Credit Amount in Gel"`
-	EntryComment            string  `json:"EntryComment" csv:"Entry Comment"`
-	Ref                     string  `json:"Ref" csv:"Ref"`
-	SenderName              string  `json:"SenderName" csv:"Sender Name"`
-	SenderNumberTaxpayer    string  `json:"SenderNumberTaxpayer" csv:"Sender Number Taxpayer"`
-	SenderAccountN          string  `json:"SenderAccountN" csv:"Sender Account N"`
-	SenderBankCode          string  `json:"SenderBankCode" csv:"Sender Bank Code"`
-	SenderBankName          string  `json:"SenderBankName" csv:"Sender Bank Name"`
-	RecipientName           string  `json:"RecipientName" csv:"Recipient Name"`
-	RecipientNumberTaxpayer string  `json:"RecipientNumberTaxpayer" csv:"Recipient Number Taxpayer"`
-	RecipientAccountN       string  `json:"RecipientAccountN" csv:"Recipient Account N"`
-	RecipientBankCode       string  `json:"RecipientBankCode" csv:"Recipient Bank Code"`
-	RecipientBankName       string  `json:"RecipientBankName" csv:"Recipient Bank Name"`
-	Nomination              string  `json:"Nomination" csv:"Nomination"`
-	AdditionalInfo          string  `json:"AdditionalInfo" csv:"Additional Info"`
-	Amount                  float64 `json:"Amount" csv:"Amount"`
-	AmountInGel             float64 `json:"AmountInGel" csv:"Amount in Gel"`
-	TurnoverDebit           float64 `json:"TurnoverDebit" csv:"Turnover Debit"`
-	TurnoverCredit          float64 `json:"TurnoverCredit" csv:"Turnover Credit"`
-	TurnoverDebitInGel      float64 `json:"TurnoverDebitInGel" csv:"Turnover Debit in Gel"`
-	TurnoverCreditInGel     float64 `json:"TurnoverCreditInGel" csv:"Turnover Credit in Gel"`
-	BalanceAtEndOfDay       float64 `json:"BalanceAtEndOfDay" csv:"Balance at end of day"`
-	BalanceAtEndOfDayInGel  float64 `json:"BalanceAtEndOfDayInGel" csv:"Balance at end of day in Gel"`
-	Balance                 float64 `json:"Balance" csv:"Balance"`
+	Date                    string          `json:"Date" csv:"Date"`
+	DocumentNumber          string          `json:"DocumentNumber" csv:"Doc N"`
+	OperationID             uint64          `json:"OperationID" csv:"Operation ID"`
+	OperationType           string          `json:"OperationType" csv:"Operation Type"`
+	Account                 string          `json:"Account" csv:"Account"`
+	Currency                string          `json:"Currency" csv:"Currency"`
+	LoroAccount             string          `json:"LoroAccount" csv:"Loro Account"`
+	Debit                   Money           `json:"Debit" csv:"Debit"`
+	Credit                  Money           `json:"Credit" csv:"Credit"`
+	Rate                    decimal.Decimal `json:"Rate" csv:"Rate"`
+	DebitAmountInGel        Money           `json:"DebitAmountInGel" csv:"Debit Amount in Gel"`
+	CreditAmountInGel       Money           `json:"CreditAmountInGel" csv:"Credit Amount in Gel"`
+	EntryComment            string          `json:"EntryComment" csv:"Entry Comment"`
+	Ref                     string          `json:"Ref" csv:"Ref"`
+	SenderName              string          `json:"SenderName" csv:"Sender Name"`
+	SenderNumberTaxpayer    string          `json:"SenderNumberTaxpayer" csv:"Sender Number Taxpayer"`
+	SenderAccountN          string          `json:"SenderAccountN" csv:"Sender Account N"`
+	SenderBankCode          string          `json:"SenderBankCode" csv:"Sender Bank Code"`
+	SenderBankName          string          `json:"SenderBankName" csv:"Sender Bank Name"`
+	RecipientName           string          `json:"RecipientName" csv:"Recipient Name"`
+	RecipientNumberTaxpayer string          `json:"RecipientNumberTaxpayer" csv:"Recipient Number Taxpayer"`
+	RecipientAccountN       string          `json:"RecipientAccountN" csv:"Recipient Account N"`
+	RecipientBankCode       string          `json:"RecipientBankCode" csv:"Recipient Bank Code"`
+	RecipientBankName       string          `json:"RecipientBankName" csv:"Recipient Bank Name"`
+	Nomination              string          `json:"Nomination" csv:"Nomination"`
+	AdditionalInfo          string          `json:"AdditionalInfo" csv:"Additional Info"`
+	Amount                  Money           `json:"Amount" csv:"Amount"`
+	AmountInGel             Money           `json:"AmountInGel" csv:"Amount in Gel"`
+	TurnoverDebit           Money           `json:"TurnoverDebit" csv:"Turnover Debit"`
+	TurnoverCredit          Money           `json:"TurnoverCredit" csv:"Turnover Credit"`
+	TurnoverDebitInGel      Money           `json:"TurnoverDebitInGel" csv:"Turnover Debit in Gel"`
+	TurnoverCreditInGel     Money           `json:"TurnoverCreditInGel" csv:"Turnover Credit in Gel"`
+	BalanceAtEndOfDay       Money           `json:"BalanceAtEndOfDay" csv:"Balance at end of day"`
+	BalanceAtEndOfDayInGel  Money           `json:"BalanceAtEndOfDayInGel" csv:"Balance at end of day in Gel"`
+	Balance                 Money           `json:"Balance" csv:"Balance"`
+	// BalanceMismatch is set if the computed end of day balance differs from the one reported by the bank
+	BalanceMismatch bool `json:"BalanceMismatch,omitempty" csv:"Balance Mismatch"`
+	// Category and Tags are assigned by categorization rules
+	Category string   `json:"Category,omitempty" csv:"Category"`
+	Tags     []string `json:"Tags,omitempty" csv:"Tags"`
+	// Card is set for card operations, and provides the Original Amount, Original Currency,
+	// MCC, Merchant, Authorization Date, Card Number and Authorization Code columns
+	Card *CardPayment `json:"Card,omitempty" csv:"-"`
 
 	Recort Record `json:"-"`
+
+	// BalanceKnown, BalanceAtEndOfDayKnown and BalanceAtEndOfDayInGelKnown are set for the balances
+	// computed from a known opening balance or reported by the bank, unknown balances are written as empty cells
+	BalanceKnown                bool `json:"BalanceKnown,omitempty" csv:"-"`
+	BalanceAtEndOfDayKnown      bool `json:"BalanceAtEndOfDayKnown,omitempty" csv:"-"`
+	BalanceAtEndOfDayInGelKnown bool `json:"BalanceAtEndOfDayInGelKnown,omitempty" csv:"-"`
 }
 
 type TransactionSlice []Transaction
@@ -115,7 +142,9 @@ func (t TransactionSlice) ToCSV(w io.Writer) error {
 		"Recipient Account N", "Recipient Bank Code", "Recipient Bank Name", "Nomination",
 		"Additional Info", "Amount", "Amount in Gel", "Turnover Debit", "Turnover Credit",
 		"Turnover Debit in Gel", "Turnover Credit in Gel", "Balance at end of day",
-		"Balance at end of day in Gel", "Balance",
+		"Balance at end of day in Gel", "Balance", "Balance Mismatch", "Category", "Tags",
+		"Original Amount", "Original Currency", "MCC", "Merchant", "Authorization Date",
+		"Card Number", "Authorization Code",
 	}
 	if err := writer.Write(header); err != nil {
 		return err
@@ -131,11 +160,11 @@ func (t TransactionSlice) ToCSV(w io.Writer) error {
 			transaction.Account,
 			transaction.Currency,
 			transaction.LoroAccount,
-			formatFloat(transaction.Debit),
-			formatFloat(transaction.Credit),
-			formatFloat(transaction.Rate),
-			formatFloat(transaction.DebitAmountInGel),
-			formatFloat(transaction.CreditAmountInGel),
+			transaction.Debit.Format(),
+			transaction.Credit.Format(),
+			transaction.Rate.String(),
+			transaction.DebitAmountInGel.Format(),
+			transaction.CreditAmountInGel.Format(),
 			transaction.EntryComment,
 			transaction.Ref,
 			transaction.SenderName,
@@ -150,16 +179,20 @@ func (t TransactionSlice) ToCSV(w io.Writer) error {
 			transaction.RecipientBankName,
 			transaction.Nomination,
 			transaction.AdditionalInfo,
-			formatFloat(transaction.Amount),
-			formatFloat(transaction.AmountInGel),
-			formatFloat(transaction.TurnoverDebit),
-			formatFloat(transaction.TurnoverCredit),
-			formatFloat(transaction.TurnoverDebitInGel),
-			formatFloat(transaction.TurnoverCreditInGel),
-			formatFloat(transaction.BalanceAtEndOfDay),
-			formatFloat(transaction.BalanceAtEndOfDayInGel),
-			formatFloat(transaction.Balance),
+			transaction.Amount.Format(),
+			transaction.AmountInGel.Format(),
+			transaction.TurnoverDebit.Format(),
+			transaction.TurnoverCredit.Format(),
+			transaction.TurnoverDebitInGel.Format(),
+			transaction.TurnoverCreditInGel.Format(),
+			formatBalance(transaction.BalanceAtEndOfDay, transaction.BalanceAtEndOfDayKnown),
+			formatBalance(transaction.BalanceAtEndOfDayInGel, transaction.BalanceAtEndOfDayInGelKnown),
+			formatBalance(transaction.Balance, transaction.BalanceKnown),
+			formatMismatch(transaction.BalanceMismatch),
+			transaction.Category,
+			strings.Join(transaction.Tags, ", "),
 		}
+		row = append(row, cardColumns(transaction.Card)...)
 		if err := writer.Write(row); err != nil {
 			return err
 		}
@@ -168,14 +201,56 @@ func (t TransactionSlice) ToCSV(w io.Writer) error {
 	return nil
 }
 
-func formatFloat(f float64) string {
-	return fmt.Sprintf("%.2f", f)
+// cardColumns returns the card operation columns, empty for other transactions
+func cardColumns(c *CardPayment) []string {
+	if c == nil {
+		return make([]string, 7)
+	}
+	return []string{
+		c.Amount.Format(),
+		c.Currency,
+		c.MCC,
+		c.Merchant,
+		formatCardDate(c.AuthorizationDate),
+		c.CardNumber,
+		c.AuthorizationCode,
+	}
+}
+
+func formatCardDate(t Time) string {
+	if time.Time(t).IsZero() {
+		return ""
+	}
+	return t.String()
 }
 
 func formatUInt(i uint64) string {
 	return strconv.FormatUint(i, 10)
 }
 
+// formatBalance returns the formatted balance, or an empty string if it is unknown
+func formatBalance(balance Money, known bool) string {
+	if !known {
+		return ""
+	}
+	return balance.Format()
+}
+
+// balanceCell returns the balance as the value of an Excel cell, which is empty if it is unknown
+func balanceCell(balance Money, known bool) any {
+	if !known {
+		return ""
+	}
+	return balance
+}
+
+func formatMismatch(mismatch bool) string {
+	if mismatch {
+		return "yes"
+	}
+	return ""
+}
+
 func (t TransactionSlice) Dedup() TransactionSlice {
 	transactionMap := make(map[string]Transaction)
 	var transactions TransactionSlice
@@ -191,22 +266,46 @@ func (t TransactionSlice) Dedup() TransactionSlice {
 	return transactions
 }
 
+// Report returns transactions of the statements, sorted by date,
+// with running balances computed from the opening balances of the summaries
 func Report(r *AccountStatements) TransactionSlice {
+	return ReportWithBalances(r, nil)
+}
+
+// ReportWithBalances returns transactions of the statements, sorted by date.
+// Running balances are computed from the opening balance of the statement summary,
+// or, if the summary is missing, from the current balance keyed by "account currency",
+// as returned by Client.AllBalances, which is valid for statements ending today.
+// End of day balances are taken from the daily summaries, and the transactions of days
+// where the computed balance differs are flagged with BalanceMismatch.
+func ReportWithBalances(r *AccountStatements, balances map[string]*AccountBalance) TransactionSlice {
 	var transactions TransactionSlice
 
 	for _, accountStatement := range r.Combined {
-		for _, record := range accountStatement.Records {
+		records := make([]Record, len(accountStatement.Records))
+		copy(records, accountStatement.Records)
+		sort.SliceStable(records, func(i, j int) bool {
+			di, dj := time.Time(records[i].EntryDate), time.Time(records[j].EntryDate)
+			if !di.Equal(dj) {
+				return di.Before(dj)
+			}
+			return records[i].EntryId < records[j].EntryId
+		})
+
+		currency := accountStatement.Currency
+		start := len(transactions)
+		for _, record := range records {
 			transaction := Transaction{
 				Date:                    record.EntryDate.String(),
 				DocumentNumber:          record.EntryDocumentNumber,
 				Account:                 accountStatement.Account,
 				Currency:                accountStatement.Currency,
 				LoroAccount:             record.EntryAccountNumber,
-				Debit:                   record.EntryAmountDebit,
-				Credit:                  record.EntryAmountCredit,
-				Rate:                    record.DocumentRate,
-				DebitAmountInGel:        record.EntryAmountDebitBase,
-				CreditAmountInGel:       record.EntryAmountCreditBase,
+				Debit:                   record.EntryAmountDebit.In(currency),
+				Credit:                  record.EntryAmountCredit.In(currency),
+				Rate:                    decimal.NewFromFloat(record.DocumentRate),
+				DebitAmountInGel:        record.EntryAmountDebitBase.In("GEL"),
+				CreditAmountInGel:       record.EntryAmountCreditBase.In("GEL"),
 				EntryComment:            record.EntryComment,
 				OperationType:           record.DocumentProductGroup,
 				OperationID:             uint64(record.EntryId),
@@ -223,22 +322,27 @@ func Report(r *AccountStatements) TransactionSlice {
 				RecipientBankName:       record.BeneficiaryDetails.BankName,
 				Nomination:              record.DocumentNomination,
 				AdditionalInfo:          record.DocumentInformation,
-				Amount:                  record.EntryAmount,
-				AmountInGel:             record.EntryAmountBase,
-				TurnoverDebit:           record.EntryAmountDebit,
-				TurnoverCredit:          record.EntryAmountCredit,
-				TurnoverDebitInGel:      record.EntryAmountDebitBase,
-				TurnoverCreditInGel:     record.EntryAmountCreditBase,
-				BalanceAtEndOfDay:       record.EntryAmount,
-				BalanceAtEndOfDayInGel:  record.EntryAmountBase,
-				Balance:                 record.EntryAmount,
+				Amount:                  record.EntryAmount.In(currency),
+				AmountInGel:             record.EntryAmountBase.In("GEL"),
+				TurnoverDebit:           record.EntryAmountDebit.In(currency),
+				TurnoverCredit:          record.EntryAmountCredit.In(currency),
+				TurnoverDebitInGel:      record.EntryAmountDebitBase.In("GEL"),
+				TurnoverCreditInGel:     record.EntryAmountCreditBase.In("GEL"),
+				Card:                    record.CardPayment(),
+				Recort:                  record,
 			}
 
 			transactions = append(transactions, transaction)
 		}
+
+		var balance *AccountBalance
+		if balances != nil {
+			balance = balances[accountStatement.Account+" "+accountStatement.Currency]
+		}
+		fillBalances(transactions[start:], accountStatement, balance)
 	}
 
-	sort.Slice(transactions, func(i, j int) bool {
+	sort.SliceStable(transactions, func(i, j int) bool {
 		if transactions[i].Date == transactions[j].Date {
 			return transactions[i].OperationID < transactions[j].OperationID
 		}
@@ -248,6 +352,83 @@ func Report(r *AccountStatements) TransactionSlice {
 	return transactions
 }
 
+// fillBalances computes running and end of day balances of the transactions of one statement,
+// sorted by date. The balances are unknown if the opening balance is unknown,
+// except end of day balances reported by the bank. The current balance is used
+// only for statements which end today, as it includes later transactions otherwise.
+func fillBalances(transactions TransactionSlice, st *AccountStatement, current *AccountBalance) {
+	var opening, openingBase Money
+	known, knownBase := false, false
+	switch {
+	case st.Summary != nil:
+		opening, openingBase = st.Summary.GlobalSummary.InAmount, st.Summary.GlobalSummary.InAmountBase
+		known, knownBase = true, true
+	case current != nil && st.EndDate == NowFunc().In(Location).Format(DateFormat):
+		// the current balance includes all transactions of the statement
+		opening = current.CurrentBalance
+		for _, t := range transactions {
+			opening = opening.Sub(t.Credit.Sub(t.Debit))
+		}
+		known = true
+		if st.Currency == "GEL" {
+			openingBase, knownBase = opening, true
+		}
+	}
+
+	balance, balanceBase := opening.In(st.Currency), openingBase.In("GEL")
+	runningBase := make([]Money, len(transactions))
+	for i := range transactions {
+		t := &transactions[i]
+		balance = balance.Add(t.Credit).Sub(t.Debit)
+		balanceBase = balanceBase.Add(t.CreditAmountInGel).Sub(t.DebitAmountInGel)
+		if known {
+			t.Balance = balance
+			t.BalanceKnown = true
+		}
+		if knownBase {
+			runningBase[i] = balanceBase
+		}
+	}
+
+	reported := make(map[string]*DailySummary)
+	if st.Summary != nil {
+		for i := range st.Summary.DailySummaries {
+			d := &st.Summary.DailySummaries[i]
+			reported[reportDay(d.Date)] = d
+		}
+	}
+
+	// the balance after the last transaction of the day is the end of day balance
+	for end := len(transactions) - 1; end >= 0; {
+		day := reportDay(transactions[end].Recort.EntryDate)
+		first := end
+		for first > 0 && reportDay(transactions[first-1].Recort.EntryDate) == day {
+			first--
+		}
+
+		eod, eodBase := transactions[end].Balance, runningBase[end]
+		eodKnown, eodBaseKnown := known, knownBase
+		mismatch := false
+		if d := reported[day]; d != nil {
+			mismatch = known && !eod.Equal(d.Balance)
+			eod, eodBase = d.Balance.In(st.Currency), d.BalanceBase.In("GEL")
+			eodKnown, eodBaseKnown = true, true
+		}
+		for i := first; i <= end; i++ {
+			transactions[i].BalanceAtEndOfDay = eod
+			transactions[i].BalanceAtEndOfDayInGel = eodBase
+			transactions[i].BalanceMismatch = mismatch
+			transactions[i].BalanceAtEndOfDayKnown = eodKnown
+			transactions[i].BalanceAtEndOfDayInGelKnown = eodBaseKnown
+		}
+		end = first - 1
+	}
+}
+
+func reportDay(t Time) string {
+	return time.Time(t).In(Location).Format(DateFormat)
+}
+
 func (t TransactionSlice) ToExcel(w io.Writer) error {
 	f := excelize.NewFile()
 	sheet := "Statement of Accounts"
@@ -262,7 +443,9 @@ func (t TransactionSlice) ToExcel(w io.Writer) error {
 		"Recipient Account N", "Recipient Bank Code", "Recipient Bank Name", "Nomination",
 		"Additional Info", "Amount", "Amount in Gel", "Turnover Debit", "Turnover Credit",
 		"Turnover Debit in Gel", "Turnover Credit in Gel", "Balance at end of day",
-		"Balance at end of day in Gel", "Balance",
+		"Balance at end of day in Gel", "Balance", "Balance Mismatch", "Category", "Tags",
+		"Original Amount", "Original Currency", "MCC", "Merchant", "Authorization Date",
+		"Card Number", "Authorization Code",
 	}
 	for i, h := range header {
 		col, _ := excelize.ColumnNumberToName(i + 1)
@@ -270,6 +453,7 @@ func (t TransactionSlice) ToExcel(w io.Writer) error {
 	}
 
 	// Write Excel rows
+	styles := make(excelStyles)
 	for i, transaction := range t {
 		row := []any{
 			transaction.Date,
@@ -281,7 +465,7 @@ func (t TransactionSlice) ToExcel(w io.Writer) error {
 			transaction.LoroAccount,
 			transaction.Debit,
 			transaction.Credit,
-			transaction.Rate,
+			transaction.Rate.InexactFloat64(),
 			transaction.DebitAmountInGel,
 			transaction.CreditAmountInGel,
 			transaction.EntryComment,
@@ -304,13 +488,25 @@ func (t TransactionSlice) ToExcel(w io.Writer) error {
 			transaction.TurnoverCredit,
 			transaction.TurnoverDebitInGel,
 			transaction.TurnoverCreditInGel,
-			transaction.BalanceAtEndOfDay,
-			transaction.BalanceAtEndOfDayInGel,
-			transaction.Balance,
+			balanceCell(transaction.BalanceAtEndOfDay, transaction.BalanceAtEndOfDayKnown),
+			balanceCell(transaction.BalanceAtEndOfDayInGel, transaction.BalanceAtEndOfDayInGelKnown),
+			balanceCell(transaction.Balance, transaction.BalanceKnown),
+			formatMismatch(transaction.BalanceMismatch),
+			transaction.Category,
+			strings.Join(transaction.Tags, ", "),
+		}
+		if c := transaction.Card; c != nil {
+			row = append(row, c.Amount, c.Currency, c.MCC, c.Merchant, formatCardDate(c.AuthorizationDate), c.CardNumber, c.AuthorizationCode)
 		}
 		for j, value := range row {
 			col, _ := excelize.ColumnNumberToName(j + 1)
 			cell := fmt.Sprintf("%s%d", col, i+2)
+			if m, ok := value.(Money); ok {
+				// amounts are numbers shown with the decimals of the currency
+				_ = f.SetCellValue(sheet, cell, m.Float64())
+				_ = f.SetCellStyle(sheet, cell, cell, styles.get(f, m.Decimals()))
+				continue
+			}
 			_ = f.SetCellValue(sheet, cell, value)
 		}
 	}
@@ -321,3 +517,19 @@ func (t TransactionSlice) ToExcel(w io.Writer) error {
 
 	return nil
 }
+
+// excelStyles caches number styles by the number of decimals
+type excelStyles map[int32]int
+
+func (s excelStyles) get(f *excelize.File, decimals int32) int {
+	if id, ok := s[decimals]; ok {
+		return id
+	}
+	format := "0"
+	if decimals > 0 {
+		format += "." + strings.Repeat("0", int(decimals))
+	}
+	id, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &format})
+	s[decimals] = id
+	return id
+}
@@ -1,12 +1,18 @@
 package bogapi_test
 
 import (
+	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/xuri/excelize/v2"
 )
 
 func TestReport(t *testing.T) {
@@ -17,6 +23,22 @@ func TestReport(t *testing.T) {
 	err = json.Unmarshal(data, &res)
 	require.NoError(t, err)
 
+	// opening balances of the statements, so the golden file shows the running balances
+	opening := map[string][2]float64{
+		"GE12BG0000000106360002 GEL": {120, 120},
+		"GE12BG0000000106360002 USD": {1000, 2780},
+		"GE12BG0000000106360002 EUR": {250, 730},
+		"GE12BG0000000106360001 GEL": {300, 300},
+	}
+	for _, st := range res.Combined {
+		if o, ok := opening[st.Account+" "+st.Currency]; ok {
+			st.Summary = &bogapi.StatementSummary{GlobalSummary: bogapi.GlobalSummary{
+				InAmount:     bogapi.MoneyFromFloat(o[0]),
+				InAmountBase: bogapi.MoneyFromFloat(o[1]),
+			}}
+		}
+	}
+
 	transactions := bogapi.Report(&res)
 
 	// Create a new CSV file
@@ -26,3 +48,160 @@ func TestReport(t *testing.T) {
 
 	require.NoError(t, transactions.ToCSV(file))
 }
+
+func TestReport_Balances(t *testing.T) {
+	day := func(d, hour int) bogapi.Time {
+		return bogapi.Time(time.Date(2025, 3, d, hour, 0, 0, 0, time.UTC))
+	}
+	st := &bogapi.AccountStatement{
+		Account:  "GE35BG0000000106360001",
+		Currency: "USD",
+		Records: []bogapi.Record{
+			{EntryId: 3, EntryDate: day(2, 9), EntryAmountDebit: bogapi.MoneyFromFloat(30), EntryAmountDebitBase: bogapi.MoneyFromFloat(81)},
+			{EntryId: 1, EntryDate: day(1, 9), EntryAmountCredit: bogapi.MoneyFromFloat(50), EntryAmountCreditBase: bogapi.MoneyFromFloat(135)},
+			{EntryId: 2, EntryDate: day(1, 10), EntryAmountDebit: bogapi.MoneyFromFloat(20), EntryAmountDebitBase: bogapi.MoneyFromFloat(54)},
+		},
+		Summary: &bogapi.StatementSummary{
+			GlobalSummary: bogapi.GlobalSummary{InAmount: bogapi.MoneyFromFloat(100), InAmountBase: bogapi.MoneyFromFloat(270)},
+			DailySummaries: []bogapi.DailySummary{
+				{Date: day(1, 0), Balance: bogapi.MoneyFromFloat(130), BalanceBase: bogapi.MoneyFromFloat(351)},
+				{Date: day(2, 0), Balance: bogapi.MoneyFromFloat(99), BalanceBase: bogapi.MoneyFromFloat(270)},
+			},
+		},
+	}
+
+	res := bogapi.Report(&bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{st}})
+	require.Len(t, res, 3)
+	assert.Equal(t, []string{"150.00", "130.00", "100.00"}, []string{res[0].Balance.Format(), res[1].Balance.Format(), res[2].Balance.Format()})
+	assert.Equal(t, "130.00", res[0].BalanceAtEndOfDay.Format())
+	assert.Equal(t, "351.00", res[0].BalanceAtEndOfDayInGel.Format())
+	assert.Equal(t, "130.00", res[1].BalanceAtEndOfDay.Format())
+	assert.False(t, res[0].BalanceMismatch)
+	assert.False(t, res[1].BalanceMismatch)
+	// the bank reports 99 at the end of the second day, while the records add up to 100
+	assert.Equal(t, "99.00", res[2].BalanceAtEndOfDay.Format())
+	assert.True(t, res[2].BalanceMismatch)
+
+	// without the summary, the opening balance of the statement ending today
+	// is derived from the current balance
+	st.Summary = nil
+	st.EndDate = time.Now().In(bogapi.Location).Format(bogapi.DateFormat)
+	balances := map[string]*bogapi.AccountBalance{
+		"GE35BG0000000106360001 USD": {CurrentBalance: bogapi.MoneyFromFloat(100)},
+	}
+	res = bogapi.ReportWithBalances(&bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{st}}, balances)
+	require.Len(t, res, 3)
+	assert.Equal(t, []string{"150.00", "130.00", "100.00"}, []string{res[0].Balance.Format(), res[1].Balance.Format(), res[2].Balance.Format()})
+	assert.Equal(t, "130.00", res[1].BalanceAtEndOfDay.Format())
+	assert.Equal(t, "100.00", res[2].BalanceAtEndOfDay.Format())
+	// the balance in GEL is unknown for a foreign currency
+	assert.Zero(t, res[2].BalanceAtEndOfDayInGel)
+	assert.False(t, res[2].BalanceMismatch)
+
+	// the current balance includes later transactions of the statement ended earlier
+	st.EndDate = "2025-03-31"
+	res = bogapi.ReportWithBalances(&bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{st}}, balances)
+	assert.Zero(t, res[2].Balance)
+
+	// without the opening balance, the balances are unknown, and written as empty cells
+	st.Records[0].DocumentRate = 2.70125
+	res = bogapi.Report(&bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{st}})
+	assert.Zero(t, res[2].Balance)
+	assert.Zero(t, res[2].BalanceAtEndOfDay)
+
+	var buf bytes.Buffer
+	require.NoError(t, res.ToCSV(&buf))
+	rows, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	for i, h := range rows[0] {
+		if strings.HasPrefix(h, "Balance") {
+			assert.Empty(t, rows[1][i], h)
+		}
+	}
+	// rates are written in full
+	assert.Equal(t, "Rate", rows[0][9])
+	assert.Equal(t, "2.70125", rows[3][9])
+}
+
+func TestTransactionSlice_JSON(t *testing.T) {
+	data, err := os.ReadFile("testdata/statement_feb.json")
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	// the opening balance is known for one statement only
+	for _, st := range doc.Combined {
+		if st.Account == "GE12BG0000000106360002" && st.Currency == "EUR" {
+			st.Summary = &bogapi.StatementSummary{GlobalSummary: bogapi.GlobalSummary{
+				InAmount:     bogapi.MoneyFromFloat(250),
+				InAmountBase: bogapi.MoneyFromFloat(730),
+			}}
+		}
+	}
+	transactions := bogapi.Report(&doc)
+
+	data, err = json.Marshal(transactions)
+	require.NoError(t, err)
+	assert.Contains(t, string(data), `"BalanceKnown":true`)
+
+	var decoded bogapi.TransactionSlice
+	require.NoError(t, json.Unmarshal(data, &decoded))
+
+	// unknown balances stay empty after the round trip, while zero balances are written
+	var expected, actual bytes.Buffer
+	require.NoError(t, transactions.ToCSV(&expected))
+	require.NoError(t, decoded.ToCSV(&actual))
+	assert.Equal(t, expected.String(), actual.String())
+	assert.Contains(t, actual.String(), ",,,")
+}
+
+func TestTransactionSlice_ToExcel(t *testing.T) {
+	doc := &bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{
+		{
+			Account:  "GE35BG0000000106360001",
+			Currency: "USD",
+			Records: []bogapi.Record{
+				{
+					EntryId: 1, EntryAmountCredit: bogapi.MoneyFromFloat(1500.5), EntryAmountCreditBase: bogapi.MoneyFromFloat(4051.35),
+					EntryComment: "Refund - Amount: JPY 220000; MCC: 4722; Card Number: 42222*******0002",
+				},
+			},
+		},
+		{
+			Account:  "GE35BG0000000106360001",
+			Currency: "JPY",
+			Records: []bogapi.Record{
+				{EntryId: 2, EntryAmountDebit: bogapi.MoneyFromFloat(2500)},
+			},
+		},
+	}}
+
+	var buf bytes.Buffer
+	require.NoError(t, bogapi.Report(doc).ToExcel(&buf))
+
+	f, err := excelize.OpenReader(&buf)
+	require.NoError(t, err)
+	sheet := "Statement of Accounts"
+	cell := func(name string, opts ...excelize.Options) string {
+		v, err := f.GetCellValue(sheet, name, opts...)
+		require.NoError(t, err)
+		return v
+	}
+	// amounts are numbers, shown with the decimals of the currency
+	assert.Equal(t, "1500.50", cell("I2"))
+	assert.Equal(t, "1500.5", cell("I2", excelize.Options{RawCellValue: true}))
+	assert.Equal(t, "4051.35", cell("L2"))
+	assert.Equal(t, "2500", cell("H3"))
+	// card operation columns
+	assert.Equal(t, "Original Amount", cell("AM1"))
+	assert.Equal(t, "220000", cell("AM2"))
+	assert.Equal(t, "JPY", cell("AN2"))
+	assert.Equal(t, "4722", cell("AO2"))
+	assert.Equal(t, "42222*******0002", cell("AR2"))
+	assert.Empty(t, cell("AM3"))
+	// unknown balances are empty
+	assert.Equal(t, "Balance", cell("AI1"))
+	assert.Empty(t, cell("AI2"))
+	assert.Empty(t, cell("AG2"))
+}
@@ -0,0 +1,136 @@
+package bogapi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// AuthEndpoint is the key of the token endpoint in TransportConfig.Timeouts
+const AuthEndpoint = "auth"
+
+// TransportConfig specifies how the client connects to the API,
+// such as through a corporate proxy with its own root CA
+type TransportConfig struct {
+	// Proxy specifies the URL of the HTTP proxy,
+	// HTTPS_PROXY and NO_PROXY environment are used by default
+	Proxy string `json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	// CAFile specifies PEM encoded root CAs, trusted in addition to the system ones
+	CAFile string `json:"ca_file,omitempty" yaml:"ca_file,omitempty"`
+	// CertFile and KeyFile specify PEM encoded client certificate and key for mutual TLS
+	CertFile string `json:"cert_file,omitempty" yaml:"cert_file,omitempty"`
+	KeyFile  string `json:"key_file,omitempty" yaml:"key_file,omitempty"`
+	// MinTLSVersion specifies the minimum TLS version: 1.2 or 1.3, 1.2 by default
+	MinTLSVersion string `json:"min_tls_version,omitempty" yaml:"min_tls_version,omitempty"`
+	// Timeout specifies in seconds the request timeout, overriding the timeout of the client
+	Timeout int `json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	// Timeouts specifies in seconds the request timeout per endpoint,
+	// keyed by the API path prefix, such as /api/statement, or AuthEndpoint for the token endpoint
+	Timeouts map[string]int `json:"timeouts,omitempty" yaml:"timeouts,omitempty"`
+}
+
+// ClientOption customizes the client created by CreateProfileClient
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	roundTripper http.RoundTripper
+	cache        *Cache
+}
+
+// WithRoundTripper specifies a custom transport for all requests of the client,
+// the proxy and TLS settings of TransportConfig are ignored in this case
+func WithRoundTripper(rt http.RoundTripper) ClientOption {
+	return func(o *clientOptions) {
+		o.roundTripper = rt
+	}
+}
+
+// RoundTripper returns the transport with the proxy and TLS settings
+func (t *TransportConfig) RoundTripper() (http.RoundTripper, error) {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+
+	if t.Proxy != "" {
+		proxy, err := url.Parse(t.Proxy)
+		if err != nil || proxy.Host == "" {
+			return nil, errors.Errorf("invalid proxy URL: %s", t.Proxy)
+		}
+		tr.Proxy = http.ProxyURL(proxy)
+	}
+
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	switch t.MinTLSVersion {
+	case "", "1.2":
+	case "1.3":
+		tlsCfg.MinVersion = tls.VersionTLS13
+	default:
+		return nil, errors.Errorf("unsupported TLS version: %s, expected 1.2 or 1.3", t.MinTLSVersion)
+	}
+
+	if t.CAFile != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		file, _ := homedir.Expand(t.CAFile)
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to read CA file")
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, errors.Errorf("no certificates found in CA file: %s", t.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		certFile, _ := homedir.Expand(t.CertFile)
+		keyFile, _ := homedir.Expand(t.KeyFile)
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.WithMessage(err, "failed to load client certificate")
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	tr.TLSClientConfig = tlsCfg
+	return tr, nil
+}
+
+// timeout returns the request timeout for the endpoint, matching the longest path prefix,
+// or zero if not configured
+func (t *TransportConfig) timeout(endpoint string) time.Duration {
+	if t == nil {
+		return 0
+	}
+	matched := ""
+	seconds := 0
+	for prefix, sec := range t.Timeouts {
+		if strings.HasPrefix(endpoint, prefix) && len(prefix) > len(matched) {
+			matched = prefix
+			seconds = sec
+		}
+	}
+	if seconds == 0 {
+		seconds = t.Timeout
+	}
+	return time.Second * time.Duration(seconds)
+}
+
+// maxTimeout returns the longest configured timeout, or zero if none
+func (t *TransportConfig) maxTimeout() time.Duration {
+	if t == nil {
+		return 0
+	}
+	seconds := t.Timeout
+	for _, sec := range t.Timeouts {
+		seconds = max(seconds, sec)
+	}
+	return time.Second * time.Duration(seconds)
+}
@@ -0,0 +1,144 @@
+package bogapi_test
+
+import (
+	"context"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"gopkg.in/yaml.v3"
+)
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func TestTransport_CAFile(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(srv.Close)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	require.NoError(t, os.WriteFile(caFile, ca, 0600))
+
+	// the test server is not trusted by default
+	_, err := (&http.Client{Transport: http.DefaultTransport}).Get(srv.URL)
+	require.Error(t, err)
+
+	tr, err := (&bogapi.TransportConfig{CAFile: caFile, MinTLSVersion: "1.3"}).RoundTripper()
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tr}).Get(srv.URL)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestTransport_Proxy(t *testing.T) {
+	var proxied atomic.Value
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Store(r.URL.String())
+		_, _ = io.WriteString(w, "ok")
+	}))
+	t.Cleanup(proxy.Close)
+
+	tr, err := (&bogapi.TransportConfig{Proxy: proxy.URL}).RoundTripper()
+	require.NoError(t, err)
+	resp, err := (&http.Client{Transport: tr}).Get("http://api.bog.invalid/api/accounts")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, "http://api.bog.invalid/api/accounts", proxied.Load())
+}
+
+func TestTransport_Errors(t *testing.T) {
+	dir := t.TempDir()
+	invalidPEM := filepath.Join(dir, "invalid.pem")
+	require.NoError(t, os.WriteFile(invalidPEM, []byte("not a certificate"), 0600))
+
+	tcases := []struct {
+		cfg bogapi.TransportConfig
+		err string
+	}{
+		{bogapi.TransportConfig{Proxy: "proxy:8080"}, "invalid proxy URL: proxy:8080"},
+		{bogapi.TransportConfig{MinTLSVersion: "1.1"}, "unsupported TLS version: 1.1, expected 1.2 or 1.3"},
+		{bogapi.TransportConfig{CAFile: invalidPEM}, "no certificates found in CA file: " + invalidPEM},
+		{bogapi.TransportConfig{CAFile: filepath.Join(dir, "missing.pem")}, "failed to read CA file: open " + filepath.Join(dir, "missing.pem") + ": no such file or directory"},
+		{bogapi.TransportConfig{CertFile: invalidPEM, KeyFile: invalidPEM}, "failed to load client certificate: tls: failed to find any PEM data in certificate input"},
+	}
+	for _, tc := range tcases {
+		_, err := tc.cfg.RoundTripper()
+		assert.EqualError(t, err, tc.err)
+	}
+}
+
+func TestClient_WithRoundTripper(t *testing.T) {
+	srv := newTestServer(t)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, srv.WriteConfig(cfgFile))
+
+	var count atomic.Int32
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		count.Add(1)
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	client, err := bogapi.CreateClient(cfgFile, 6, bogapi.WithRoundTripper(rt))
+	require.NoError(t, err)
+	_, err = client.Balance(context.Background(), "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+	assert.Equal(t, int32(2), count.Load())
+}
+
+func TestClient_EndpointTimeout(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := srv.Config()
+	cfg.Transport = &bogapi.TransportConfig{
+		Timeouts: map[string]int{"/api/accounts": 1},
+	}
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+
+	// the balance endpoint hangs until the request is cancelled
+	rt := roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path == "/api/accounts/GE12BG0000000106360002/USD" {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+
+	client, err := bogapi.CreateClient(cfgFile, 60, bogapi.WithRoundTripper(rt))
+	require.NoError(t, err)
+	_, err = client.Balance(context.Background(), "GE12BG0000000106360002", "USD")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "context deadline exceeded")
+
+	_, err = client.TodayActivities(context.Background(), "GE12BG0000000106360002", "USD")
+	require.NoError(t, err)
+}
+
+func TestClient_InvalidTransport(t *testing.T) {
+	srv := newTestServer(t)
+	cfg := srv.Config()
+	cfg.Transport = &bogapi.TransportConfig{MinTLSVersion: "1.0"}
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+
+	_, err = bogapi.CreateClient(cfgFile, 6)
+	assert.EqualError(t, err, "invalid transport: unsupported TLS version: 1.0, expected 1.2 or 1.3")
+}
@@ -0,0 +1,163 @@
+// Package categorize assigns categories and tags to statement records by rules,
+// declared in rules.yaml in the storage folder.
+package categorize
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is the name of the rules file in the storage folder
+const DefaultFile = "rules.yaml"
+
+// Rules is the ordered list of rules, the first matching rule assigns the category
+type Rules struct {
+	Rules []*Rule `json:"rules" yaml:"rules"`
+}
+
+// Rule assigns the category and tags to the records matching all of its conditions,
+// empty conditions match all records
+type Rule struct {
+	// Name identifies the rule in explanations, #N by the position in the file if empty
+	Name     string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Category string   `json:"category" yaml:"category"`
+	Tags     []string `json:"tags,omitempty" yaml:"tags,omitempty"`
+
+	// Counterparty matches a part of the sender or beneficiary name, case insensitive
+	Counterparty string `json:"counterparty,omitempty" yaml:"counterparty,omitempty"`
+	// INN matches the taxpayer number of the sender or beneficiary
+	INN string `json:"inn,omitempty" yaml:"inn,omitempty"`
+	// IBAN matches the account number of the sender or beneficiary
+	IBAN string `json:"iban,omitempty" yaml:"iban,omitempty"`
+	// ProductGroup matches DocumentProductGroup, such as CCO, PMI, TRN or FEE, case insensitive
+	ProductGroup string `json:"product_group,omitempty" yaml:"product_group,omitempty"`
+	// MCC lists merchant category codes of card payments
+	MCC []string `json:"mcc,omitempty" yaml:"mcc,omitempty"`
+	// Side is "debit" for outgoing, or "credit" for incoming records
+	Side string `json:"side,omitempty" yaml:"side,omitempty"`
+	// MinAmount and MaxAmount specify the range of the debit or credit amount
+	MinAmount bogapi.Money `json:"min_amount,omitempty" yaml:"min_amount,omitempty"`
+	MaxAmount bogapi.Money `json:"max_amount,omitempty" yaml:"max_amount,omitempty"`
+	// Comment and Nomination are regular expressions matched against
+	// EntryComment and DocumentNomination, use (?i) to ignore case
+	Comment    string `json:"comment,omitempty" yaml:"comment,omitempty"`
+	Nomination string `json:"nomination,omitempty" yaml:"nomination,omitempty"`
+
+	query      *bogapi.Query
+	comment    *regexp.Regexp
+	nomination *regexp.Regexp
+}
+
+// Load returns the rules of the file
+func Load(file string) (*Rules, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read rules")
+	}
+	rules, err := Parse(data)
+	if err != nil {
+		return nil, errors.WithMessagef(err, "invalid rules: %s", file)
+	}
+	return rules, nil
+}
+
+// Parse returns the rules of YAML document, with compiled conditions
+func Parse(data []byte) (*Rules, error) {
+	rules := new(Rules)
+	if err := yaml.Unmarshal(data, rules); err != nil {
+		return nil, errors.WithMessage(err, "failed to parse rules")
+	}
+	for i, rule := range rules.Rules {
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("#%d", i+1)
+		}
+		if err := rule.compile(); err != nil {
+			return nil, errors.WithMessagef(err, "rule %s", rule.Name)
+		}
+	}
+	return rules, nil
+}
+
+func (r *Rule) compile() error {
+	if r.Category == "" {
+		return errors.New("category is required")
+	}
+	r.query = &bogapi.Query{
+		Side:         r.Side,
+		MinAmount:    r.MinAmount,
+		MaxAmount:    r.MaxAmount,
+		Counterparty: r.Counterparty,
+		INN:          r.INN,
+		IBAN:         r.IBAN,
+		ProductGroup: r.ProductGroup,
+	}
+	if err := r.query.Validate(); err != nil {
+		return err
+	}
+
+	var err error
+	if r.Comment != "" {
+		if r.comment, err = regexp.Compile(r.Comment); err != nil {
+			return errors.WithMessage(err, "invalid comment")
+		}
+	}
+	if r.Nomination != "" {
+		if r.nomination, err = regexp.Compile(r.Nomination); err != nil {
+			return errors.WithMessage(err, "invalid nomination")
+		}
+	}
+	return nil
+}
+
+// Match returns true if the record matches all conditions of the rule
+func (r *Rule) Match(rec *bogapi.Record) bool {
+	if !r.query.Match(rec) {
+		return false
+	}
+	if len(r.MCC) > 0 {
+		card := rec.CardPayment()
+		if card == nil || !slices.Contains(r.MCC, card.MCC) {
+			return false
+		}
+	}
+	if r.comment != nil && !r.comment.MatchString(rec.EntryComment) {
+		return false
+	}
+	if r.nomination != nil && !r.nomination.MatchString(rec.DocumentNomination) {
+		return false
+	}
+	return true
+}
+
+// Match returns the first rule matching the record, or nil if the record is uncategorized
+func (r *Rules) Match(rec *bogapi.Record) *Rule {
+	for _, rule := range r.Rules {
+		if rule.Match(rec) {
+			return rule
+		}
+	}
+	return nil
+}
+
+// Apply sets the category and tags of the transactions, and returns the matched rules
+// in the order of the transactions, nil for uncategorized ones
+func (r *Rules) Apply(transactions bogapi.TransactionSlice) []*Rule {
+	matched := make([]*Rule, len(transactions))
+	for i := range transactions {
+		t := &transactions[i]
+		rule := r.Match(&t.Recort)
+		if rule == nil {
+			t.Category, t.Tags = "", nil
+			continue
+		}
+		t.Category, t.Tags = rule.Category, rule.Tags
+		matched[i] = rule
+	}
+	return matched
+}
@@ -0,0 +1,112 @@
+package categorize_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/categorize"
+)
+
+const testRules = `
+rules:
+  - name: telecom
+    category: utilities
+    tags: [phone, internet]
+    mcc: [4812, 4814]
+  - category: bank fees
+    product_group: fee
+    side: debit
+  - name: clients
+    category: income
+    tags: [consulting]
+    counterparty: avaleris
+    iban: GE00BG0000000000000001
+    min_amount: 1000
+  - name: funding
+    category: transfers
+    side: credit
+    max_amount: 500
+    nomination: (?i)\bfunding$
+  - name: conversion
+    category: exchange
+    comment: ^ვალუტის გაცვლითი ოპერაცია
+`
+
+func TestRules(t *testing.T) {
+	data, err := os.ReadFile("../bogapi/testdata/statement_feb.json")
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	rules, err := categorize.Parse([]byte(testRules))
+	require.NoError(t, err)
+	require.Len(t, rules.Rules, 5)
+	assert.Equal(t, "#2", rules.Rules[1].Name)
+	assert.Equal(t, "1000", rules.Rules[2].MinAmount.String())
+
+	transactions := bogapi.Report(&doc)
+	matched := rules.Apply(transactions)
+	require.Len(t, matched, len(transactions))
+
+	byRule := make(map[string]int)
+	for i, rule := range matched {
+		name := ""
+		if rule != nil {
+			name = rule.Name
+			assert.Equal(t, rule.Category, transactions[i].Category)
+		}
+		byRule[name]++
+	}
+	// the credit fee and the payment from a client in another bank are uncategorized
+	assert.Equal(t, map[string]int{"telecom": 1, "#2": 2, "funding": 1, "conversion": 2, "": 2}, byRule)
+
+	for _, tr := range transactions {
+		if tr.Category == "utilities" {
+			assert.Equal(t, []string{"phone", "internet"}, tr.Tags)
+			assert.Equal(t, "135.00", tr.Debit.Format())
+		}
+	}
+
+	// the first matching rule wins
+	rec := &bogapi.Record{
+		EntryAmountDebit:     bogapi.MoneyFromFloat(10),
+		DocumentProductGroup: "FEE",
+		EntryComment:         "ვალუტის გაცვლითი ოპერაცია",
+	}
+	assert.Equal(t, "#2", rules.Match(rec).Name)
+	rec.EntryAmountDebit, rec.EntryAmountCredit = bogapi.Money{}, bogapi.MoneyFromFloat(10)
+	assert.Equal(t, "conversion", rules.Match(rec).Name)
+	rec.EntryComment = ""
+	assert.Nil(t, rules.Match(rec))
+}
+
+func TestParse_Errors(t *testing.T) {
+	tcases := []struct {
+		rules string
+		err   string
+	}{
+		{"rules:\n  - name: empty\n", "rule empty: category is required"},
+		{"rules:\n  - category: x\n    side: both\n", "rule #1: invalid side: both, expected debit or credit"},
+		{"rules:\n  - category: x\n    min_amount: 10\n    max_amount: 5\n", "rule #1: invalid amount range: 10.00 is above 5.00"},
+		{"rules:\n  - category: x\n    comment: '('\n", "rule #1: invalid comment: error parsing regexp: missing closing ): `(`"},
+		{"rules:\n  - category: x\n    min_amount: abc\n", `failed to parse rules: invalid amount: "abc"`},
+	}
+	for _, tc := range tcases {
+		_, err := categorize.Parse([]byte(tc.rules))
+		assert.EqualError(t, err, tc.err)
+	}
+
+	file := filepath.Join(t.TempDir(), categorize.DefaultFile)
+	_, err := categorize.Load(file)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to read rules")
+
+	require.NoError(t, os.WriteFile(file, []byte("rules:\n  - name: empty\n"), 0600))
+	_, err = categorize.Load(file)
+	assert.EqualError(t, err, "invalid rules: "+file+": rule empty: category is required")
+}
@@ -1,6 +1,7 @@
 package print
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -71,6 +72,25 @@ func Map(w io.Writer, header []string, vals map[string]string) {
 	fmt.Fprintln(w)
 }
 
+// Table prints rows with the header
+func Table(w io.Writer, header []string, rows [][]string) {
+	table := tablewriter.NewTable(w)
+	table.Header(header)
+	for _, row := range rows {
+		_ = table.Append(row)
+	}
+	_ = table.Render()
+	fmt.Fprintln(w)
+}
+
+// CSV prints rows with the header as CSV
+func CSV(w io.Writer, header []string, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	_ = writer.Write(header)
+	_ = writer.WriteAll(rows)
+	return errors.WithMessage(writer.Error(), "failed to write CSV")
+}
+
 // Strings prints strings
 func Strings(w io.Writer, res []string) {
 	for _, r := range res {
@@ -0,0 +1,97 @@
+package rates
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+// Deviation is the difference between the document rate of the statement record
+// and the official rate of the same day
+type Deviation struct {
+	Account      string  `json:"account" yaml:"account"`
+	Currency     string  `json:"currency" yaml:"currency"`
+	Date         string  `json:"date" yaml:"date"`
+	Document     string  `json:"document" yaml:"document"`
+	Nomination   string  `json:"nomination,omitempty" yaml:"nomination,omitempty"`
+	DocumentRate float64 `json:"document_rate" yaml:"document_rate"`
+	OfficialRate float64 `json:"official_rate" yaml:"official_rate"`
+	// Percent is the deviation of the document rate from the official rate
+	Percent float64 `json:"percent" yaml:"percent"`
+}
+
+// CheckStatements compares the document rates of the statement records with
+// the official rates of the same day, and returns the records deviating by more
+// than tolerance percent. Records without a document rate are skipped.
+// Conversions between two foreign currencies are compared with the official cross rate.
+func (s *Service) CheckStatements(ctx context.Context, doc *bogapi.AccountStatements, tolerance float64) ([]*Deviation, error) {
+	var list []*Deviation
+	for _, st := range doc.Combined {
+		for _, r := range st.Records {
+			if r.DocumentRate == 0 {
+				continue
+			}
+			currency := recordCurrency(&r, st.Currency)
+			if currency == "" {
+				continue
+			}
+
+			date := time.Time(r.EntryDate)
+			if r.DocumentValueDate != nil && !time.Time(*r.DocumentValueDate).IsZero() {
+				date = time.Time(*r.DocumentValueDate)
+			}
+
+			from, to := r.DocumentSourceCurrency, r.DocumentDestinationCurrency
+			if from == "" || to == "" || from == "GEL" || to == "GEL" || from == to {
+				// the rate is GEL for one unit of the foreign currency
+				from, to = currency, "GEL"
+			} else {
+				currency = from + "/" + to
+			}
+			official, err := s.crossRate(ctx, from, to, date)
+			if err != nil {
+				return nil, err
+			}
+
+			d := &Deviation{
+				Account:      st.Account,
+				Currency:     currency,
+				Date:         dateKey(date),
+				Document:     r.EntryDocumentNumber,
+				Nomination:   r.DocumentNomination,
+				DocumentRate: r.DocumentRate,
+				OfficialRate: official,
+			}
+			d.Percent = math.Round((d.DocumentRate/d.OfficialRate-1)*10000) / 100
+			if math.Abs(d.Percent) > tolerance {
+				list = append(list, d)
+			}
+		}
+	}
+	return list, nil
+}
+
+// crossRate returns the official amount of the to currency for one unit of the from currency
+func (s *Service) crossRate(ctx context.Context, from, to string, date time.Time) (float64, error) {
+	a, err := s.Official(ctx, from, date)
+	if err != nil {
+		return 0, err
+	}
+	b, err := s.Official(ctx, to, date)
+	if err != nil {
+		return 0, err
+	}
+	return a.PerUnit() / b.PerUnit(), nil
+}
+
+// recordCurrency returns the foreign currency of the record, or empty if the record is in GEL
+func recordCurrency(r *bogapi.Record, currency string) string {
+	for _, c := range []string{r.DocumentSourceCurrency, r.DocumentDestinationCurrency, currency} {
+		if c != "" && c != "GEL" {
+			return c
+		}
+	}
+	return ""
+}
@@ -0,0 +1,342 @@
+// Package rates provides BOG commercial and National Bank of Georgia official exchange rates
+package rates
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/effective-security/x/fileutil"
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+var logger = xlog.NewPackageLogger("github.com/tbilicode/bogclient/pkg", "rates")
+
+const (
+	// DefaultNBGURL is the endpoint of the National Bank of Georgia official rates
+	DefaultNBGURL = "https://nbg.gov.ge/gw/api/ct/monetarypolicy/currencies/en/json"
+	// DateFormat is the format of the rate dates
+	DateFormat = "2006-01-02"
+	// DefaultTimeout is the default timeout of NBG requests
+	DefaultTimeout = 10 * time.Second
+)
+
+// NowFunc is a function that returns the current time
+var NowFunc = time.Now
+
+// OfficialRate is the NBG official rate of the currency for a date
+type OfficialRate struct {
+	Currency string `json:"code" yaml:"code"`
+	Name     string `json:"name,omitempty" yaml:"name,omitempty"`
+	// Quantity is the number of currency units the rate is quoted for, such as 100 JPY
+	Quantity int `json:"quantity" yaml:"quantity"`
+	// Rate is the amount of GEL for Quantity units of the currency
+	Rate          float64 `json:"rate" yaml:"rate"`
+	ValidFromDate string  `json:"validFromDate,omitempty" yaml:"valid_from_date,omitempty"`
+}
+
+// PerUnit returns the amount of GEL for one unit of the currency
+func (r *OfficialRate) PerUnit() float64 {
+	if r.Quantity > 1 {
+		return r.Rate / float64(r.Quantity)
+	}
+	return r.Rate
+}
+
+// nbgResponse is the response of NBG rates API
+type nbgResponse struct {
+	Date       string          `json:"date"`
+	Currencies []*OfficialRate `json:"currencies"`
+}
+
+// Rate is the official and commercial rate of the currency for a date
+type Rate struct {
+	Currency string `json:"currency" yaml:"currency"`
+	Date     string `json:"date" yaml:"date"`
+	// Official is the NBG rate, in GEL for one unit of the currency
+	Official float64 `json:"official" yaml:"official"`
+	// Buy and Sell are the current BOG commercial rates, only available for today
+	Buy  float64 `json:"buy,omitempty" yaml:"buy,omitempty"`
+	Sell float64 `json:"sell,omitempty" yaml:"sell,omitempty"`
+}
+
+// CommercialSource provides BOG commercial rates, implemented by bogapi.Client
+type CommercialSource interface {
+	CommercialRate(ctx context.Context, currency string) (*bogapi.CommercialRate, error)
+}
+
+// Service returns official and commercial rates. Official rates for past days
+// are cached in memory and, if the cache directory is set, on disk.
+// It is safe for concurrent use.
+type Service struct {
+	nbgURL     string
+	httpClient *http.Client
+	dir        string
+	offline    bool
+	commercial CommercialSource
+
+	lock sync.Mutex
+	days map[string]map[string]*OfficialRate
+}
+
+// New returns a new service with the default NBG endpoint and no disk cache
+func New() *Service {
+	return &Service{
+		nbgURL:     DefaultNBGURL,
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		days:       make(map[string]map[string]*OfficialRate),
+	}
+}
+
+// WithNBGURL allows to specify the NBG endpoint, such as a local stand-in
+func (s *Service) WithNBGURL(nbgURL string) *Service {
+	if nbgURL != "" {
+		s.nbgURL = nbgURL
+	}
+	return s
+}
+
+// WithHTTPClient allows to specify the client for NBG requests
+func (s *Service) WithHTTPClient(httpClient *http.Client) *Service {
+	s.httpClient = httpClient
+	return s
+}
+
+// WithCacheDir allows to specify the folder to cache official rates of past days
+func (s *Service) WithCacheDir(dir string) *Service {
+	s.dir = dir
+	return s
+}
+
+// WithOffline allows to serve official rates only from the disk cache
+func (s *Service) WithOffline(offline bool) *Service {
+	s.offline = offline
+	return s
+}
+
+// WithCommercial allows to specify the source of commercial rates
+func (s *Service) WithCommercial(src CommercialSource) *Service {
+	s.commercial = src
+	return s
+}
+
+// Commercial returns the current BOG commercial rate of the currency
+func (s *Service) Commercial(ctx context.Context, currency string) (*bogapi.CommercialRate, error) {
+	if s.commercial == nil {
+		return nil, errors.New("commercial rates are not available: BOG credentials are not configured")
+	}
+	return s.commercial.CommercialRate(ctx, strings.ToUpper(currency))
+}
+
+// Official returns the NBG official rate of the currency for the date
+func (s *Service) Official(ctx context.Context, currency string, date time.Time) (*OfficialRate, error) {
+	currency = strings.ToUpper(currency)
+	if currency == "GEL" {
+		return &OfficialRate{Currency: currency, Quantity: 1, Rate: 1}, nil
+	}
+
+	day, err := s.OfficialRates(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+	rate := day[currency]
+	if rate == nil {
+		return nil, errors.Errorf("official rate not found: %s on %s", currency, dateKey(date))
+	}
+	return rate, nil
+}
+
+// OfficialRates returns the NBG official rates of all currencies for the date
+func (s *Service) OfficialRates(ctx context.Context, date time.Time) (map[string]*OfficialRate, error) {
+	key := dateKey(date)
+	// rates of today and future days may still change
+	historical := key < dateKey(NowFunc())
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if day := s.days[key]; day != nil {
+		return day, nil
+	}
+
+	if historical {
+		day, err := s.load(key)
+		if err != nil {
+			logger.ContextKV(ctx, xlog.WARNING, "reason", "load_cache", "date", key, "err", err.Error())
+		} else if day != nil {
+			s.days[key] = day
+			return day, nil
+		}
+	}
+
+	if s.offline {
+		return nil, errors.WithMessagef(bogapi.ErrNotCached, "offline: official rates on %s", key)
+	}
+	day, err := s.fetch(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+
+	if historical {
+		s.days[key] = day
+		if err = s.save(key, day); err != nil {
+			logger.ContextKV(ctx, xlog.WARNING, "reason", "save_cache", "date", key, "err", err.Error())
+		}
+	}
+	return day, nil
+}
+
+// Rates returns official rates of the currencies for the date,
+// and commercial rates if the date is today and the commercial source is set
+func (s *Service) Rates(ctx context.Context, date time.Time, currencies ...string) ([]*Rate, error) {
+	day, err := s.OfficialRates(ctx, date)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(currencies) == 0 {
+		for currency := range day {
+			currencies = append(currencies, currency)
+		}
+		sort.Strings(currencies)
+	}
+
+	key := dateKey(date)
+	today := key == dateKey(NowFunc())
+	var list []*Rate
+	for _, currency := range currencies {
+		currency = strings.ToUpper(currency)
+		official := day[currency]
+		if official == nil {
+			return nil, errors.Errorf("official rate not found: %s on %s", currency, key)
+		}
+
+		rate := &Rate{
+			Currency: currency,
+			Date:     key,
+			Official: official.PerUnit(),
+		}
+		if today && s.commercial != nil {
+			commercial, err := s.Commercial(ctx, currency)
+			if err != nil {
+				return nil, err
+			}
+			rate.Buy = commercial.Buy
+			rate.Sell = commercial.Sell
+		}
+		list = append(list, rate)
+	}
+	return list, nil
+}
+
+func (s *Service) fetch(ctx context.Context, date string) (map[string]*OfficialRate, error) {
+	u := s.nbgURL + "?date=" + url.QueryEscape(date)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create request")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to get official rates")
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read response")
+	}
+	if resp.StatusCode != http.StatusOK {
+		logger.ContextKV(ctx, xlog.ERROR,
+			"date", date,
+			"status", resp.StatusCode,
+			"body", string(body),
+		)
+		return nil, errors.Errorf("failed to get official rates on %s: %d %s", date, resp.StatusCode, http.StatusText(resp.StatusCode))
+	}
+
+	var res []*nbgResponse
+	if err = json.Unmarshal(body, &res); err != nil {
+		return nil, errors.WithMessage(err, "failed to decode official rates")
+	}
+
+	day := make(map[string]*OfficialRate)
+	for _, r := range res {
+		for _, rate := range r.Currencies {
+			day[rate.Currency] = rate
+		}
+	}
+	if len(day) == 0 {
+		return nil, errors.Errorf("official rates not found on %s", date)
+	}
+	return day, nil
+}
+
+func (s *Service) file(date string) string {
+	return filepath.Join(s.dir, "nbg", date+".json")
+}
+
+// load returns rates from the disk cache, or nil if not cached
+func (s *Service) load(date string) (map[string]*OfficialRate, error) {
+	if s.dir == "" || fileutil.FileExists(s.file(date)) != nil {
+		return nil, nil
+	}
+
+	data, err := os.ReadFile(s.file(date))
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read file")
+	}
+	var day map[string]*OfficialRate
+	if err = json.Unmarshal(data, &day); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal rates")
+	}
+	return day, nil
+}
+
+func (s *Service) save(date string, day map[string]*OfficialRate) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(day, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal rates")
+	}
+	if err = os.MkdirAll(filepath.Dir(s.file(date)), 0755); err != nil {
+		return errors.WithMessage(err, "failed to create folder")
+	}
+	if err = os.WriteFile(s.file(date), data, 0644); err != nil {
+		return errors.WithMessage(err, "failed to write file")
+	}
+	return nil
+}
+
+// dateKey returns the date in DateFormat, in the time zone of the bank
+func dateKey(date time.Time) string {
+	return date.In(bogapi.Location).Format(DateFormat)
+}
+
+// ParseDate parses the date in DateFormat, or returns today if empty,
+// in the time zone of the bank
+func ParseDate(value string) (time.Time, error) {
+	if value == "" {
+		now := NowFunc().In(bogapi.Location)
+		return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, bogapi.Location), nil
+	}
+	date, err := time.ParseInLocation(DateFormat, value, bogapi.Location)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date: %s, expected %s", value, DateFormat)
+	}
+	return date, nil
+}
@@ -0,0 +1,196 @@
+package rates_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+	"github.com/tbilicode/bogclient/pkg/rates"
+)
+
+func newTestServer(t *testing.T) *bogtest.Server {
+	srv := bogtest.NewServer()
+	t.Cleanup(srv.Close)
+
+	srv.SetOfficialRate("2025-02-18", "EUR", 1, 2.9284)
+	srv.SetOfficialRate("2025-02-19", "EUR", 1, 2.9361)
+	srv.SetOfficialRate("2025-02-19", "USD", 1, 2.8123)
+	srv.SetOfficialRate("2025-02-19", "JPY", 100, 1.8512)
+	return srv
+}
+
+func TestOfficial(t *testing.T) {
+	srv := newTestServer(t)
+	dir := t.TempDir()
+	svc := rates.New().
+		WithNBGURL(srv.URL + bogtest.NBGPath).
+		WithCacheDir(dir)
+	ctx := context.Background()
+	date := time.Date(2025, 2, 19, 0, 0, 0, 0, time.UTC)
+
+	rate, err := svc.Official(ctx, "usd", date)
+	require.NoError(t, err)
+	assert.Equal(t, 2.8123, rate.PerUnit())
+
+	rate, err = svc.Official(ctx, "JPY", date)
+	require.NoError(t, err)
+	assert.InDelta(t, 0.018512, rate.PerUnit(), 1e-12)
+
+	rate, err = svc.Official(ctx, "GEL", date)
+	require.NoError(t, err)
+	assert.Equal(t, 1.0, rate.PerUnit())
+
+	_, err = svc.Official(ctx, "CHF", date)
+	assert.EqualError(t, err, "official rate not found: CHF on 2025-02-19")
+	assert.Equal(t, 1, srv.Requests(bogtest.NBGPath))
+
+	// weekends return the latest rates
+	rate, err = svc.Official(ctx, "EUR", date.AddDate(0, 0, 3))
+	require.NoError(t, err)
+	assert.Equal(t, 2.9361, rate.Rate)
+	assert.Equal(t, 2, srv.Requests(bogtest.NBGPath))
+
+	// a new service uses the disk cache
+	assert.FileExists(t, filepath.Join(dir, "nbg", "2025-02-19.json"))
+	svc = rates.New().
+		WithNBGURL(srv.URL + bogtest.NBGPath).
+		WithCacheDir(dir)
+	rate, err = svc.Official(ctx, "USD", date)
+	require.NoError(t, err)
+	assert.Equal(t, 2.8123, rate.Rate)
+	assert.Equal(t, 2, srv.Requests(bogtest.NBGPath))
+}
+
+func TestOfficial_Today(t *testing.T) {
+	srv := newTestServer(t)
+	today := time.Now().Format(rates.DateFormat)
+	srv.SetOfficialRate(today, "USD", 1, 2.7)
+
+	dir := t.TempDir()
+	svc := rates.New().
+		WithNBGURL(srv.URL + bogtest.NBGPath).
+		WithCacheDir(dir)
+	ctx := context.Background()
+
+	date, err := rates.ParseDate("")
+	require.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		rate, err := svc.Official(ctx, "USD", date)
+		require.NoError(t, err)
+		assert.Equal(t, 2.7, rate.Rate)
+	}
+	// today rates are not cached
+	assert.Equal(t, 2, srv.Requests(bogtest.NBGPath))
+	assert.NoFileExists(t, filepath.Join(dir, "nbg", today+".json"))
+}
+
+func TestRates(t *testing.T) {
+	srv := newTestServer(t)
+	srv.SetRate("USD", 2.78, 2.84)
+	srv.SetRate("EUR", 2.90, 2.97)
+	now := time.Now().In(bogapi.Location).Format(rates.DateFormat)
+	srv.SetOfficialRate(now, "USD", 1, 2.81)
+	srv.SetOfficialRate(now, "EUR", 1, 2.93)
+
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, srv.WriteConfig(cfgFile))
+	client, err := bogapi.CreateClient(cfgFile, 6)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	svc := rates.New().
+		WithNBGURL(srv.URL + bogtest.NBGPath).
+		WithCommercial(client)
+
+	today, err := rates.ParseDate("")
+	require.NoError(t, err)
+	list, err := svc.Rates(ctx, today)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, &rates.Rate{Currency: "EUR", Date: today.Format(rates.DateFormat), Official: 2.93, Buy: 2.90, Sell: 2.97}, list[0])
+	assert.Equal(t, 2.84, list[1].Sell)
+
+	// commercial rates are not available for past days
+	list, err = svc.Rates(ctx, time.Date(2025, 2, 19, 0, 0, 0, 0, time.UTC), "USD")
+	require.NoError(t, err)
+	assert.Equal(t, []*rates.Rate{{Currency: "USD", Date: "2025-02-19", Official: 2.8123}}, list)
+
+	_, err = rates.New().Commercial(ctx, "USD")
+	assert.EqualError(t, err, "commercial rates are not available: BOG credentials are not configured")
+
+	_, err = rates.ParseDate("19.02.2025")
+	assert.EqualError(t, err, "invalid date: 19.02.2025, expected 2006-01-02")
+}
+
+func TestParseDate(t *testing.T) {
+	// late evening in UTC is already the next day in Tbilisi
+	rates.NowFunc = func() time.Time {
+		return time.Date(2025, 2, 19, 21, 30, 0, 0, time.UTC)
+	}
+	defer func() {
+		rates.NowFunc = time.Now
+	}()
+
+	today, err := rates.ParseDate("")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 2, 20, 0, 0, 0, 0, bogapi.Location), today)
+
+	date, err := rates.ParseDate("2025-02-19")
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 2, 19, 0, 0, 0, 0, bogapi.Location), date)
+}
+
+func TestCheckStatements(t *testing.T) {
+	srv := newTestServer(t)
+	svc := rates.New().WithNBGURL(srv.URL + bogtest.NBGPath)
+
+	data, err := os.ReadFile("../bogapi/testdata/statement_feb.json")
+	require.NoError(t, err)
+	var doc bogapi.AccountStatements
+	require.NoError(t, json.Unmarshal(data, &doc))
+
+	ctx := context.Background()
+	res, err := svc.CheckStatements(ctx, &doc, 1)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "2025-02-19", res[0].Date)
+	assert.Equal(t, "EUR", res[0].Currency)
+	assert.Equal(t, 2.893, res[0].DocumentRate)
+	assert.Equal(t, 2.9361, res[0].OfficialRate)
+	assert.Equal(t, -1.47, res[0].Percent)
+
+	res, err = svc.CheckStatements(ctx, &doc, 2)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	// USD to EUR conversion is compared with the official USD/EUR cross rate
+	date := bogapi.Time(time.Date(2025, 2, 19, 0, 0, 0, 0, time.UTC))
+	cross := &bogapi.AccountStatements{Combined: []*bogapi.AccountStatement{{
+		Account:  "GE12BG0000000106360002",
+		Currency: "USD",
+		Records: []bogapi.Record{{
+			EntryDate:                   date,
+			EntryDocumentNumber:         "1",
+			DocumentSourceCurrency:      "USD",
+			DocumentDestinationCurrency: "EUR",
+			DocumentRate:                0.95,
+		}},
+	}}}
+	res, err = svc.CheckStatements(ctx, cross, 1)
+	require.NoError(t, err)
+	assert.Empty(t, res)
+
+	res, err = svc.CheckStatements(ctx, cross, 0.5)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	assert.Equal(t, "USD/EUR", res[0].Currency)
+	assert.InDelta(t, 2.8123/2.9361, res[0].OfficialRate, 1e-9)
+	assert.Equal(t, -0.82, res[0].Percent)
+}
@@ -0,0 +1,125 @@
+// Package secrets resolves secret references in the configuration,
+// such as env:BOG_SECRET, file:/run/secrets/bog or exec:pass show bog/secret,
+// and provides an encrypted secrets file unlocked by a passphrase.
+package secrets
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+// ExecTimeout specifies how long exec: references may run
+var ExecTimeout = time.Minute
+
+// Resolver returns the secret for the reference value, without the scheme prefix
+type Resolver func(value string) (string, error)
+
+var (
+	lock      sync.RWMutex
+	resolvers = map[string]Resolver{
+		"env":  fromEnv,
+		"file": fromFile,
+		"exec": fromExec,
+	}
+)
+
+// Register adds or replaces the resolver for the scheme,
+// such as "secret" for the encrypted secrets file
+func Register(scheme string, r Resolver) {
+	lock.Lock()
+	defer lock.Unlock()
+	resolvers[scheme] = r
+}
+
+// resolver returns the resolver and the value of the reference,
+// or nil if the value is not a reference
+func resolver(value string) (Resolver, string) {
+	scheme, ref, ok := strings.Cut(value, ":")
+	if !ok {
+		return nil, ""
+	}
+
+	lock.RLock()
+	defer lock.RUnlock()
+	return resolvers[scheme], ref
+}
+
+// IsReference returns true if the value starts with a registered scheme
+func IsReference(value string) bool {
+	r, _ := resolver(value)
+	return r != nil
+}
+
+// Resolve returns the secret for the reference,
+// or the value itself if it does not start with a registered scheme
+func Resolve(value string) (string, error) {
+	r, ref := resolver(value)
+	if r == nil {
+		return value, nil
+	}
+
+	secret, err := r(ref)
+	if err != nil {
+		scheme, _, _ := strings.Cut(value, ":")
+		return "", errors.WithMessagef(err, "failed to resolve %s secret", scheme)
+	}
+	return secret, nil
+}
+
+func fromEnv(name string) (string, error) {
+	value := os.Getenv(name)
+	if value == "" {
+		return "", errors.Errorf("environment variable is not set: %s", name)
+	}
+	return value, nil
+}
+
+func fromFile(file string) (string, error) {
+	file, _ = homedir.Expand(file)
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return "", errors.WithMessage(err, "failed to read file")
+	}
+	value := strings.TrimRight(string(data), "\r\n")
+	if value == "" {
+		return "", errors.Errorf("file is empty: %s", file)
+	}
+	return value, nil
+}
+
+// fromExec runs the command without a shell, and returns the first line of its output,
+// as password managers like pass print the secret on the first line
+func fromExec(command string) (string, error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return "", errors.New("command is empty")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ExecTimeout)
+	defer cancel()
+
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// the command may ask for a passphrase, such as gpg-agent
+	cmd.Stdin = os.Stdin
+	cmd.Stderr = os.Stderr
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", errors.WithMessagef(err, "failed to run %s", args[0])
+	}
+
+	value, _, _ := strings.Cut(out.String(), "\n")
+	value = strings.TrimRight(value, "\r")
+	if value == "" {
+		return "", errors.Errorf("command returned empty output: %s", args[0])
+	}
+	return value, nil
+}
@@ -0,0 +1,87 @@
+package secrets_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/secrets"
+)
+
+func TestResolve(t *testing.T) {
+	t.Setenv("BOG_TEST_SECRET", "from-env")
+	file := filepath.Join(t.TempDir(), "secret")
+	require.NoError(t, os.WriteFile(file, []byte("from-file\n"), 0600))
+
+	tcases := []struct {
+		value string
+		exp   string
+		err   string
+	}{
+		{value: "plain", exp: "plain"},
+		{value: "https://example.com", exp: "https://example.com"},
+		{value: "env:BOG_TEST_SECRET", exp: "from-env"},
+		{value: "file:" + file, exp: "from-file"},
+		{value: "exec:echo from-exec", exp: "from-exec"},
+		{value: "env:BOG_TEST_MISSING", err: "failed to resolve env secret: environment variable is not set: BOG_TEST_MISSING"},
+		{value: "exec:", err: "failed to resolve exec secret: command is empty"},
+		{value: "exec:false", err: "failed to resolve exec secret: failed to run false: exit status 1"},
+	}
+	for _, tc := range tcases {
+		t.Run(tc.value, func(t *testing.T) {
+			value, err := secrets.Resolve(tc.value)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.exp, value)
+		})
+	}
+
+	assert.False(t, secrets.IsReference("plain"))
+	assert.True(t, secrets.IsReference("env:X"))
+
+	secrets.Register("test", func(value string) (string, error) {
+		return "test-" + value, nil
+	})
+	value, err := secrets.Resolve("test:x")
+	require.NoError(t, err)
+	assert.Equal(t, "test-x", value)
+}
+
+func TestStore(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "nested", secrets.StoreFile)
+
+	_, err := secrets.OpenStore(file, "")
+	assert.EqualError(t, err, "passphrase is required")
+
+	s, err := secrets.OpenStore(file, "pass")
+	require.NoError(t, err)
+	assert.Empty(t, s.Names())
+
+	s.Set("b", "secret-b")
+	s.Set("a", "secret-a")
+	require.NoError(t, s.Save())
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "secret-a")
+
+	_, err = secrets.OpenStore(file, "wrong")
+	assert.EqualError(t, err, "invalid passphrase or corrupted secrets file")
+
+	s, err = secrets.OpenStore(file, "pass")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, s.Names())
+	value, ok := s.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "secret-a", value)
+
+	assert.True(t, s.Delete("a"))
+	assert.False(t, s.Delete("a"))
+	_, ok = s.Get("a")
+	assert.False(t, ok)
+}
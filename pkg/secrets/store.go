@@ -0,0 +1,174 @@
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/effective-security/x/fileutil"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/scrypt"
+)
+
+// StoreFile is the name of the encrypted secrets file in the storage folder
+const StoreFile = "secrets.enc"
+
+// scrypt parameters recommended for interactive logins
+const (
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+	keySize = 32
+)
+
+// Store is a set of named secrets, kept in a file encrypted with AES-256-GCM
+// using a key derived from the passphrase with scrypt
+type Store struct {
+	file       string
+	passphrase string
+	values     map[string]string
+}
+
+// envelope is the content of the secrets file
+type envelope struct {
+	Version int    `json:"version"`
+	KDF     string `json:"kdf"`
+	N       int    `json:"n"`
+	R       int    `json:"r"`
+	P       int    `json:"p"`
+	Salt    []byte `json:"salt"`
+	Nonce   []byte `json:"nonce"`
+	Data    []byte `json:"data"`
+}
+
+// OpenStore decrypts the secrets file, or returns an empty store if the file does not exist
+func OpenStore(file, passphrase string) (*Store, error) {
+	if passphrase == "" {
+		return nil, errors.New("passphrase is required")
+	}
+
+	s := &Store{
+		file:       file,
+		passphrase: passphrase,
+		values:     make(map[string]string),
+	}
+	if fileutil.FileExists(file) != nil {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read file")
+	}
+
+	var env envelope
+	if err = json.Unmarshal(data, &env); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal secrets file")
+	}
+	if env.Version != 1 || env.KDF != "scrypt" {
+		return nil, errors.Errorf("unsupported secrets file: version %d, %s", env.Version, env.KDF)
+	}
+
+	gcm, err := newGCM(passphrase, env.Salt, env.N, env.R, env.P)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, env.Nonce, env.Data, nil)
+	if err != nil {
+		return nil, errors.New("invalid passphrase or corrupted secrets file")
+	}
+	if err = json.Unmarshal(plain, &s.values); err != nil {
+		return nil, errors.WithMessage(err, "failed to unmarshal secrets")
+	}
+	return s, nil
+}
+
+// Get returns the secret by name
+func (s *Store) Get(name string) (string, bool) {
+	value, ok := s.values[name]
+	return value, ok
+}
+
+// Set adds or replaces the secret, call Save to persist it
+func (s *Store) Set(name, value string) {
+	s.values[name] = value
+}
+
+// Delete removes the secret, and returns false if it does not exist
+func (s *Store) Delete(name string) bool {
+	_, ok := s.values[name]
+	delete(s.values, name)
+	return ok
+}
+
+// Names returns sorted names of the secrets
+func (s *Store) Names() []string {
+	names := make([]string, 0, len(s.values))
+	for name := range s.values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save encrypts the secrets with a new salt and writes the file
+func (s *Store) Save() error {
+	plain, err := json.Marshal(s.values)
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal secrets")
+	}
+
+	env := envelope{
+		Version: 1,
+		KDF:     "scrypt",
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, 16),
+	}
+	if _, err = rand.Read(env.Salt); err != nil {
+		return errors.WithMessage(err, "failed to generate salt")
+	}
+
+	gcm, err := newGCM(s.passphrase, env.Salt, env.N, env.R, env.P)
+	if err != nil {
+		return err
+	}
+	env.Nonce = make([]byte, gcm.NonceSize())
+	if _, err = rand.Read(env.Nonce); err != nil {
+		return errors.WithMessage(err, "failed to generate nonce")
+	}
+	env.Data = gcm.Seal(nil, env.Nonce, plain, nil)
+
+	data, err := json.MarshalIndent(env, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "failed to marshal secrets file")
+	}
+	if err = os.MkdirAll(filepath.Dir(s.file), 0700); err != nil {
+		return errors.WithMessage(err, "failed to create folder")
+	}
+	if err = os.WriteFile(s.file, data, 0600); err != nil {
+		return errors.WithMessage(err, "failed to write file")
+	}
+	return nil
+}
+
+func newGCM(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, keySize)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to derive key")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create cipher")
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to create cipher")
+	}
+	return gcm, nil
+}
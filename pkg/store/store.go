@@ -0,0 +1,285 @@
+// Package store provides the local database of statement records,
+// kept up to date by Sync, so the records can be used offline.
+package store
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"github.com/spaolacci/murmur3"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+var logger = xlog.NewPackageLogger("github.com/tbilicode/bogclient/pkg", "store")
+
+// DefaultFile is the name of the database in the storage folder
+const DefaultFile = "bog.db"
+
+var (
+	accountsBucket = []byte("accounts")
+	recordsBucket  = []byte("records")
+	syncedKey      = []byte("synced")
+)
+
+// Store keeps statement records by account and currency, keyed by EntryId
+type Store struct {
+	db *bolt.DB
+}
+
+// AccountStatus describes the stored records of the account and currency
+type AccountStatus struct {
+	Account  string `json:"account" yaml:"account"`
+	Currency string `json:"currency" yaml:"currency"`
+	Records  int    `json:"records" yaml:"records"`
+	// Synced is the last date fetched by Sync
+	Synced string `json:"synced,omitempty" yaml:"synced,omitempty"`
+}
+
+// Open opens or creates the database file
+func Open(file string) (*Store, error) {
+	db, err := bolt.Open(file, 0600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		if errors.Is(err, bolt.ErrTimeout) {
+			return nil, errors.Errorf("database is used by another process: %s", file)
+		}
+		return nil, errors.WithMessage(err, "failed to open database")
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(accountsBucket)
+		return err
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, errors.WithMessage(err, "failed to initialize database")
+	}
+	return &Store{db: db}, nil
+}
+
+// Close releases the database file
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+func accountKey(account, currency string) []byte {
+	return []byte(account + " " + currency)
+}
+
+// RecordKey returns the key of the record: EntryId, or a hash of the entry fields
+// for records without EntryId
+func RecordKey(r *bogapi.Record) []byte {
+	if r.EntryId != 0 {
+		key := make([]byte, 8)
+		binary.BigEndian.PutUint64(key, uint64(r.EntryId))
+		return key
+	}
+
+	h := murmur3.New64()
+	_, _ = fmt.Fprintf(h, "%s|%s|%f|%f|%s",
+		time.Time(r.EntryDate).Format(time.RFC3339),
+		r.EntryDocumentNumber,
+		r.EntryAmountDebit.Float64(),
+		r.EntryAmountCredit.Float64(),
+		r.EntryComment)
+	return []byte(fmt.Sprintf("h%016x", h.Sum64()))
+}
+
+// Put adds or replaces the records of the account, and returns the number of new records
+func (s *Store) Put(account, currency string, records []bogapi.Record) (int, error) {
+	added := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(accountsBucket).CreateBucketIfNotExists(accountKey(account, currency))
+		if err != nil {
+			return err
+		}
+		rb, err := b.CreateBucketIfNotExists(recordsBucket)
+		if err != nil {
+			return err
+		}
+
+		for i := range records {
+			key := RecordKey(&records[i])
+			data, err := json.Marshal(&records[i])
+			if err != nil {
+				return errors.WithMessage(err, "failed to marshal record")
+			}
+			if rb.Get(key) == nil {
+				added++
+			}
+			if err = rb.Put(key, data); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.WithMessagef(err, "failed to store records: %s %s", account, currency)
+	}
+	return added, nil
+}
+
+// Records returns the records of the account with the entry date within the period,
+// sorted by the entry date. Zero start or end means the period is not bounded.
+func (s *Store) Records(account, currency string, start, end time.Time) ([]bogapi.Record, error) {
+	var list []bogapi.Record
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket).Bucket(accountKey(account, currency))
+		if b == nil || b.Bucket(recordsBucket) == nil {
+			return nil
+		}
+		return b.Bucket(recordsBucket).ForEach(func(_, v []byte) error {
+			var r bogapi.Record
+			if err := json.Unmarshal(v, &r); err != nil {
+				return errors.WithMessage(err, "failed to unmarshal record")
+			}
+			if inPeriod(time.Time(r.EntryDate), start, end) {
+				list = append(list, r)
+			}
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to read records: %s %s", account, currency)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		di, dj := time.Time(list[i].EntryDate), time.Time(list[j].EntryDate)
+		if !di.Equal(dj) {
+			return di.Before(dj)
+		}
+		return list[i].EntryId < list[j].EntryId
+	})
+	return list, nil
+}
+
+// parseBound returns the date in DateFormat, or zero time for an empty date
+func parseBound(date string) (time.Time, error) {
+	if date == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.ParseInLocation(bogapi.DateFormat, date, bogapi.Location)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date: %s", date)
+	}
+	return t, nil
+}
+
+// inPeriod returns true if the date of t is within the dates of start and end, both inclusive
+func inPeriod(t, start, end time.Time) bool {
+	day := t.Format(bogapi.DateFormat)
+	if !start.IsZero() && day < start.Format(bogapi.DateFormat) {
+		return false
+	}
+	if !end.IsZero() && day > end.Format(bogapi.DateFormat) {
+		return false
+	}
+	return true
+}
+
+// Synced returns the last date fetched by Sync, or zero time if the account was never synced
+func (s *Store) Synced(account, currency string) (time.Time, error) {
+	var synced time.Time
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket).Bucket(accountKey(account, currency))
+		if b == nil || b.Get(syncedKey) == nil {
+			return nil
+		}
+		var err error
+		synced, err = time.ParseInLocation(bogapi.DateFormat, string(b.Get(syncedKey)), bogapi.Location)
+		return err
+	})
+	if err != nil {
+		return time.Time{}, errors.WithMessagef(err, "failed to read synced date: %s %s", account, currency)
+	}
+	return synced, nil
+}
+
+// SetSynced stores the last date fetched by Sync
+func (s *Store) SetSynced(account, currency string, date time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(accountsBucket).CreateBucketIfNotExists(accountKey(account, currency))
+		if err != nil {
+			return err
+		}
+		return b.Put(syncedKey, []byte(date.Format(bogapi.DateFormat)))
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to store synced date: %s %s", account, currency)
+	}
+	return nil
+}
+
+// Status returns the stored accounts, sorted by account and currency
+func (s *Store) Status() ([]*AccountStatus, error) {
+	var list []*AccountStatus
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(accountsBucket).ForEachBucket(func(k []byte) error {
+			account, currency, _ := strings.Cut(string(k), " ")
+			st := &AccountStatus{Account: account, Currency: currency}
+
+			b := tx.Bucket(accountsBucket).Bucket(k)
+			st.Synced = string(b.Get(syncedKey))
+			if rb := b.Bucket(recordsBucket); rb != nil {
+				st.Records = rb.Stats().KeyN
+			}
+			list = append(list, st)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed to read accounts")
+	}
+	return list, nil
+}
+
+// Statements returns the stored records of the accounts within the period of the request,
+// in the same form as bogapi.Client.AllStatements. Empty dates of the request
+// mean the period is not bounded. Summaries are built from the stored balances,
+// and left nil for accounts without stored balances.
+func (s *Store) Statements(accounts []bogapi.Account, req *bogapi.StatementRequest) (*bogapi.AccountStatements, error) {
+	start, err := parseBound(req.StartDate)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseBound(req.EndDate)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &bogapi.AccountStatements{}
+	for _, acc := range accounts {
+		if req.Account != "" && req.Account != acc.ID {
+			continue
+		}
+		for _, cur := range acc.Currency {
+			if req.Currency != "" && req.Currency != cur {
+				continue
+			}
+			records, err := s.Records(acc.ID, cur, start, end)
+			if err != nil {
+				return nil, err
+			}
+			st := &bogapi.AccountStatement{
+				Account:   acc.ID,
+				Currency:  cur,
+				StartDate: req.StartDate,
+				EndDate:   req.EndDate,
+				Records:   records,
+			}
+			if req.Summary {
+				if st.Summary, err = s.Summary(acc.ID, cur, start, end); err != nil {
+					return nil, err
+				}
+			}
+			res.Combined = append(res.Combined, st)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,209 @@
+package store_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	"github.com/tbilicode/bogclient/pkg/bogapi/bogtest"
+	"github.com/tbilicode/bogclient/pkg/store"
+	"gopkg.in/yaml.v3"
+)
+
+const testAccount = "GE35BG0000000106360001"
+
+func day(month time.Month, d int) bogapi.Time {
+	return bogapi.Time(time.Date(2024, month, d, 10, 0, 0, 0, time.UTC))
+}
+
+func openStore(t *testing.T) *store.Store {
+	s, err := store.Open(filepath.Join(t.TempDir(), store.DefaultFile))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close() })
+	return s
+}
+
+func TestStore_Records(t *testing.T) {
+	s := openStore(t)
+
+	added, err := s.Put(testAccount, "GEL", []bogapi.Record{
+		{EntryId: 3, EntryDate: day(11, 5), EntryDocumentNumber: "NOV"},
+		{EntryId: 1, EntryDate: day(10, 31), EntryDocumentNumber: "OCT"},
+		{EntryDate: day(12, 1), EntryDocumentNumber: "NO-ID", EntryAmountDebit: bogapi.MoneyFromFloat(5)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 3, added)
+
+	// the same entries replace the stored ones
+	added, err = s.Put(testAccount, "GEL", []bogapi.Record{
+		{EntryId: 3, EntryDate: day(11, 5), EntryDocumentNumber: "NOV", EntryComment: "updated"},
+		{EntryDate: day(12, 1), EntryDocumentNumber: "NO-ID", EntryAmountDebit: bogapi.MoneyFromFloat(5)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 0, added)
+
+	list, err := s.Records(testAccount, "GEL", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "OCT", list[0].EntryDocumentNumber)
+	assert.Equal(t, "updated", list[1].EntryComment)
+	assert.Equal(t, "NO-ID", list[2].EntryDocumentNumber)
+
+	start := time.Date(2024, 11, 1, 0, 0, 0, 0, bogapi.Location)
+	end := time.Date(2024, 11, 30, 0, 0, 0, 0, bogapi.Location)
+	list, err = s.Records(testAccount, "GEL", start, end)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, "NOV", list[0].EntryDocumentNumber)
+
+	list, err = s.Records(testAccount, "USD", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	synced, err := s.Synced(testAccount, "GEL")
+	require.NoError(t, err)
+	assert.True(t, synced.IsZero())
+	require.NoError(t, s.SetSynced(testAccount, "GEL", end))
+	synced, err = s.Synced(testAccount, "GEL")
+	require.NoError(t, err)
+	assert.Equal(t, "2024-11-30", synced.Format(bogapi.DateFormat))
+
+	status, err := s.Status()
+	require.NoError(t, err)
+	assert.Equal(t, []*store.AccountStatus{
+		{Account: testAccount, Currency: "GEL", Records: 3, Synced: "2024-11-30"},
+	}, status)
+
+	res, err := s.Statements([]bogapi.Account{{ID: testAccount, Currency: []string{"GEL", "USD"}}},
+		&bogapi.StatementRequest{StartDate: "2024-10-01", EndDate: "2024-11-30", Currency: "GEL"})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 1)
+	assert.Equal(t, "2024-10-01", res.Combined[0].StartDate)
+	assert.Len(t, res.Combined[0].Records, 2)
+
+	// a single bound leaves the other side of the period open
+	res, err = s.Statements([]bogapi.Account{{ID: testAccount, Currency: []string{"GEL"}}},
+		&bogapi.StatementRequest{StartDate: "2024-11-01"})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 1)
+	assert.Len(t, res.Combined[0].Records, 2)
+
+	res, err = s.Statements([]bogapi.Account{{ID: testAccount, Currency: []string{"GEL"}}},
+		&bogapi.StatementRequest{EndDate: "2024-10-31"})
+	require.NoError(t, err)
+	require.Len(t, res.Combined, 1)
+	assert.Len(t, res.Combined[0].Records, 1)
+
+	_, err = s.Statements([]bogapi.Account{{ID: testAccount, Currency: []string{"GEL"}}},
+		&bogapi.StatementRequest{StartDate: "2024-13-01"})
+	assert.EqualError(t, err, "invalid date: 2024-13-01")
+}
+
+func TestStore_Locked(t *testing.T) {
+	file := filepath.Join(t.TempDir(), store.DefaultFile)
+	s, err := store.Open(file)
+	require.NoError(t, err)
+	defer s.Close()
+
+	_, err = store.Open(file)
+	assert.EqualError(t, err, "database is used by another process: "+file)
+}
+
+func TestStore_Sync(t *testing.T) {
+	bogapi.NowFunc = func() time.Time {
+		return time.Date(2024, 12, 20, 12, 0, 0, 0, time.UTC)
+	}
+	defer func() {
+		bogapi.NowFunc = time.Now
+	}()
+
+	srv := bogtest.NewServer()
+	defer srv.Close()
+	srv.AddRecords(testAccount, "GEL",
+		bogapi.Record{EntryId: 1, EntryDate: day(10, 31), EntryDocumentNumber: "OCT", EntryAmountCredit: bogapi.MoneyFromFloat(50)},
+		bogapi.Record{EntryId: 2, EntryDate: day(11, 5), EntryDocumentNumber: "NOV", EntryAmountDebit: bogapi.MoneyFromFloat(20)},
+		bogapi.Record{EntryId: 3, EntryDate: day(12, 18), EntryDocumentNumber: "DEC", EntryAmountCredit: bogapi.MoneyFromFloat(10)},
+	)
+	srv.SetOpeningBalance(testAccount, "GEL", bogapi.MoneyFromFloat(100))
+	cfg := srv.Config()
+	cfg.Accounts = []bogapi.Account{{ID: testAccount, Currency: []string{"GEL", "USD"}}}
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	cfgFile := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(cfgFile, data, 0600))
+	client, err := bogapi.CreateClient(cfgFile, 6)
+	require.NoError(t, err)
+
+	s := openStore(t)
+	ctx := context.Background()
+	req := &store.SyncRequest{
+		Currency: "GEL",
+		Since:    time.Date(2024, 11, 1, 0, 0, 0, 0, bogapi.Location),
+		Overlap:  store.DefaultOverlap,
+	}
+
+	res, err := s.Sync(ctx, client, req)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.SyncResult{
+		{Account: testAccount, Currency: "GEL", From: "2024-11-01", To: "2024-12-20", Fetched: 2, Added: 2},
+	}, res)
+
+	// a late booking within the overlap is picked up by the next sync
+	srv.AddRecords(testAccount, "GEL",
+		bogapi.Record{EntryId: 4, EntryDate: day(12, 19), EntryDocumentNumber: "LATE", EntryAmountDebit: bogapi.MoneyFromFloat(5)},
+	)
+	res, err = s.Sync(ctx, client, req)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.SyncResult{
+		{Account: testAccount, Currency: "GEL", From: "2024-12-17", To: "2024-12-20", Fetched: 2, Added: 1},
+	}, res)
+
+	list, err := s.Records(testAccount, "GEL", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, list, 3)
+	assert.Equal(t, "LATE", list[2].EntryDocumentNumber)
+
+	// summaries are built from the balances stored by the syncs
+	accounts := []bogapi.Account{{ID: testAccount, Currency: []string{"GEL"}}}
+	summary := func(start, end string) *bogapi.StatementSummary {
+		st, err := s.Statements(accounts, &bogapi.StatementRequest{StartDate: start, EndDate: end, Summary: true})
+		require.NoError(t, err)
+		require.Len(t, st.Combined, 1)
+		require.NotNil(t, st.Combined[0].Summary)
+		return st.Combined[0].Summary
+	}
+	g := summary("2024-11-01", "2024-11-30").GlobalSummary
+	assert.Equal(t, "150.00", g.InAmount.Format())
+	assert.Equal(t, "130.00", g.OutAmount.Format())
+	assert.Equal(t, "20.00", g.DebitSum.Format())
+
+	dec := summary("2024-12-01", "")
+	assert.Equal(t, "130.00", dec.GlobalSummary.InAmount.Format())
+	assert.Equal(t, "135.00", dec.GlobalSummary.OutAmount.Format())
+	assert.Equal(t, "10.00", dec.GlobalSummary.CreditSum.Format())
+	assert.Equal(t, "5.00", dec.GlobalSummary.DebitSum.Format())
+	assert.Len(t, dec.DailySummaries, 2)
+
+	g = summary("", "").GlobalSummary
+	assert.Equal(t, "150.00", g.InAmount.Format())
+	assert.Equal(t, "135.00", g.OutAmount.Format())
+
+	// the failed account is reported, and is not marked as synced
+	req.Currency = ""
+	res, err = s.Sync(ctx, client, req)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Empty(t, res[0].Error)
+	assert.NotEmpty(t, res[1].Error)
+	synced, err := s.Synced(testAccount, "USD")
+	require.NoError(t, err)
+	assert.True(t, synced.IsZero())
+
+	_, err = s.Sync(ctx, client, &store.SyncRequest{Account: "GE29NB0000000101904917"})
+	assert.EqualError(t, err, "no accounts to sync")
+}
@@ -0,0 +1,123 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+	bolt "go.etcd.io/bbolt"
+)
+
+var balancesBucket = []byte("balances")
+
+// PutSummary stores the end of day balances of the statement summary,
+// and the opening balance as the balance of the day before start,
+// so the summaries of stored periods can be built offline
+func (s *Store) PutSummary(account, currency string, summary *bogapi.StatementSummary, start time.Time) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.Bucket(accountsBucket).CreateBucketIfNotExists(accountKey(account, currency))
+		if err != nil {
+			return err
+		}
+		bb, err := b.CreateBucketIfNotExists(balancesBucket)
+		if err != nil {
+			return err
+		}
+
+		put := func(d *bogapi.DailySummary) error {
+			data, err := json.Marshal(d)
+			if err != nil {
+				return errors.WithMessage(err, "failed to marshal daily summary")
+			}
+			return bb.Put([]byte(time.Time(d.Date).Format(bogapi.DateFormat)), data)
+		}
+
+		// the day before start may have its own movements, stored by a previous sync
+		before := start.AddDate(0, 0, -1)
+		if bb.Get([]byte(before.Format(bogapi.DateFormat))) == nil {
+			g := &summary.GlobalSummary
+			if err = put(&bogapi.DailySummary{
+				Date:        bogapi.Time(before),
+				Balance:     g.InAmount,
+				BalanceBase: g.InAmountBase,
+			}); err != nil {
+				return err
+			}
+		}
+		for i := range summary.DailySummaries {
+			if err = put(&summary.DailySummaries[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return errors.WithMessagef(err, "failed to store balances: %s %s", account, currency)
+	}
+	return nil
+}
+
+// Summary returns the summary of the account within the period, built from the stored
+// balances and records, or nil if no balances are stored. Zero start or end mean
+// the period is not bounded.
+func (s *Store) Summary(account, currency string, start, end time.Time) (*bogapi.StatementSummary, error) {
+	var days []bogapi.DailySummary
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket(accountsBucket).Bucket(accountKey(account, currency))
+		if b == nil || b.Bucket(balancesBucket) == nil {
+			return nil
+		}
+		return b.Bucket(balancesBucket).ForEach(func(_, v []byte) error {
+			var d bogapi.DailySummary
+			if err := json.Unmarshal(v, &d); err != nil {
+				return errors.WithMessage(err, "failed to unmarshal daily summary")
+			}
+			days = append(days, d)
+			return nil
+		})
+	})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to read balances: %s %s", account, currency)
+	}
+	if len(days) == 0 {
+		return nil, nil
+	}
+
+	res := &bogapi.StatementSummary{
+		GlobalSummary: bogapi.GlobalSummary{
+			AccountNumber: account,
+			Currency:      currency,
+			StartDate:     bogapi.Time(start),
+			EndDate:       bogapi.Time(end),
+		},
+	}
+	g := &res.GlobalSummary
+
+	// the opening balance is the balance of the last day before start, or the balance
+	// before the movements of the first stored day, as no records are stored before it
+	first := days[0]
+	g.InAmount = first.Balance.Sub(first.CreditSum).Add(first.DebitSum)
+	if first.EntryCount == 0 {
+		g.InAmountBase = first.BalanceBase
+	}
+	g.OutAmount, g.OutAmountBase = g.InAmount, g.InAmountBase
+
+	startDay, endDay := start.Format(bogapi.DateFormat), end.Format(bogapi.DateFormat)
+	for _, d := range days {
+		day := time.Time(d.Date).Format(bogapi.DateFormat)
+		switch {
+		case !start.IsZero() && day < startDay:
+			g.InAmount, g.InAmountBase = d.Balance, d.BalanceBase
+			g.OutAmount, g.OutAmountBase = d.Balance, d.BalanceBase
+		case end.IsZero() || day <= endDay:
+			g.OutAmount, g.OutAmountBase = d.Balance, d.BalanceBase
+			g.CreditSum = g.CreditSum.Add(d.CreditSum)
+			g.DebitSum = g.DebitSum.Add(d.DebitSum)
+			if d.EntryCount > 0 {
+				res.DailySummaries = append(res.DailySummaries, d)
+			}
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,139 @@
+package store
+
+import (
+	"context"
+	"time"
+
+	"github.com/effective-security/xlog"
+	"github.com/pkg/errors"
+	"github.com/tbilicode/bogclient/pkg/bogapi"
+)
+
+// DefaultOverlap specifies how many days before the last synced date are fetched again,
+// to pick up late bookings
+const DefaultOverlap = 3
+
+// SyncRequest specifies accounts and the period to sync
+type SyncRequest struct {
+	// Account to filter by, empty for all
+	Account string
+	// Currency to filter by, empty for all
+	Currency string
+	// Since specifies the start of the first sync of an account
+	Since time.Time
+	// Overlap specifies how many days before the last synced date are fetched again
+	Overlap int
+	// Full ignores the last synced date, and fetches from Since
+	Full bool
+	// ChunkWorkers specifies the number of monthly chunks fetched concurrently
+	ChunkWorkers int
+}
+
+// SyncResult describes the sync of one account and currency
+type SyncResult struct {
+	Account  string `json:"account" yaml:"account"`
+	Currency string `json:"currency" yaml:"currency"`
+	From     string `json:"from" yaml:"from"`
+	To       string `json:"to" yaml:"to"`
+	// Fetched is the number of records returned by the API
+	Fetched int `json:"fetched" yaml:"fetched"`
+	// Added is the number of records not stored before
+	Added int    `json:"added" yaml:"added"`
+	Error string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Sync fetches statements of the configured accounts from the last synced date,
+// and stores the records. Accounts are synced one by one, failed accounts are reported
+// in the results, and do not stop the others.
+func (s *Store) Sync(ctx context.Context, client bogapi.Client, req *SyncRequest) ([]*SyncResult, error) {
+	now := bogapi.NowFunc().In(bogapi.Location)
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, bogapi.Location)
+
+	var results []*SyncResult
+	for _, acc := range client.Accounts() {
+		if req.Account != "" && req.Account != acc.ID {
+			continue
+		}
+		for _, cur := range acc.Currency {
+			if req.Currency != "" && req.Currency != cur {
+				continue
+			}
+			res, err := s.syncAccount(ctx, client, req, acc.ID, cur, today)
+			if err != nil {
+				logger.ContextKV(ctx, xlog.ERROR,
+					"account", acc.ID,
+					"currency", cur,
+					"err", err.Error(),
+				)
+				res.Error = err.Error()
+			}
+			results = append(results, res)
+		}
+	}
+	if len(results) == 0 {
+		return nil, errors.New("no accounts to sync")
+	}
+	return results, nil
+}
+
+func (s *Store) syncAccount(ctx context.Context, client bogapi.Client, req *SyncRequest, account, currency string, today time.Time) (*SyncResult, error) {
+	start := req.Since
+	if !req.Full {
+		synced, err := s.Synced(account, currency)
+		if err != nil {
+			return &SyncResult{Account: account, Currency: currency}, err
+		}
+		if !synced.IsZero() {
+			start = synced.AddDate(0, 0, -req.Overlap)
+		}
+	}
+	if start.IsZero() || start.After(today) {
+		start = today
+	}
+
+	res := &SyncResult{
+		Account:  account,
+		Currency: currency,
+		From:     start.Format(bogapi.DateFormat),
+		To:       today.Format(bogapi.DateFormat),
+	}
+
+	st, err := client.AllStatements(ctx, &bogapi.StatementRequest{
+		Account:   account,
+		Currency:  currency,
+		StartDate: res.From,
+		EndDate:   res.To,
+		Summary:   true,
+		// late bookings within the overlap are not in the cached statements
+		NoCache:      true,
+		Workers:      1,
+		ChunkWorkers: req.ChunkWorkers,
+	})
+	if err != nil {
+		return res, err
+	}
+
+	for _, ast := range st.Combined {
+		res.Fetched += len(ast.Records)
+		added, err := s.Put(account, currency, ast.Records)
+		if err != nil {
+			return res, err
+		}
+		res.Added += added
+		if ast.Summary != nil {
+			if err = s.PutSummary(account, currency, ast.Summary, start); err != nil {
+				return res, err
+			}
+		}
+	}
+
+	logger.ContextKV(ctx, xlog.DEBUG,
+		"account", account,
+		"currency", currency,
+		"from", res.From,
+		"to", res.To,
+		"fetched", res.Fetched,
+		"added", res.Added,
+	)
+	return res, s.SetSynced(account, currency, today)
+}